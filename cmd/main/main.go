@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/JonathanTriC/nomie-api/internal/config"
 	"github.com/JonathanTriC/nomie-api/internal/database"
+	meals_jobs "github.com/JonathanTriC/nomie-api/internal/modules/meals/jobs"
 	"github.com/JonathanTriC/nomie-api/internal/server"
 	"github.com/JonathanTriC/nomie-api/pkg/logger"
 )
@@ -28,8 +34,12 @@ func main() {
 	}
 	defer db.DB.Close()
 
+	// Cancelled on shutdown to stop periodic background jobs
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Init server router
-	r := server.SetupRouter(db, cfg)
+	r := server.SetupRouter(ctx, db, cfg)
 
 	// Start server
 	serverAddr := cfg.Server.Host + ":" + cfg.Server.Port
@@ -42,7 +52,23 @@ func main() {
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
 
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatal("Server failed:", err)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.InfoLogger.Println("Server shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.ErrorLogger.Println("Server shutdown failed:", err)
 	}
+
+	// No request can queue work any more; let background jobs finish before
+	// the database closes
+	meals_jobs.Stop()
+	meals_jobs.Wait()
 }
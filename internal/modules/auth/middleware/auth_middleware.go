@@ -1,6 +1,7 @@
 package auth_middleware
 
 import (
+	"database/sql"
 	"net/http"
 	"strings"
 	"time"
@@ -8,6 +9,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/time/rate"
+
+	"github.com/JonathanTriC/nomie-api/internal/database"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
 )
 
 // AuthMiddleware verifies JWT tokens in incoming requests
@@ -78,6 +82,34 @@ func AuthMiddleware(jwtSecret []byte) gin.HandlerFunc {
     }
 }
 
+// AdminMiddleware only lets users flagged as admins through. It must run after
+// AuthMiddleware so the user ID is in the context.
+func AdminMiddleware(db *database.Database) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        userID, ok := utils.GetUserID(c)
+        if !ok {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+            c.Abort()
+            return
+        }
+
+        var isAdmin bool
+        err := db.DB.QueryRow(`SELECT is_admin FROM users WHERE id = $1`, userID).Scan(&isAdmin)
+        if err != nil && err != sql.ErrNoRows {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
+            c.Abort()
+            return
+        }
+        if !isAdmin {
+            c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+            c.Abort()
+            return
+        }
+
+        c.Next()
+    }
+}
+
 // RateLimiter middleware to prevent brute force attacks
 func RateLimiter() gin.HandlerFunc {
     limiter := rate.NewLimiter(rate.Every(time.Second), 10)
@@ -0,0 +1,285 @@
+package meals_handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	meals_repository "github.com/JonathanTriC/nomie-api/internal/modules/meals/repository"
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetCollections(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	collections, err := h.service.GetCollections(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
+
+func (h *Handler) CreateCollection(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	name, ok := collectionName(c)
+	if !ok {
+		return
+	}
+
+	collection, err := h.service.CreateCollection(userID, name)
+	if err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Collection created successfully",
+		"collection": collection,
+	})
+}
+
+func (h *Handler) GetCollection(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	collectionID, ok := collectionParam(c, "collectionId")
+	if !ok {
+		return
+	}
+	limit, page := pageQuery(c)
+
+	result, err := h.service.GetCollection(userID, collectionID, limit, page)
+	if err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) RenameCollection(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	collectionID, ok := collectionParam(c, "collectionId")
+	if !ok {
+		return
+	}
+	name, ok := collectionName(c)
+	if !ok {
+		return
+	}
+
+	collection, err := h.service.RenameCollection(userID, collectionID, name)
+	if err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Collection renamed successfully",
+		"collection": collection,
+	})
+}
+
+func (h *Handler) DeleteCollection(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	collectionID, ok := collectionParam(c, "collectionId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteCollection(userID, collectionID); err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+}
+
+func (h *Handler) AddCollectionMeal(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	collectionID, ok := collectionParam(c, "collectionId")
+	if !ok {
+		return
+	}
+
+	var req meals_models.CollectionMealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection, err := h.service.AddCollectionMeal(userID, collectionID, strings.TrimSpace(req.MealID))
+	if err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Meal added to collection",
+		"collection": collection,
+	})
+}
+
+func (h *Handler) RemoveCollectionMeal(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	collectionID, ok := collectionParam(c, "collectionId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveCollectionMeal(userID, collectionID, c.Param("mealId")); err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Meal removed from collection"})
+}
+
+func (h *Handler) MoveCollectionMeal(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	collectionID, ok := collectionParam(c, "collectionId")
+	if !ok {
+		return
+	}
+
+	var req meals_models.CollectionMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.MoveCollectionMeal(userID, collectionID, req.ToCollectionID, c.Param("mealId")); err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Meal moved successfully"})
+}
+
+func (h *Handler) ReorderCollection(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	collectionID, ok := collectionParam(c, "collectionId")
+	if !ok {
+		return
+	}
+
+	var req meals_models.CollectionOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ReorderCollection(userID, collectionID, req.MealIDs); err != nil {
+		h.collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection reordered successfully"})
+}
+
+func (h *Handler) collectionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, meals_services.ErrCollectionNotFound),
+		errors.Is(err, meals_services.ErrMealNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, meals_repository.ErrCollectionExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, meals_repository.ErrCollectionOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func collectionParam(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a number"})
+		return 0, false
+	}
+	return id, true
+}
+
+func collectionName(c *gin.Context) (string, bool) {
+	var req meals_models.CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return "", false
+	}
+	return name, true
+}
+
+// pageQuery reads the limit and page query parameters
+func pageQuery(c *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	return limit, page
+}
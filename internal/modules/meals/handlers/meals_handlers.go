@@ -1,30 +1,41 @@
 package meals_handlers
 
 import (
+	"errors"
+	"fmt"
 	"math"
-	"mime/multipart"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/JonathanTriC/nomie-api/internal/database"
+	meals_jobs "github.com/JonathanTriC/nomie-api/internal/modules/meals/jobs"
 	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
 	meals_repository "github.com/JonathanTriC/nomie-api/internal/modules/meals/repository"
 	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
 	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/measure"
+	"github.com/JonathanTriC/nomie-api/pkg/recipeexport"
 	"github.com/gin-gonic/gin"
 )
 
+// maxCaptionLength is the longest caption accepted for a review image
+const maxCaptionLength = 200
+
 type Handler struct {
 	service   meals_services.Service
 	repository meals_repository.Repository
+	lastSeen  *meals_jobs.LastSeenRecorder
 	db        *database.Database
 	jwtSecret []byte
 }
 
-func NewHandler(service meals_services.Service, repository meals_repository.Repository, db *database.Database, jwtSecret []byte) *Handler {
+func NewHandler(service meals_services.Service, repository meals_repository.Repository, lastSeen *meals_jobs.LastSeenRecorder, db *database.Database, jwtSecret []byte) *Handler {
 	return &Handler{
 		service:   	service,
 		repository: repository,
+		lastSeen:   lastSeen,
 		db:        	db,
 		jwtSecret: 	jwtSecret,
 	}
@@ -73,7 +84,13 @@ func (h *Handler) GetPopularPicks(c *gin.Context) {
         page = 1
     }
 
-    popularPicks, err := h.service.GetPopularPicks(userID, limit, page)
+    category := c.Query("category")
+
+    popularPicks, err := h.service.GetPopularPicks(userID, category, limit, page)
+    if errors.Is(err, meals_services.ErrUnknownCategory) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -102,7 +119,13 @@ func (h *Handler) GetCuisinePicks(c *gin.Context) {
         page = 1
     }
 
-    cuisinePicks, err := h.service.GetCuisinePicks(userID, limit, page)
+    area := c.Query("area")
+
+    cuisinePicks, err := h.service.GetCuisinePicks(userID, area, limit, page)
+    if errors.Is(err, meals_services.ErrUnknownArea) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -164,17 +187,47 @@ func (h *Handler) GetMealDetail(c *gin.Context) {
 		return
 	}
 
-	meal, err := h.service.GetMealDetail(userID, mealID)
+	opts, ok := detailOptions(c)
+	if !ok {
+		return
+	}
+
+	meal, err := h.service.GetMealDetail(userID, mealID, opts)
+	if errors.Is(err, meals_services.ErrMealNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	go h.repository.LogLastSeen(userID, meal.MealID, meal.MealName, meal.MealThumbImage)
+	h.lastSeen.Record(userID, meal.MealID, meal.MealName, meal.MealThumbImage)
 
 	c.JSON(http.StatusOK, meal)
 }
 
+// detailOptions reads the units and servings a meal is presented with
+func detailOptions(c *gin.Context) (meals_models.DetailOptions, bool) {
+	units := c.Query("units")
+	if _, ok := measure.ParseSystem(units); units != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "units must be metric or imperial"})
+		return meals_models.DetailOptions{}, false
+	}
+
+	servings := 0
+	if value := c.Query("servings"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "servings must be a number between 1 and 100"})
+			return meals_models.DetailOptions{}, false
+		}
+		servings = n
+	}
+
+	return meals_models.DetailOptions{MeasurementSystem: units, Servings: servings}, true
+}
+
 func (h *Handler) GetFavourites(c *gin.Context) {
 	userIDInt, ok := utils.GetUserID(c)
 	if !ok {
@@ -183,15 +236,23 @@ func (h *Handler) GetFavourites(c *gin.Context) {
 	}
 	userID := strconv.Itoa(userIDInt)
 
-	favs, err := h.service.GetFavourites(userID)
+	sort := c.DefaultQuery("sort", meals_models.FavouriteSortNewest)
+	switch sort {
+	case meals_models.FavouriteSortNewest, meals_models.FavouriteSortOldest, meals_models.FavouriteSortName:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of newest, oldest, name"})
+		return
+	}
+
+	limit, page := pageQuery(c)
+
+	result, err := h.service.GetFavourites(userID, sort, limit, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"favourites": favs,
-	})
+	c.JSON(http.StatusOK, result)
 }
 
 
@@ -210,7 +271,11 @@ func (h *Handler) SetFavourite(c *gin.Context) {
 
 	userID := strconv.Itoa(userIDInt)
 
-	if err := h.service.SetFavourite(userID, req.MealID, req.MealName, req.MealThumbImage); err != nil {
+	if err := h.service.SetFavourite(userID, strings.TrimSpace(req.MealID)); err != nil {
+		if errors.Is(err, meals_services.ErrMealNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -296,6 +361,27 @@ func (h *Handler) DeleteLastSeen(c *gin.Context) {
     c.JSON(http.StatusOK, gin.H{"message": "Last seen meals deleted successfully"})
 }
 
+func (h *Handler) DeleteLastSeenMeal(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    found, err := h.repository.DeleteLastSeenMeal(userID, c.Param("mealId"))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if !found {
+        c.JSON(http.StatusNotFound, gin.H{"error": "meal not found in last seen"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Last seen meal deleted successfully"})
+}
+
 func (h *Handler) CreateReviewMeals(c *gin.Context) {
     userIDInt, ok := utils.GetUserID(c)
     if !ok {
@@ -324,39 +410,935 @@ func (h *Handler) CreateReviewMeals(c *gin.Context) {
 
     reviewText := c.PostForm("reviewText")
 
-    // handle optional file upload
-    var file multipart.File
-    var header *multipart.FileHeader
-    file, header, err = c.Request.FormFile("reviewImage")
+    // handle optional image uploads
+    images, err := reviewImageUploads(c)
     if err != nil {
-        if err != http.ErrMissingFile {
-            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file upload"})
-            return
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    defer closeReviewImages(images)
+
+    review, created, err := h.service.CreateReview(userID, mealID, rating, reviewText, images)
+    if err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    avgRating, totalReviews, err := h.repository.GetMealRating(mealID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    status, message := http.StatusCreated, "Review created successfully"
+    if !created {
+        status, message = http.StatusOK, "Review updated successfully"
+    }
+    if review.Status == meals_models.ReviewStatusPending {
+        message = "Review submitted and awaiting moderation"
+    }
+
+    c.JSON(status, gin.H{
+        "message":      message,
+        "review":       review,
+        "avgRating":    avgRating,
+        "totalReviews": totalReviews,
+    })
+}
+
+func (h *Handler) UpdateReviewMeals(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    reviewID, err := strconv.Atoi(c.Param("reviewId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reviewId must be a number"})
+        return
+    }
+
+    // parse rating
+    rating, err := strconv.Atoi(c.PostForm("rating"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be a number"})
+        return
+    }
+    if rating < 1 || rating > 5 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be between 1 and 5"})
+        return
+    }
+
+    reviewText := c.PostForm("reviewText")
+    removeAllImages := c.PostForm("removeImage") == "true"
+
+    // removeImageIds may be repeated or comma separated
+    var removeImageIDs []int
+    for _, value := range c.PostFormArray("removeImageIds") {
+        for _, raw := range strings.Split(value, ",") {
+            if raw = strings.TrimSpace(raw); raw == "" {
+                continue
+            }
+            id, err := strconv.Atoi(raw)
+            if err != nil {
+                c.JSON(http.StatusBadRequest, gin.H{"error": "removeImageIds must be numbers"})
+                return
+            }
+            removeImageIDs = append(removeImageIDs, id)
         }
-        file = nil
-        header = nil
     }
 
-    review, err := h.service.CreateReview(userID, mealID, rating, reviewText, file, header)
+    // handle optional image uploads
+    images, err := reviewImageUploads(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    defer closeReviewImages(images)
+
+    review, err := h.service.UpdateReview(userID, reviewID, rating, reviewText, images, removeImageIDs, removeAllImages)
+    if err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    avgRating, totalReviews, err := h.repository.GetMealRating(review.MealID)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    c.JSON(http.StatusCreated, gin.H{
-        "message": "Review created successfully",
+    message := "Review updated successfully"
+    if review.Status == meals_models.ReviewStatusPending {
+        message = "Review updated and awaiting moderation"
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":      message,
+        "review":       review,
+        "avgRating":    avgRating,
+        "totalReviews": totalReviews,
+    })
+}
+
+// reviewImageUploads opens the images of a review form. Images are sent as
+// repeated reviewImages files with matching captions; a single reviewImage
+// file is still accepted.
+func reviewImageUploads(c *gin.Context) ([]meals_services.ReviewImageUpload, error) {
+    form, err := c.MultipartForm()
+    if err != nil {
+        if err == http.ErrNotMultipart {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("invalid file upload")
+    }
+
+    headers := append(form.File["reviewImages"], form.File["reviewImage"]...)
+    captions := form.Value["captions"]
+
+    images := make([]meals_services.ReviewImageUpload, 0, len(headers))
+    for i, header := range headers {
+        var caption string
+        if i < len(captions) {
+            caption = strings.TrimSpace(captions[i])
+        }
+        if len(caption) > maxCaptionLength {
+            closeReviewImages(images)
+            return nil, fmt.Errorf("captions must be at most %d characters", maxCaptionLength)
+        }
+
+        file, err := header.Open()
+        if err != nil {
+            closeReviewImages(images)
+            return nil, fmt.Errorf("invalid file upload")
+        }
+        images = append(images, meals_services.ReviewImageUpload{File: file, Header: header, Caption: caption})
+    }
+    return images, nil
+}
+
+func closeReviewImages(images []meals_services.ReviewImageUpload) {
+    for _, img := range images {
+        img.File.Close()
+    }
+}
+
+func (h *Handler) ArrangeReviewImages(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    reviewID, err := strconv.Atoi(c.Param("reviewId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reviewId must be a number"})
+        return
+    }
+
+    var req meals_models.ReviewImagesRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    for i := range req.Images {
+        req.Images[i].Caption = strings.TrimSpace(req.Images[i].Caption)
+    }
+
+    review, err := h.service.ArrangeReviewImages(userID, reviewID, req.Images)
+    if err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Review images updated successfully",
         "review":  review,
     })
 }
 
-func (h *Handler) GetAllMealsReview(c *gin.Context) {
+func (h *Handler) GetMealPhotos(c *gin.Context) {
     mealID := c.Param("mealId")
 
-    reviews, err := h.repository.GetReviewsByMealID(mealID, false) 
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if err != nil || limit <= 0 {
+        limit = 10
+    }
+    page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+    if err != nil || page <= 0 {
+        page = 1
+    }
+
+    result, err := h.service.GetMealPhotos(mealID, limit, page)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) DeleteReviewMeals(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    reviewID, err := strconv.Atoi(c.Param("reviewId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reviewId must be a number"})
+        return
+    }
+
+    review, err := h.service.DeleteReview(userID, reviewID)
+    if err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    avgRating, totalReviews, err := h.repository.GetMealRating(review.MealID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":      "Review deleted successfully",
+        "avgRating":    avgRating,
+        "totalReviews": totalReviews,
+    })
+}
+
+func (h *Handler) ToggleHelpfulVote(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    reviewID, err := strconv.Atoi(c.Param("reviewId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reviewId must be a number"})
+        return
+    }
+
+    vote, err := h.service.ToggleHelpfulVote(userID, reviewID)
+    if err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, vote)
+}
+
+func (h *Handler) ReportReview(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    reviewID, err := strconv.Atoi(c.Param("reviewId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reviewId must be a number"})
+        return
+    }
+
+    var req meals_models.ReviewReportRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if !meals_models.ReviewReportReasons[req.Reason] {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reason must be one of spam, offensive, off-topic, inappropriate-image, other"})
+        return
+    }
+    if req.Reason == "other" && strings.TrimSpace(req.Details) == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "details are required when reason is other"})
+        return
+    }
+
+    if err := h.service.ReportReview(userID, reviewID, req.Reason, strings.TrimSpace(req.Details)); err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{"message": "Review reported successfully"})
+}
+
+// reviewError maps review service errors to HTTP responses
+func (h *Handler) reviewError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, meals_services.ErrOwnReview):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, meals_repository.ErrAlreadyReported):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    case errors.Is(err, meals_services.ErrReviewNotFound),
+        errors.Is(err, meals_services.ErrMealNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case errors.Is(err, meals_services.ErrNotReviewAuthor),
+        errors.Is(err, meals_services.ErrNotReplyAuthor):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, meals_services.ErrReplyNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case errors.Is(err, meals_services.ErrInvalidImage),
+        errors.Is(err, meals_repository.ErrTooManyImages),
+        errors.Is(err, meals_repository.ErrImageOrder):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// moderationStatusQuery reads the status filter of a moderation queue
+func moderationStatusQuery(c *gin.Context) (string, bool) {
+    status := c.DefaultQuery("status", meals_models.ReviewStatusPending)
+    switch status {
+    case meals_models.ReviewStatusPending, meals_models.ReviewStatusApproved, meals_models.ReviewStatusRejected:
+        return status, true
+    }
+    c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of pending, approved, rejected"})
+    return "", false
+}
+
+func (h *Handler) GetModerationQueue(c *gin.Context) {
+    status, ok := moderationStatusQuery(c)
+    if !ok {
+        return
+    }
+
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if err != nil || limit <= 0 {
+        limit = 10
+    }
+    page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+    if err != nil || page <= 0 {
+        page = 1
+    }
+
+    result, err := h.service.GetModerationQueue(status, limit, page)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    c.JSON(http.StatusOK, gin.H{"reviews": reviews})
+    c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) ApproveReview(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    reviewID, err := strconv.Atoi(c.Param("reviewId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reviewId must be a number"})
+        return
+    }
+
+    if err := h.service.ApproveReview(userID, reviewID); err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Review approved successfully"})
+}
+
+func (h *Handler) RejectReview(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    reviewID, err := strconv.Atoi(c.Param("reviewId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reviewId must be a number"})
+        return
+    }
+
+    var req meals_models.ModerationDecisionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    reason := strings.TrimSpace(req.Reason)
+    if reason == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required so the author knows why"})
+        return
+    }
+
+    if err := h.service.RejectReview(userID, reviewID, reason); err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Review rejected successfully"})
+}
+
+// SetMealServings stores how many a meal serves, for recipes that do not say
+func (h *Handler) SetMealServings(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    var req meals_models.MealServingsRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    err := h.service.SetMealServings(userID, c.Param("mealId"), req.Servings)
+    if errors.Is(err, meals_services.ErrMealNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Servings updated successfully"})
+}
+
+func (h *Handler) GetReviewReplies(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    reviewID, err := strconv.Atoi(c.Param("reviewId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reviewId must be a number"})
+        return
+    }
+
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if err != nil || limit <= 0 {
+        limit = 10
+    }
+    if limit > 50 {
+        limit = 50
+    }
+    page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+    if err != nil || page <= 0 {
+        page = 1
+    }
+
+    result, err := h.service.GetReviewReplies(userID, reviewID, limit, page)
+    if err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) CreateReply(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    reviewID, err := strconv.Atoi(c.Param("reviewId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reviewId must be a number"})
+        return
+    }
+
+    var req meals_models.ReviewReplyRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    replyText := strings.TrimSpace(req.ReplyText)
+    if replyText == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "replyText is required"})
+        return
+    }
+
+    reply, err := h.service.CreateReply(userID, reviewID, replyText)
+    if err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    message := "Reply created successfully"
+    if reply.Status == meals_models.ReviewStatusPending {
+        message = "Reply submitted and awaiting moderation"
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": message,
+        "reply":   reply,
+    })
+}
+
+func (h *Handler) UpdateReply(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    replyID, err := strconv.Atoi(c.Param("replyId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "replyId must be a number"})
+        return
+    }
+
+    var req meals_models.ReviewReplyRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    replyText := strings.TrimSpace(req.ReplyText)
+    if replyText == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "replyText is required"})
+        return
+    }
+
+    reply, err := h.service.UpdateReply(userID, replyID, replyText)
+    if err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    message := "Reply updated successfully"
+    if reply.Status == meals_models.ReviewStatusPending {
+        message = "Reply updated and awaiting moderation"
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": message,
+        "reply":   reply,
+    })
+}
+
+func (h *Handler) DeleteReply(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    replyID, err := strconv.Atoi(c.Param("replyId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "replyId must be a number"})
+        return
+    }
+
+    if err := h.service.DeleteReply(userID, replyID); err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Reply deleted successfully"})
+}
+
+func (h *Handler) GetReplyModerationQueue(c *gin.Context) {
+    status, ok := moderationStatusQuery(c)
+    if !ok {
+        return
+    }
+
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if err != nil || limit <= 0 {
+        limit = 10
+    }
+    page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+    if err != nil || page <= 0 {
+        page = 1
+    }
+
+    result, err := h.service.GetReplyModerationQueue(status, limit, page)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) ApproveReply(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    replyID, err := strconv.Atoi(c.Param("replyId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "replyId must be a number"})
+        return
+    }
+
+    if err := h.service.ApproveReply(userID, replyID); err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Reply approved successfully"})
+}
+
+func (h *Handler) RejectReply(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    replyID, err := strconv.Atoi(c.Param("replyId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "replyId must be a number"})
+        return
+    }
+
+    var req meals_models.ModerationDecisionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    reason := strings.TrimSpace(req.Reason)
+    if reason == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required so the author knows why"})
+        return
+    }
+
+    if err := h.service.RejectReply(userID, replyID, reason); err != nil {
+        h.reviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Reply rejected successfully"})
+}
+
+func (h *Handler) GetAllMealsReview(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    mealID := c.Param("mealId")
+    if mealID == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "mealId is required"})
+        return
+    }
+
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if err != nil || limit <= 0 {
+        limit = 10
+    }
+    if limit > 50 {
+        limit = 50
+    }
+
+    sort := c.DefaultQuery("sort", meals_models.ReviewSortNewest)
+    switch sort {
+    case meals_models.ReviewSortNewest, meals_models.ReviewSortHighest, meals_models.ReviewSortLowest, meals_models.ReviewSortMostHelpful:
+    default:
+        c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of newest, highest, lowest, most-helpful"})
+        return
+    }
+
+    stars := 0
+    if starsStr := c.Query("stars"); starsStr != "" {
+        stars, err = strconv.Atoi(starsStr)
+        if err != nil || stars < 1 || stars > 5 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "stars must be between 1 and 5"})
+            return
+        }
+    }
+
+    reviews, err := h.service.GetMealReviews(userID, mealID, meals_models.ReviewQuery{
+        Sort:       sort,
+        Cursor:     c.Query("cursor"),
+        Limit:      limit,
+        WithPhotos: c.Query("withPhotos") == "true",
+        Stars:      stars,
+    })
+    if errors.Is(err, meals_repository.ErrInvalidCursor) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if errors.Is(err, meals_services.ErrMealNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, reviews)
+}
+
+func (h *Handler) GetPantryMatches(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    limitStr := c.DefaultQuery("limit", "10")
+    limit, err := strconv.Atoi(limitStr)
+    if err != nil || limit <= 0 {
+        limit = 10
+    }
+
+    pageStr := c.DefaultQuery("page", "1")
+    page, err := strconv.Atoi(pageStr)
+    if err != nil || page <= 0 {
+        page = 1
+    }
+
+    matches, err := h.service.GetPantryMatches(userID, limit, page)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, matches)
+}
+
+func (h *Handler) GetTrendingMeals(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    limitStr := c.DefaultQuery("limit", "10")
+    limit, err := strconv.Atoi(limitStr)
+    if err != nil || limit <= 0 {
+        limit = 10
+    }
+
+    pageStr := c.DefaultQuery("page", "1")
+    page, err := strconv.Atoi(pageStr)
+    if err != nil || page <= 0 {
+        page = 1
+    }
+
+    trending, err := h.service.GetTrendingMeals(userID, limit, page)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, trending)
+}
+
+func (h *Handler) GetTopRatedMeals(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    limitStr := c.DefaultQuery("limit", "10")
+    limit, err := strconv.Atoi(limitStr)
+    if err != nil || limit <= 0 {
+        limit = 10
+    }
+
+    pageStr := c.DefaultQuery("page", "1")
+    page, err := strconv.Atoi(pageStr)
+    if err != nil || page <= 0 {
+        page = 1
+    }
+
+    topRated, err := h.service.GetTopRatedMeals(userID, limit, page)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, topRated)
+}
+
+func (h *Handler) GetSimilarMeals(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    mealID := c.Param("mealId")
+    if mealID == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "mealId is required"})
+        return
+    }
+
+    limitStr := c.DefaultQuery("limit", "10")
+    limit, err := strconv.Atoi(limitStr)
+    if err != nil || limit <= 0 {
+        limit = 10
+    }
+
+    pageStr := c.DefaultQuery("page", "1")
+    page, err := strconv.Atoi(pageStr)
+    if err != nil || page <= 0 {
+        page = 1
+    }
+
+    similar, err := h.service.GetSimilarMeals(userID, mealID, limit, page)
+    if errors.Is(err, meals_services.ErrMealNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, similar)
+}
+
+func (h *Handler) GetMealSteps(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    mealID := c.Param("mealId")
+    if mealID == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "mealId is required"})
+        return
+    }
+
+    steps, err := h.service.GetMealSteps(userID, mealID)
+    if errors.Is(err, meals_services.ErrMealNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, steps)
+}
+
+// ExportMeal renders a meal as Markdown, a printable HTML page or a PDF.
+// Markdown and PDF are sent as downloads.
+func (h *Handler) ExportMeal(c *gin.Context) {
+    userIDInt, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+    userID := strconv.Itoa(userIDInt)
+
+    mealID := c.Param("mealId")
+    if mealID == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "mealId is required"})
+        return
+    }
+
+    format := c.DefaultQuery("format", recipeexport.FormatHTML)
+    if !recipeexport.ValidFormat(format) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of md, pdf, html"})
+        return
+    }
+
+    opts, ok := detailOptions(c)
+    if !ok {
+        return
+    }
+
+    recipe, err := h.service.ExportMeal(userID, mealID, opts)
+    if errors.Is(err, meals_services.ErrMealNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    body, contentType, err := recipeexport.Render(recipe, format)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    disposition := "attachment"
+    if format == recipeexport.FormatHTML {
+        disposition = "inline"
+    }
+    c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{
+        "filename": recipeexport.FileName(recipe, format),
+    }))
+    c.Data(http.StatusOK, contentType, body)
 }
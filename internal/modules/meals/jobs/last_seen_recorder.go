@@ -0,0 +1,88 @@
+package meals_jobs
+
+import (
+	"strconv"
+
+	meals_repository "github.com/JonathanTriC/nomie-api/internal/modules/meals/repository"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/logger"
+)
+
+// lastSeenQueueSize bounds how many views can wait to be written
+const lastSeenQueueSize = 256
+
+type lastSeenEntry struct {
+	userID    string
+	mealID    string
+	mealName  string
+	mealThumb string
+}
+
+// LastSeenRecorder writes meal views in the background so the detail page
+// does not wait on them. Views still queued at shutdown are written before
+// the worker stops.
+type LastSeenRecorder struct {
+	repo  meals_repository.Repository
+	keep  int
+	queue chan lastSeenEntry
+}
+
+// StartLastSeenRecorder starts the worker that records meal views until Stop
+// is called, so views queued by requests that finish during shutdown are
+// kept. LAST_SEEN_LIMIT caps how many meals are kept per user.
+func StartLastSeenRecorder(repo meals_repository.Repository) *LastSeenRecorder {
+	keep, err := strconv.Atoi(utils.GetEnv("LAST_SEEN_LIMIT", "50"))
+	if err != nil || keep <= 0 {
+		keep = 50
+	}
+
+	rec := &LastSeenRecorder{
+		repo:  repo,
+		keep:  keep,
+		queue: make(chan lastSeenEntry, lastSeenQueueSize),
+	}
+
+	running.Add(1)
+	go func() {
+		defer running.Done()
+
+		for {
+			select {
+			case <-stopping:
+				rec.drain()
+				return
+			case entry := <-rec.queue:
+				rec.write(entry)
+			}
+		}
+	}()
+
+	return rec
+}
+
+// Record queues a view. When the queue is full the view is dropped rather
+// than slowing down the request.
+func (rec *LastSeenRecorder) Record(userID, mealID, mealName, mealThumb string) {
+	select {
+	case rec.queue <- lastSeenEntry{userID: userID, mealID: mealID, mealName: mealName, mealThumb: mealThumb}:
+	default:
+		logger.ErrorLogger.Printf("last seen queue full, dropping view of meal %s by user %s", mealID, userID)
+	}
+}
+
+func (rec *LastSeenRecorder) drain() {
+	for {
+		select {
+		case entry := <-rec.queue:
+			rec.write(entry)
+		default:
+			return
+		}
+	}
+}
+
+func (rec *LastSeenRecorder) write(entry lastSeenEntry) {
+	if err := rec.repo.LogLastSeen(entry.userID, entry.mealID, entry.mealName, entry.mealThumb, rec.keep); err != nil {
+		logger.ErrorLogger.Printf("failed to record last seen meal %s for user %s: %v", entry.mealID, entry.userID, err)
+	}
+}
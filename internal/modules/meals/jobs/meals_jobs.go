@@ -0,0 +1,80 @@
+package meals_jobs
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	meals_repository "github.com/JonathanTriC/nomie-api/internal/modules/meals/repository"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/logger"
+)
+
+var (
+	// running tracks every background job so shutdown can wait for them
+	running sync.WaitGroup
+
+	// stopping is closed by Stop, once no more requests can queue work
+	stopping = make(chan struct{})
+	stopOnce sync.Once
+)
+
+// Stop tells the jobs that take work from requests to finish what is queued
+// and return. Call it once the server has shut down, before Wait.
+func Stop() {
+	stopOnce.Do(func() { close(stopping) })
+}
+
+// Wait blocks until all background jobs have stopped
+func Wait() {
+	running.Wait()
+}
+
+// StartStatsAggregator periodically rebuilds the trending and top-rated
+// statistics until ctx is cancelled
+func StartStatsAggregator(ctx context.Context, repo meals_repository.Repository) {
+	interval := durationEnv("MEAL_STATS_INTERVAL", 15*time.Minute)
+	window := durationEnv("TRENDING_WINDOW", 7*24*time.Hour)
+	priorWeight := floatEnv("RATING_PRIOR_WEIGHT", 5)
+
+	running.Add(1)
+	go func() {
+		defer running.Done()
+
+		aggregate := func() {
+			if err := repo.AggregateMealStats(window, priorWeight); err != nil {
+				logger.ErrorLogger.Printf("meal stats aggregation failed: %v", err)
+			}
+		}
+
+		aggregate()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				aggregate()
+			}
+		}
+	}()
+}
+
+func durationEnv(key string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(utils.GetEnv(key, ""))
+	if err != nil || d <= 0 {
+		return defaultValue
+	}
+	return d
+}
+
+func floatEnv(key string, defaultValue float64) float64 {
+	f, err := strconv.ParseFloat(utils.GetEnv(key, ""), 64)
+	if err != nil || f <= 0 {
+		return defaultValue
+	}
+	return f
+}
@@ -1,6 +1,14 @@
 package meals_models
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JonathanTriC/nomie-api/pkg/measure"
+	"github.com/JonathanTriC/nomie-api/pkg/nutrition"
+	"github.com/JonathanTriC/nomie-api/pkg/steps"
+)
 
 type Meal struct {
 	MealID                     string           `json:"mealId"`
@@ -21,12 +29,43 @@ type Meal struct {
 	Reviews 									 []MealReview 		`json:"reviews,omitempty"`
 	AvgRating     						 float64          `json:"avgRating"`
   TotalReviews  						 int              `json:"totalReviews"`
+	Explanation                string           `json:"explanation,omitempty"`
+	Similar                    []SimilarMeal    `json:"similar,omitempty"`
+	RatingHistogram            map[string]int   `json:"ratingHistogram,omitempty"`
+	// BaseServings is how many the recipe serves, Servings how many the
+	// measures are scaled for
+	BaseServings               int              `json:"baseServings,omitempty"`
+	Servings                   int              `json:"servings,omitempty"`
+	ServingsSource             string           `json:"servingsSource,omitempty"`
+	Nutrition                  *nutrition.Estimate `json:"nutrition,omitempty"`
+	// Times in minutes; only user recipes state them
+	PrepMinutes                int              `json:"prepMinutes,omitempty"`
+	CookMinutes                int              `json:"cookMinutes,omitempty"`
+	TotalMinutes               int              `json:"totalMinutes,omitempty"`
 
 }
 
 type MealIngredient struct {
 	IngredientName   string `json:"ingredientName"`
 	IngredientMeasure string `json:"ingredientMeasure"`
+	// Measure is the parsed measure in the viewer's measurement system and
+	// DisplayMeasure its text; IngredientMeasure keeps the recipe's original
+	Measure        *measure.Measure `json:"measure,omitempty"`
+	DisplayMeasure string           `json:"displayMeasure,omitempty"`
+	// Unscaled flags a measure that could not be parsed and so was not
+	// adjusted to the requested servings
+	Unscaled bool `json:"unscaled,omitempty"`
+}
+
+// Where a meal's base serving count comes from
+const (
+	ServingsSourceStored    = "stored"
+	ServingsSourceRecipe    = "recipe"
+	ServingsSourceEstimated = "estimated"
+)
+
+type MealServingsRequest struct {
+	Servings int `json:"servings" binding:"required,min=1,max=100"`
 }
 
 // Structs for mapping API response
@@ -34,16 +73,80 @@ type MealAPIResponse struct {
 	Meals []map[string]interface{} `json:"meals"`
 }
 
+// UserRecipePrefix namespaces the meal IDs of user-authored recipes so they
+// never collide with upstream meal IDs
+const UserRecipePrefix = "u-"
+
+// UserRecipeMealID returns the meal ID a user-authored recipe is served under
+func UserRecipeMealID(recipeID int) string {
+	return UserRecipePrefix + strconv.Itoa(recipeID)
+}
+
+// ParseUserRecipeID returns the recipe ID behind a user recipe's meal ID
+func ParseUserRecipeID(mealID string) (int, bool) {
+	if !strings.HasPrefix(mealID, UserRecipePrefix) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(mealID, UserRecipePrefix))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
 type Favourite struct {
 	MealID         string `json:"mealId"`
 	MealName       string `json:"mealName"`
 	MealThumbImage string `json:"mealThumbImage"`
+	// FavouritedAt is nil for favourites added before the date was recorded
+	FavouritedAt   *time.Time `json:"favouritedAt"`
 }
 
+// FavouriteRequest only names the meal; its name and thumbnail are looked up
+// from the meal source rather than trusted from the client
 type FavouriteRequest struct {
 	MealID    string `json:"mealId" binding:"required"`
-	MealName  string `json:"mealName" binding:"required"`
-	MealThumbImage string `json:"mealThumbImage" binding:"required"`
+}
+
+const (
+	FavouriteSortNewest = "newest"
+	FavouriteSortOldest = "oldest"
+	FavouriteSortName   = "name"
+)
+
+// FavouriteCollection is a user-defined folder of favourite meals. The cover
+// image is the thumbnail of its first meal.
+type FavouriteCollection struct {
+	ID         int       `json:"id" db:"id"`
+	UserID     string    `json:"-" db:"user_id"`
+	Name       string    `json:"name" db:"name"`
+	MealCount  int       `json:"mealCount"`
+	CoverImage string    `json:"coverImage"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type CollectionMeal struct {
+	Favourite
+	Position int       `json:"position" db:"position"`
+	AddedAt  time.Time `json:"addedAt" db:"added_at"`
+}
+
+type CollectionRequest struct {
+	Name string `json:"name" binding:"required,max=60"`
+}
+
+type CollectionMealRequest struct {
+	MealID string `json:"mealId" binding:"required"`
+}
+
+type CollectionMoveRequest struct {
+	ToCollectionID int `json:"toCollectionId" binding:"required"`
+}
+
+// CollectionOrderRequest lists every meal of a collection in its new order
+type CollectionOrderRequest struct {
+	MealIDs []string `json:"mealIds" binding:"required"`
 }
 
 type MealReview struct {
@@ -52,6 +155,169 @@ type MealReview struct {
 	MealID      string    `json:"mealId" db:"meal_id"`
 	Rating      int       `json:"rating" db:"rating"`
 	ReviewText  string    `json:"reviewText" db:"review_text"`
-	ReviewImage string    `json:"reviewImage,omitempty" db:"review_image"`
+	// ReviewImage is the first image, kept for clients that show a single photo
+	ReviewImage string    `json:"reviewImage,omitempty"`
+	Images      []ReviewImage `json:"images"`
 	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	EditedAt    *time.Time `json:"editedAt" db:"edited_at"`
+	HelpfulCount int      `json:"helpfulCount" db:"helpful_count"`
+	ViewerHasVoted bool   `json:"viewerHasVoted"`
+	IsOwnReview bool      `json:"isOwnReview"`
+	Status      string    `json:"status" db:"status"`
+	ModerationReason string `json:"moderationReason,omitempty" db:"moderation_reason"`
+	ReplyCount  int       `json:"replyCount"`
+}
+
+// ReviewReply answers a review. Replies cannot be replied to.
+type ReviewReply struct {
+	ID               int        `json:"id" db:"id"`
+	ReviewID         int        `json:"reviewId" db:"review_id"`
+	UserID           string     `json:"userId" db:"user_id"`
+	ReplyText        string     `json:"replyText" db:"reply_text"`
+	Status           string     `json:"status" db:"status"`
+	ModerationReason string     `json:"moderationReason,omitempty" db:"moderation_reason"`
+	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+	EditedAt         *time.Time `json:"editedAt" db:"edited_at"`
+	IsOwnReply       bool       `json:"isOwnReply"`
+}
+
+type ReviewReplyRequest struct {
+	ReplyText string `json:"replyText" binding:"required,max=1000"`
+}
+
+type ReviewImage struct {
+	ID        int       `json:"id" db:"id"`
+	ReviewID  int       `json:"reviewId" db:"review_id"`
+	PublicID  string    `json:"-" db:"public_id"`
+	ImageURL  string    `json:"imageUrl" db:"image_url"`
+	Caption   string    `json:"caption" db:"caption"`
+	Position  int       `json:"position" db:"position"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// MealPhoto is a review image shown in a meal's photo gallery
+type MealPhoto struct {
+	ReviewImage
+	UserID string `json:"userId"`
+	Rating int    `json:"rating"`
+}
+
+type ReviewImageUpdate struct {
+	ID      int    `json:"id" binding:"required"`
+	Caption string `json:"caption" binding:"max=200"`
+}
+
+// ReviewImagesRequest lists every image of a review in its new order
+type ReviewImagesRequest struct {
+	Images []ReviewImageUpdate `json:"images" binding:"required,dive"`
+}
+
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// ModerationItem is a review in the moderation queue
+type ModerationItem struct {
+	Review        MealReview `json:"review"`
+	ReportCount   int        `json:"reportCount"`
+	ReportReasons []string   `json:"reportReasons"`
+}
+
+type ModerationDecisionRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// DetailOptions adjusts how a meal's detail page is presented
+type DetailOptions struct {
+	// MeasurementSystem overrides the viewer's preferred system when set
+	MeasurementSystem string
+	// Servings scales the ingredient measures when set
+	Servings int
+}
+
+// ReviewQuery describes one page of a meal's review listing
+type ReviewQuery struct {
+	ViewerID   string
+	Sort       string
+	Cursor     string
+	Limit      int
+	WithPhotos bool
+	Stars      int
+}
+
+type ReviewReportRequest struct {
+	Reason  string `json:"reason" binding:"required"`
+	Details string `json:"details" binding:"max=500"`
+}
+
+// ReviewReportReasons are the accepted reasons for reporting a review
+var ReviewReportReasons = map[string]bool{
+	"spam":                true,
+	"offensive":           true,
+	"off-topic":           true,
+	"inappropriate-image": true,
+	"other":               true,
+}
+
+const (
+	ReviewSortNewest      = "newest"
+	ReviewSortHighest     = "highest"
+	ReviewSortLowest      = "lowest"
+	ReviewSortMostHelpful = "most-helpful"
+)
+
+type UserPreferences struct {
+	FavouriteCategories []string `json:"favouriteCategories"`
+	FavouriteAreas      []string `json:"favouriteAreas"`
+	ExcludedIngredients []string `json:"excludedIngredients"`
+	MeasurementSystem   string   `json:"measurementSystem"`
+}
+
+type SeenMeal struct {
+	MealID   string    `json:"mealId"`
+	MealName string    `json:"mealName"`
+	SeenAt   time.Time `json:"seenAt"`
+}
+
+type DailyRecommendation struct {
+	UserID      string    `json:"userId"`
+	Date        string    `json:"date"`
+	MealID      string    `json:"mealId"`
+	Explanation string    `json:"explanation"`
+}
+
+type MealStats struct {
+	MealID         string    `json:"mealId"`
+	MealName       string    `json:"mealName"`
+	MealThumbImage string    `json:"mealThumbImage"`
+	RecentViews    int       `json:"recentViews"`
+	FavouriteCount int       `json:"favouriteCount"`
+	RecentReviews  int       `json:"recentReviews"`
+	TotalReviews   int       `json:"totalReviews"`
+	AvgRating      float64   `json:"avgRating"`
+	BayesianRating float64   `json:"bayesianRating"`
+	TrendingScore  float64   `json:"trendingScore"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// MealSteps drives the cooking mode: the instructions as numbered steps with
+// their timers
+type MealSteps struct {
+	MealID              string       `json:"mealId"`
+	MealName            string       `json:"mealName"`
+	MealThumbImage      string       `json:"mealThumbImage"`
+	MealYoutubeTutorial string       `json:"mealYoutubeTutorial,omitempty"`
+	Steps               []steps.Step `json:"steps"`
+	TotalTimerSeconds   int          `json:"totalTimerSeconds"`
+}
+
+type SimilarMeal struct {
+	MealID         string   `json:"mealId"`
+	MealName       string   `json:"mealName"`
+	MealThumbImage string   `json:"mealThumbImage"`
+	IsFavourite    bool     `json:"isFavourite"`
+	Score          float64  `json:"score"`
+	Reasons        []string `json:"reasons"`
 }
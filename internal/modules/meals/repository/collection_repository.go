@@ -0,0 +1,277 @@
+package meals_repository
+
+import (
+	"database/sql"
+	"errors"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrCollectionExists = errors.New("you already have a collection with this name")
+	ErrCollectionOrder  = errors.New("mealIds must list every meal of the collection exactly once")
+)
+
+// collectionColumns is the column list scanned by scanCollection. The cover is
+// the thumbnail of the collection's first meal.
+const collectionColumns = `c.id, c.user_id, c.name, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM favourite_collection_meals cm WHERE cm.collection_id = c.id),
+	COALESCE((
+		SELECT f.meal_thumb
+		FROM favourite_collection_meals cm
+		JOIN favourites f ON f.user_id = c.user_id AND f.meal_id = cm.meal_id
+		WHERE cm.collection_id = c.id
+		ORDER BY cm.position
+		LIMIT 1
+	), '')`
+
+func scanCollection(row rowScanner) (*meals_models.FavouriteCollection, error) {
+	var c meals_models.FavouriteCollection
+	if err := row.Scan(&c.ID, &c.UserID, &c.Name, &c.CreatedAt, &c.UpdatedAt, &c.MealCount, &c.CoverImage); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (r *repository) GetCollections(userID string) ([]meals_models.FavouriteCollection, error) {
+	rows, err := r.db.Query(`
+		SELECT `+collectionColumns+`
+		FROM favourite_collections c
+		WHERE c.user_id = $1
+		ORDER BY c.name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []meals_models.FavouriteCollection{}
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *c)
+	}
+	return collections, rows.Err()
+}
+
+func (r *repository) GetCollection(collectionID int) (*meals_models.FavouriteCollection, error) {
+	c, err := scanCollection(r.db.QueryRow(`
+		SELECT `+collectionColumns+`
+		FROM favourite_collections c
+		WHERE c.id = $1
+	`, collectionID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (r *repository) CreateCollection(collection *meals_models.FavouriteCollection) error {
+	err := r.db.QueryRow(`
+		INSERT INTO favourite_collections (user_id, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`, collection.UserID, collection.Name).Scan(&collection.ID, &collection.CreatedAt, &collection.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrCollectionExists
+	}
+	return err
+}
+
+func (r *repository) RenameCollection(collectionID int, name string) error {
+	_, err := r.db.Exec(`
+		UPDATE favourite_collections
+		SET name = $1, updated_at = NOW()
+		WHERE id = $2
+	`, name, collectionID)
+	if isUniqueViolation(err) {
+		return ErrCollectionExists
+	}
+	return err
+}
+
+// DeleteCollection removes the collection only; its meals stay favourites
+func (r *repository) DeleteCollection(collectionID int) error {
+	_, err := r.db.Exec(`DELETE FROM favourite_collections WHERE id = $1`, collectionID)
+	return err
+}
+
+// GetCollectionMeals lists one page of a collection's meals in their order.
+// Names and thumbnails come from the owner's favourites.
+func (r *repository) GetCollectionMeals(collectionID, limit, page int) ([]meals_models.CollectionMeal, int, error) {
+	var totalItems int
+	if err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM favourite_collection_meals WHERE collection_id = $1
+	`, collectionID).Scan(&totalItems); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	rows, err := r.db.Query(`
+		SELECT cm.meal_id, f.meal_name, f.meal_thumb, f.created_at, cm.position, cm.added_at
+		FROM favourite_collection_meals cm
+		JOIN favourite_collections c ON c.id = cm.collection_id
+		JOIN favourites f ON f.user_id = c.user_id AND f.meal_id = cm.meal_id
+		WHERE cm.collection_id = $1
+		ORDER BY cm.position
+		LIMIT $2 OFFSET $3
+	`, collectionID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	meals := []meals_models.CollectionMeal{}
+	for rows.Next() {
+		var m meals_models.CollectionMeal
+		var favouritedAt sql.NullTime
+		if err := rows.Scan(&m.MealID, &m.MealName, &m.MealThumbImage, &favouritedAt, &m.Position, &m.AddedAt); err != nil {
+			return nil, 0, err
+		}
+		if favouritedAt.Valid {
+			m.FavouritedAt = &favouritedAt.Time
+		}
+		meals = append(meals, m)
+	}
+	return meals, totalItems, rows.Err()
+}
+
+// AddCollectionMeal appends a meal to the end of a collection. Adding a meal
+// that is already there is a no-op.
+func (r *repository) AddCollectionMeal(collectionID int, mealID string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO favourite_collection_meals (collection_id, meal_id, position)
+		SELECT $1, $2, COALESCE(MAX(position) + 1, 0)
+		FROM favourite_collection_meals
+		WHERE collection_id = $1
+		ON CONFLICT (collection_id, meal_id) DO NOTHING
+	`, collectionID, mealID)
+	if err != nil {
+		return err
+	}
+	return r.touchCollection(collectionID)
+}
+
+func (r *repository) RemoveCollectionMeal(collectionID int, mealID string) (bool, error) {
+	res, err := r.db.Exec(`
+		DELETE FROM favourite_collection_meals
+		WHERE collection_id = $1 AND meal_id = $2
+	`, collectionID, mealID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+	return true, r.touchCollection(collectionID)
+}
+
+// MoveCollectionMeal moves a meal to the end of another collection
+func (r *repository) MoveCollectionMeal(fromID, toID int, mealID string) (bool, error) {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		DELETE FROM favourite_collection_meals
+		WHERE collection_id = $1 AND meal_id = $2
+	`, fromID, mealID)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO favourite_collection_meals (collection_id, meal_id, position)
+		SELECT $1, $2, COALESCE(MAX(position) + 1, 0)
+		FROM favourite_collection_meals
+		WHERE collection_id = $1
+		ON CONFLICT (collection_id, meal_id) DO NOTHING
+	`, toID, mealID); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE favourite_collections SET updated_at = NOW() WHERE id IN ($1, $2)
+	`, fromID, toID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// ReorderCollection sets the order of a collection's meals. The list must name
+// every meal of the collection exactly once.
+func (r *repository) ReorderCollection(collectionID int, mealIDs []string) error {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT meal_id FROM favourite_collection_meals
+		WHERE collection_id = $1
+		FOR UPDATE
+	`, collectionID)
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(mealIDs) != len(existing) {
+		return ErrCollectionOrder
+	}
+	seen := map[string]bool{}
+	for _, id := range mealIDs {
+		if !existing[id] || seen[id] {
+			return ErrCollectionOrder
+		}
+		seen[id] = true
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE favourite_collection_meals cm
+		SET position = o.position - 1
+		FROM UNNEST($2::text[]) WITH ORDINALITY AS o(meal_id, position)
+		WHERE cm.collection_id = $1 AND cm.meal_id = o.meal_id
+	`, collectionID, pq.Array(mealIDs)); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE favourite_collections SET updated_at = NOW() WHERE id = $1`, collectionID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) touchCollection(collectionID int) error {
+	_, err := r.db.Exec(`UPDATE favourite_collections SET updated_at = NOW() WHERE id = $1`, collectionID)
+	return err
+}
@@ -1,25 +1,90 @@
 package meals_repository
 
 import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/JonathanTriC/nomie-api/internal/database"
 	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	"github.com/lib/pq"
 )
 
 type Repository interface {
 	IsFavourite(userID, mealID string) (bool, error)
+	GetFavouriteStatuses(userID string, mealIDs []string) (map[string]bool, error)
 	GetFavourites(userID string) ([]meals_models.Favourite, error)
+	GetFavouritesPage(userID, sort string, limit, page int) ([]meals_models.Favourite, int, error)
 	AddFavourite(userID string, mealID, mealName, mealThumbImage string) error
 	RemoveFavourite(userID, mealID string) error
-	LogLastSeen(userID, mealID, mealName, mealThumb string) error
+	LogLastSeen(userID, mealID, mealName, mealThumb string, keep int) error
 	GetLastSeen(userID string, limit, page int) ([]map[string]interface{}, int, error)
 	DeleteLastSeen(userID string) error
-    CreateReview(review *meals_models.MealReview) error
-    GetReviewsByMealID(mealID string, limitReviews bool) ([]meals_models.MealReview, error)
+	DeleteLastSeenMeal(userID, mealID string) (bool, error)
+    UpsertReview(review *meals_models.MealReview) (bool, error)
+	GetReviewByID(reviewID int) (*meals_models.MealReview, error)
+	UpdateReview(review *meals_models.MealReview) error
+	DeleteReview(reviewID int, userID string) error
+    GetReviewsByMealID(mealID string, q meals_models.ReviewQuery) ([]meals_models.MealReview, string, error)
+	GetUserReviewForMeal(userID, mealID string) (*meals_models.MealReview, error)
+	GetRatingHistogram(mealID string) (map[string]int, error)
+	ToggleHelpfulVote(reviewID int, userID string) (bool, int, error)
+	GetViewerVotes(userID string, reviewIDs []int) (map[int]bool, error)
+	ReportReview(reviewID int, userID, reason, details string, hideThreshold int) (bool, error)
+	GetReviewsByStatus(status string, limit, page int) ([]meals_models.ModerationItem, int, error)
+	ModerateReview(reviewID int, status, reason, moderatorID string) (bool, error)
+	AddReviewImages(reviewID int, images []meals_models.ReviewImage, maxImages int) ([]meals_models.ReviewImage, error)
+	GetReviewImages(reviewIDs []int) (map[int][]meals_models.ReviewImage, error)
+	DeleteReviewImages(reviewID int, imageIDs []int) ([]meals_models.ReviewImage, error)
+	ArrangeReviewImages(reviewID int, images []meals_models.ReviewImageUpdate) error
+	GetMealPhotos(mealID string, limit, page int) ([]meals_models.MealPhoto, int, error)
+	CreateReply(reply *meals_models.ReviewReply) error
+	GetReplyByID(replyID int) (*meals_models.ReviewReply, error)
+	UpdateReply(reply *meals_models.ReviewReply) error
+	DeleteReply(replyID int, userID string) error
+	GetReplies(reviewID int, viewerID string, limit, page int) ([]meals_models.ReviewReply, int, error)
+	GetRepliesByStatus(status string, limit, page int) ([]meals_models.ReviewReply, int, error)
+	ModerateReply(replyID int, status, reason, moderatorID string) (bool, error)
+	GetCollections(userID string) ([]meals_models.FavouriteCollection, error)
+	GetCollection(collectionID int) (*meals_models.FavouriteCollection, error)
+	CreateCollection(collection *meals_models.FavouriteCollection) error
+	RenameCollection(collectionID int, name string) error
+	DeleteCollection(collectionID int) error
+	GetCollectionMeals(collectionID, limit, page int) ([]meals_models.CollectionMeal, int, error)
+	AddCollectionMeal(collectionID int, mealID string) error
+	RemoveCollectionMeal(collectionID int, mealID string) (bool, error)
+	MoveCollectionMeal(fromID, toID int, mealID string) (bool, error)
+	ReorderCollection(collectionID int, mealIDs []string) error
     GetMealRating(mealID string) (float64, int, error)
+	GetPantryIngredients(userID string) ([]string, error)
+	GetPreferences(userID string) (*meals_models.UserPreferences, error)
+	GetMealServings(mealID string) (int, error)
+	SetMealServings(mealID string, servings int, updatedBy string) error
+	GetRecentlySeen(userID string, since time.Time) ([]meals_models.SeenMeal, error)
+	GetReviewsByUser(userID string) ([]meals_models.MealReview, error)
+	GetDailyRecommendation(userID, date string) (*meals_models.DailyRecommendation, error)
+	SaveDailyRecommendation(rec *meals_models.DailyRecommendation) (*meals_models.DailyRecommendation, error)
+	ReplaceDailyRecommendation(rec *meals_models.DailyRecommendation, staleMealID string) (*meals_models.DailyRecommendation, error)
+	AggregateMealStats(window time.Duration, priorWeight float64) error
+	GetTrendingMeals(limit, page int) ([]meals_models.MealStats, int, error)
+	GetTopRatedMeals(limit, page int) ([]meals_models.MealStats, int, error)
+	GetCoFavourites(ctx context.Context, mealID string, limit int) (map[string]int, error)
 }
 
+var (
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrAlreadyReported = errors.New("you have already reported this review")
+	ErrTooManyImages   = errors.New("too many images for one review")
+	ErrImageOrder      = errors.New("images must list every image of the review exactly once")
+)
+
 type repository struct {
 	db *database.Database
 }
@@ -35,11 +100,40 @@ func (r *repository) IsFavourite(userID, mealID string) (bool, error) {
 	return exists, err
 }
 
+// GetFavouriteStatuses reports which of the given meals the user has
+// favourited, in a single query. Meals that are not favourites are absent.
+func (r *repository) GetFavouriteStatuses(userID string, mealIDs []string) (map[string]bool, error) {
+	statuses := map[string]bool{}
+	if len(mealIDs) == 0 {
+		return statuses, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT meal_id
+		FROM favourites
+		WHERE user_id = $1 AND meal_id = ANY($2)
+	`, userID, pq.Array(mealIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		statuses[id] = true
+	}
+	return statuses, rows.Err()
+}
+
 func (r *repository) GetFavourites(userID string) ([]meals_models.Favourite, error) {
 	rows, err := r.db.Query(`
-		SELECT meal_id, meal_name, meal_thumb
+		SELECT meal_id, meal_name, meal_thumb, created_at
 		FROM favourites
 		WHERE user_id = $1
+		ORDER BY created_at DESC NULLS LAST, meal_id
 	`, userID)
 	if err != nil {
 		return nil, err
@@ -48,16 +142,74 @@ func (r *repository) GetFavourites(userID string) ([]meals_models.Favourite, err
 
 	var favourites []meals_models.Favourite
 	for rows.Next() {
-		var f meals_models.Favourite
-		if err := rows.Scan(&f.MealID, &f.MealName, &f.MealThumbImage); err != nil {
+		f, err := scanFavourite(rows)
+		if err != nil {
 			return nil, err
 		}
-		favourites = append(favourites, f)
+		favourites = append(favourites, *f)
+	}
+
+	return favourites, rows.Err()
+}
+
+// favouriteOrders maps a favourites sort to its ORDER BY clause. Favourites
+// without a date sort as the oldest.
+var favouriteOrders = map[string]string{
+	meals_models.FavouriteSortNewest: "created_at DESC NULLS LAST, meal_id",
+	meals_models.FavouriteSortOldest: "created_at ASC NULLS FIRST, meal_id",
+	meals_models.FavouriteSortName:   "LOWER(meal_name), meal_id",
+}
+
+func (r *repository) GetFavouritesPage(userID, sort string, limit, page int) ([]meals_models.Favourite, int, error) {
+	orderBy, ok := favouriteOrders[sort]
+	if !ok {
+		orderBy = favouriteOrders[meals_models.FavouriteSortNewest]
+	}
+
+	var totalItems int
+	if err := r.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM favourites
+		WHERE user_id = $1
+	`, userID).Scan(&totalItems); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	rows, err := r.db.Query(`
+		SELECT meal_id, meal_name, meal_thumb, created_at
+		FROM favourites
+		WHERE user_id = $1
+		ORDER BY `+orderBy+`
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
 	}
+	defer rows.Close()
 
-	return favourites, nil
+	favourites := []meals_models.Favourite{}
+	for rows.Next() {
+		f, err := scanFavourite(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		favourites = append(favourites, *f)
+	}
+	return favourites, totalItems, rows.Err()
 }
 
+func scanFavourite(row rowScanner) (*meals_models.Favourite, error) {
+	var f meals_models.Favourite
+	var createdAt sql.NullTime
+	if err := row.Scan(&f.MealID, &f.MealName, &f.MealThumbImage, &createdAt); err != nil {
+		return nil, err
+	}
+	if createdAt.Valid {
+		f.FavouritedAt = &createdAt.Time
+	}
+	return &f, nil
+}
 
 func (r *repository) AddFavourite(userID string, mealID, mealName, mealThumbImage string) error {
 	_, err := r.db.Exec(`
@@ -68,21 +220,68 @@ func (r *repository) AddFavourite(userID string, mealID, mealName, mealThumbImag
 	return err
 }
 
+// RemoveFavourite unfavourites a meal and takes it out of the user's collections
 func (r *repository) RemoveFavourite(userID, mealID string) error {
-	_, err := r.db.Exec(`
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		DELETE FROM favourite_collection_meals cm
+		USING favourite_collections c
+		WHERE c.id = cm.collection_id AND c.user_id = $1 AND cm.meal_id = $2
+	`, userID, mealID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
 		DELETE FROM favourites
 		WHERE user_id = $1 AND meal_id = $2
-	`, userID, mealID)
-	return err
+	`, userID, mealID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r *repository) LogLastSeen(userID, mealID, mealName, mealThumbImage string) error {
-    query := `
-        INSERT INTO user_last_seen (user_id, meal_id, meal_name, meal_thumb_image)
-        VALUES ($1, $2, $3, $4)
-    `
-    _, err := r.db.Exec(query, userID, mealID, mealName, mealThumbImage)
-    return err
+// LogLastSeen records a view of a meal. Each meal appears once per user: a
+// repeat view moves it to the top and bumps its view count. Only the keep
+// most recent meals are kept.
+func (r *repository) LogLastSeen(userID, mealID, mealName, mealThumbImage string, keep int) error {
+    tx, err := r.db.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.Exec(`
+        INSERT INTO user_last_seen (user_id, meal_id, meal_name, meal_thumb_image, seen_at, view_count)
+        VALUES ($1, $2, $3, $4, NOW(), 1)
+        ON CONFLICT (user_id, meal_id) DO UPDATE
+        SET meal_name = EXCLUDED.meal_name,
+            meal_thumb_image = EXCLUDED.meal_thumb_image,
+            seen_at = NOW(),
+            view_count = user_last_seen.view_count + 1
+    `, userID, mealID, mealName, mealThumbImage); err != nil {
+        return err
+    }
+
+    if _, err := tx.Exec(`
+        DELETE FROM user_last_seen
+        WHERE user_id = $1 AND meal_id NOT IN (
+            SELECT meal_id
+            FROM user_last_seen
+            WHERE user_id = $1
+            ORDER BY seen_at DESC
+            LIMIT $2
+        )
+    `, userID, keep); err != nil {
+        return err
+    }
+
+    return tx.Commit()
 }
 
 func (r *repository) GetLastSeen(userID string, limit, page int) ([]map[string]interface{}, int, error) {
@@ -102,7 +301,7 @@ func (r *repository) GetLastSeen(userID string, limit, page int) ([]map[string]i
 
     // fetch meals
     rows, err := r.db.Query(`
-        SELECT meal_id, meal_name, meal_thumb_image
+        SELECT meal_id, meal_name, meal_thumb_image, seen_at, view_count
         FROM user_last_seen
         WHERE user_id = $1
         ORDER BY seen_at DESC
@@ -114,21 +313,35 @@ func (r *repository) GetLastSeen(userID string, limit, page int) ([]map[string]i
     defer rows.Close()
 
     var meals []map[string]interface{}
+    var mealIDs []string
     for rows.Next() {
         var mealID, mealName, mealThumb string
-        if err := rows.Scan(&mealID, &mealName, &mealThumb); err != nil {
+        var seenAt time.Time
+        var viewCount int
+        if err := rows.Scan(&mealID, &mealName, &mealThumb, &seenAt, &viewCount); err != nil {
             return nil, 0, err
         }
 
-        isFav, _ := r.IsFavourite(userID, mealID)
-
+        mealIDs = append(mealIDs, mealID)
         meals = append(meals, map[string]interface{}{
             "mealId":         mealID,
             "mealName":       mealName,
             "mealThumbImage": mealThumb,
-            "isFavourite":    isFav,
+            "seenAt":         seenAt,
+            "viewCount":      viewCount,
         })
     }
+    if err := rows.Err(); err != nil {
+        return nil, 0, err
+    }
+
+    favourites, err := r.GetFavouriteStatuses(userID, mealIDs)
+    if err != nil {
+        return nil, 0, err
+    }
+    for _, m := range meals {
+        m["isFavourite"] = favourites[m["mealId"].(string)]
+    }
 
     return meals, totalItems, nil
 }
@@ -139,56 +352,289 @@ func (r *repository) DeleteLastSeen(userID string) error {
     return err
 }
 
-func (r *repository) CreateReview(review *meals_models.MealReview) error {
+func (r *repository) DeleteLastSeenMeal(userID, mealID string) (bool, error) {
+    res, err := r.db.Exec(`DELETE FROM user_last_seen WHERE user_id = $1 AND meal_id = $2`, userID, mealID)
+    if err != nil {
+        return false, err
+    }
+    n, err := res.RowsAffected()
+    return n > 0, err
+}
+
+// visibleReviewCondition limits public listings and ratings to approved reviews
+const visibleReviewCondition = `status = 'approved'`
+
+// reportedReason is shown to the author when reports send a review to moderation
+const reportedReason = "Hidden after community reports"
+
+// reviewColumns is the column list scanned by scanReview. The review image is
+// the first image of the review's gallery.
+const reviewColumns = `r.id, r.user_id, r.meal_id, r.rating, r.review_text,
+	COALESCE((SELECT i.image_url FROM review_images i WHERE i.review_id = r.id ORDER BY i.position LIMIT 1), ''),
+	r.created_at, r.edited_at, r.helpful_count, r.status, r.moderation_reason,
+	(SELECT COUNT(*) FROM review_replies rp WHERE rp.review_id = r.id AND rp.status = 'approved')`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanReview scans reviewColumns, followed by any extra selected columns
+func scanReview(row rowScanner, extra ...interface{}) (*meals_models.MealReview, error) {
+	var review meals_models.MealReview
+	var editedAt sql.NullTime
+	dest := []interface{}{
+		&review.ID,
+		&review.UserID,
+		&review.MealID,
+		&review.Rating,
+		&review.ReviewText,
+		&review.ReviewImage,
+		&review.CreatedAt,
+		&editedAt,
+		&review.HelpfulCount,
+		&review.Status,
+		&review.ModerationReason,
+		&review.ReplyCount,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if editedAt.Valid {
+		review.EditedAt = &editedAt.Time
+	}
+	return &review, nil
+}
+
+// UpsertReview stores the user's review for a meal. A user has at most one
+// review per meal, so posting again replaces the previous one and marks it as
+// edited. Images are stored separately and kept across updates.
+func (r *repository) UpsertReview(review *meals_models.MealReview) (bool, error) {
+	var created bool
+	var editedAt sql.NullTime
+	err := r.db.QueryRow(`
+		INSERT INTO meal_reviews (user_id, meal_id, rating, review_text, status, moderation_reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (user_id, meal_id) DO UPDATE
+		SET rating = EXCLUDED.rating,
+			review_text = EXCLUDED.review_text,
+			status = EXCLUDED.status,
+			moderation_reason = EXCLUDED.moderation_reason,
+			edited_at = NOW()
+		RETURNING id, created_at, edited_at, (xmax = 0) AS created
+	`, review.UserID, review.MealID, review.Rating, review.ReviewText, review.Status, review.ModerationReason).Scan(
+		&review.ID,
+		&review.CreatedAt,
+		&editedAt,
+		&created,
+	)
+	if err != nil {
+		return false, err
+	}
+	if editedAt.Valid {
+		review.EditedAt = &editedAt.Time
+	}
+	return created, nil
+}
+
+func (r *repository) GetReviewByID(reviewID int) (*meals_models.MealReview, error) {
+	review, err := scanReview(r.db.QueryRow(`
+		SELECT `+reviewColumns+`
+		FROM meal_reviews r
+		WHERE r.id = $1
+	`, reviewID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return review, err
+}
+
+func (r *repository) UpdateReview(review *meals_models.MealReview) error {
+	var editedAt time.Time
+	err := r.db.QueryRow(`
+		UPDATE meal_reviews
+		SET rating = $1, review_text = $2, status = $3, moderation_reason = $4, edited_at = NOW()
+		WHERE id = $5 AND user_id = $6
+		RETURNING edited_at
+	`, review.Rating, review.ReviewText, review.Status, review.ModerationReason, review.ID, review.UserID).Scan(&editedAt)
+	if err != nil {
+		return err
+	}
+	review.EditedAt = &editedAt
+	return nil
+}
+
+func (r *repository) DeleteReview(reviewID int, userID string) error {
 	_, err := r.db.Exec(`
-		INSERT INTO meal_reviews (user_id, meal_id, rating, review_text, review_image, created_at)
-		VALUES ($1, $2, $3, $4, $5, NOW())
-	`, review.UserID, review.MealID, review.Rating, review.ReviewText, review.ReviewImage)
+		DELETE FROM meal_reviews
+		WHERE id = $1 AND user_id = $2
+	`, reviewID, userID)
 	return err
 }
 
-func (r *repository) GetReviewsByMealID(mealID string, limitReviews bool) ([]meals_models.MealReview, error) {
-    var reviewQuery string
-    if limitReviews {
-        reviewQuery = `
-            SELECT r.id, r.user_id, r.meal_id, r.rating, r.review_text, r.review_image, r.created_at
-            FROM meal_reviews r
-            WHERE r.meal_id = $1
-            ORDER BY r.created_at DESC
-            LIMIT 3
-        `
-    } else {
-        reviewQuery = `
-            SELECT r.id, r.user_id, r.meal_id, r.rating, r.review_text, r.review_image, r.created_at
-            FROM meal_reviews r
-            WHERE r.meal_id = $1
-            ORDER BY r.created_at DESC
-        `
-    }
+// reviewCursor is the keyset position of the last review on a page
+type reviewCursor struct {
+	Value     int       `json:"v"`
+	CreatedAt time.Time `json:"t"`
+	ID        int       `json:"id"`
+}
+
+// reviewSortColumns maps a sort to its primary column and direction. Ties are
+// always broken by newest first, then id.
+var reviewSortColumns = map[string]struct {
+	column string
+	desc   bool
+}{
+	meals_models.ReviewSortNewest:      {"", true},
+	meals_models.ReviewSortHighest:     {"rating", true},
+	meals_models.ReviewSortLowest:      {"rating", false},
+	meals_models.ReviewSortMostHelpful: {"helpful_count", true},
+}
+
+// GetReviewsByMealID returns one page of a meal's reviews using keyset
+// pagination, along with the cursor for the next page ("" on the last page)
+func (r *repository) GetReviewsByMealID(mealID string, q meals_models.ReviewQuery) ([]meals_models.MealReview, string, error) {
+	sortSpec, ok := reviewSortColumns[q.Sort]
+	if !ok {
+		sortSpec = reviewSortColumns[meals_models.ReviewSortNewest]
+	}
+
+	args := []interface{}{mealID}
+	conditions := []string{"r.meal_id = $1", visibleReviewCondition}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.WithPhotos {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM review_images i WHERE i.review_id = r.id)")
+	}
+	if q.Stars > 0 {
+		conditions = append(conditions, "r.rating = "+arg(q.Stars))
+	}
+
+	if q.Cursor != "" {
+		cursor, err := decodeReviewCursor(q.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		after := fmt.Sprintf("(r.created_at, r.id) < (%s, %s)", arg(cursor.CreatedAt), arg(cursor.ID))
+		if sortSpec.column != "" {
+			op := ">"
+			if sortSpec.desc {
+				op = "<"
+			}
+			value := arg(cursor.Value)
+			after = fmt.Sprintf("(r.%s %s %s OR (r.%s = %s AND %s))", sortSpec.column, op, value, sortSpec.column, value, after)
+		}
+		conditions = append(conditions, after)
+	}
+
+	orderBy := "r.created_at DESC, r.id DESC"
+	if sortSpec.column != "" {
+		direction := "ASC"
+		if sortSpec.desc {
+			direction = "DESC"
+		}
+		orderBy = fmt.Sprintf("r.%s %s, %s", sortSpec.column, direction, orderBy)
+	}
+
+	// Fetch one extra row to know whether another page exists
+	limit := arg(q.Limit + 1)
+
+	rows, err := r.db.Query(`
+		SELECT `+reviewColumns+`
+		FROM meal_reviews r
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY `+orderBy+`
+		LIMIT `+limit, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	reviews := []meals_models.MealReview{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		reviews = append(reviews, *review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(reviews) > q.Limit {
+		reviews = reviews[:q.Limit]
+		last := reviews[len(reviews)-1]
+
+		cursor := reviewCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		switch sortSpec.column {
+		case "rating":
+			cursor.Value = last.Rating
+		case "helpful_count":
+			cursor.Value = last.HelpfulCount
+		}
+		nextCursor = encodeReviewCursor(cursor)
+	}
+
+	return reviews, nextCursor, nil
+}
+
+func encodeReviewCursor(cursor reviewCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeReviewCursor(encoded string) (*reviewCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor reviewCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func (r *repository) GetUserReviewForMeal(userID, mealID string) (*meals_models.MealReview, error) {
+	review, err := scanReview(r.db.QueryRow(`
+		SELECT `+reviewColumns+`
+		FROM meal_reviews r
+		WHERE r.user_id = $1 AND r.meal_id = $2
+	`, userID, mealID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return review, err
+}
 
-	rows, err := r.db.Query(reviewQuery, mealID)
+// GetRatingHistogram counts reviews per star, always including all five stars
+func (r *repository) GetRatingHistogram(mealID string) (map[string]int, error) {
+	histogram := map[string]int{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}
+
+	rows, err := r.db.Query(`
+		SELECT rating, COUNT(*)
+		FROM meal_reviews
+		WHERE meal_id = $1 AND `+visibleReviewCondition+`
+		GROUP BY rating
+	`, mealID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var reviews []meals_models.MealReview
 	for rows.Next() {
-		var r meals_models.MealReview
-		if err := rows.Scan(
-            &r.ID,
-            &r.UserID,
-            &r.MealID,
-            &r.Rating,
-            &r.ReviewText,
-            &r.ReviewImage,
-            &r.CreatedAt,
-        ); err != nil {
-            return nil, err
-        }
-		reviews = append(reviews, r)
+		var rating, count int
+		if err := rows.Scan(&rating, &count); err != nil {
+			return nil, err
+		}
+		histogram[strconv.Itoa(rating)] = count
 	}
-	return reviews, nil
+	return histogram, rows.Err()
 }
 
 func (r *repository) GetMealRating(mealID string) (float64, int, error) {
@@ -198,7 +644,7 @@ func (r *repository) GetMealRating(mealID string) (float64, int, error) {
     err := r.db.QueryRow(`
         SELECT COALESCE(AVG(rating), 0), COUNT(*)
         FROM meal_reviews
-        WHERE meal_id = $1
+        WHERE meal_id = $1 AND `+visibleReviewCondition+`
     `, mealID).Scan(&avgRating, &totalReviews)
 
     if err != nil {
@@ -209,3 +655,884 @@ func (r *repository) GetMealRating(mealID string) (float64, int, error) {
 
     return avgRating, totalReviews, nil
 }
+
+func (r *repository) GetPantryIngredients(userID string) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT ingredient_key
+		FROM user_pantry
+		WHERE user_id = $1
+		ORDER BY created_at, ingredient_key
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ingredients []string
+	for rows.Next() {
+		var ingredient string
+		if err := rows.Scan(&ingredient); err != nil {
+			return nil, err
+		}
+		ingredients = append(ingredients, ingredient)
+	}
+	return ingredients, rows.Err()
+}
+
+func (r *repository) GetPreferences(userID string) (*meals_models.UserPreferences, error) {
+	prefs := &meals_models.UserPreferences{
+		FavouriteCategories: []string{},
+		FavouriteAreas:      []string{},
+		ExcludedIngredients: []string{},
+		MeasurementSystem:   "metric",
+	}
+
+	err := r.db.QueryRow(`
+		SELECT favourite_categories, favourite_areas, excluded_ingredients, measurement_system
+		FROM user_preferences
+		WHERE user_id = $1
+	`, userID).Scan(
+		pq.Array(&prefs.FavouriteCategories),
+		pq.Array(&prefs.FavouriteAreas),
+		pq.Array(&prefs.ExcludedIngredients),
+		&prefs.MeasurementSystem,
+	)
+	if err == sql.ErrNoRows {
+		return prefs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// GetMealServings returns the stored serving count of a meal, or 0 if none
+func (r *repository) GetMealServings(mealID string) (int, error) {
+	var servings int
+	err := r.db.QueryRow(`SELECT servings FROM meal_servings WHERE meal_id = $1`, mealID).Scan(&servings)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return servings, err
+}
+
+func (r *repository) SetMealServings(mealID string, servings int, updatedBy string) error {
+	_, err := r.db.DB.Exec(`
+		INSERT INTO meal_servings (meal_id, servings, updated_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (meal_id) DO UPDATE
+		SET servings = EXCLUDED.servings,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+	`, mealID, servings, updatedBy)
+	return err
+}
+
+func (r *repository) GetRecentlySeen(userID string, since time.Time) ([]meals_models.SeenMeal, error) {
+	rows, err := r.db.Query(`
+		SELECT meal_id, meal_name, MAX(seen_at) AS last_seen
+		FROM user_last_seen
+		WHERE user_id = $1 AND seen_at >= $2
+		GROUP BY meal_id, meal_name
+		ORDER BY last_seen DESC
+	`, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seen []meals_models.SeenMeal
+	for rows.Next() {
+		var m meals_models.SeenMeal
+		if err := rows.Scan(&m.MealID, &m.MealName, &m.SeenAt); err != nil {
+			return nil, err
+		}
+		seen = append(seen, m)
+	}
+	return seen, rows.Err()
+}
+
+func (r *repository) GetReviewsByUser(userID string) ([]meals_models.MealReview, error) {
+	rows, err := r.db.Query(`
+		SELECT `+reviewColumns+`
+		FROM meal_reviews r
+		WHERE r.user_id = $1
+		ORDER BY r.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []meals_models.MealReview
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, *review)
+	}
+	return reviews, rows.Err()
+}
+
+func (r *repository) GetDailyRecommendation(userID, date string) (*meals_models.DailyRecommendation, error) {
+	rec := &meals_models.DailyRecommendation{UserID: userID, Date: date}
+	err := r.db.QueryRow(`
+		SELECT meal_id, explanation
+		FROM daily_recommendations
+		WHERE user_id = $1 AND recommended_on = $2
+	`, userID, date).Scan(&rec.MealID, &rec.Explanation)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// SaveDailyRecommendation stores the pick for the day. If a concurrent request
+// already stored one, the existing pick wins and is returned instead.
+func (r *repository) SaveDailyRecommendation(rec *meals_models.DailyRecommendation) (*meals_models.DailyRecommendation, error) {
+	_, err := r.db.Exec(`
+		INSERT INTO daily_recommendations (user_id, recommended_on, meal_id, explanation)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, recommended_on) DO NOTHING
+	`, rec.UserID, rec.Date, rec.MealID, rec.Explanation)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetDailyRecommendation(rec.UserID, rec.Date)
+}
+
+// ReplaceDailyRecommendation swaps the day's pick for rec while it is still
+// staleMealID. If a concurrent request already replaced it, that pick wins and
+// is returned instead.
+func (r *repository) ReplaceDailyRecommendation(rec *meals_models.DailyRecommendation, staleMealID string) (*meals_models.DailyRecommendation, error) {
+	_, err := r.db.Exec(`
+		INSERT INTO daily_recommendations (user_id, recommended_on, meal_id, explanation)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, recommended_on) DO UPDATE
+		SET meal_id = EXCLUDED.meal_id,
+			explanation = EXCLUDED.explanation
+		WHERE daily_recommendations.meal_id = $5
+	`, rec.UserID, rec.Date, rec.MealID, rec.Explanation, staleMealID)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetDailyRecommendation(rec.UserID, rec.Date)
+}
+
+// AggregateMealStats rebuilds meal_stats from views, favourites and reviews.
+// Trending weighs views, favourites and reviews inside the sliding window; the Bayesian rating pulls
+// meals with few reviews towards the global mean so a single 5-star review
+// cannot dominate the top-rated list.
+func (r *repository) AggregateMealStats(window time.Duration, priorWeight float64) error {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM meal_stats`); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		WITH views AS (
+			-- one row per user and meal, so this counts recent viewers
+			SELECT meal_id, COUNT(*) AS views
+			FROM user_last_seen
+			WHERE seen_at >= NOW() - $1::interval
+			GROUP BY meal_id
+		), favs AS (
+			SELECT meal_id, COUNT(*) AS favourites
+			FROM favourites
+			GROUP BY meal_id
+		), recent_favs AS (
+			SELECT meal_id, COUNT(*) AS favourites
+			FROM favourites
+			WHERE created_at >= NOW() - $1::interval
+			GROUP BY meal_id
+		), recent_reviews AS (
+			SELECT meal_id, COUNT(*) AS reviews, AVG(rating) AS avg_rating
+			FROM meal_reviews
+			WHERE created_at >= NOW() - $1::interval AND `+visibleReviewCondition+`
+			GROUP BY meal_id
+		), all_reviews AS (
+			SELECT meal_id, COUNT(*) AS reviews, AVG(rating) AS avg_rating
+			FROM meal_reviews
+			WHERE `+visibleReviewCondition+`
+			GROUP BY meal_id
+		), global AS (
+			SELECT COALESCE(AVG(rating), 0) AS mean
+			FROM meal_reviews
+			WHERE `+visibleReviewCondition+`
+		), names AS (
+			SELECT DISTINCT ON (meal_id) meal_id, meal_name, meal_thumb
+			FROM (
+				SELECT meal_id, meal_name, meal_thumb_image AS meal_thumb, seen_at AS seen
+				FROM user_last_seen
+				UNION ALL
+				SELECT meal_id, meal_name, meal_thumb, NULL
+				FROM favourites
+			) n
+			ORDER BY meal_id, seen DESC NULLS LAST
+		), ids AS (
+			SELECT meal_id FROM views
+			UNION SELECT meal_id FROM favs
+			UNION SELECT meal_id FROM all_reviews
+		)
+		INSERT INTO meal_stats (
+			meal_id, meal_name, meal_thumb, recent_views, favourite_count, recent_reviews,
+			total_reviews, avg_rating, bayesian_rating, trending_score, updated_at
+		)
+		SELECT
+			ids.meal_id,
+			COALESCE(n.meal_name, ''),
+			COALESCE(n.meal_thumb, ''),
+			COALESCE(v.views, 0),
+			COALESCE(f.favourites, 0),
+			COALESCE(rr.reviews, 0),
+			COALESCE(ar.reviews, 0),
+			COALESCE(ar.avg_rating, 0),
+			($2 * g.mean + COALESCE(ar.avg_rating, 0) * COALESCE(ar.reviews, 0)) / ($2 + COALESCE(ar.reviews, 0)),
+			COALESCE(v.views, 0) * 1.0
+				+ COALESCE(rf.favourites, 0) * 3.0
+				+ COALESCE(rr.reviews, 0) * 2.0 * COALESCE(rr.avg_rating, 0) / 5.0,
+			NOW()
+		FROM ids
+		CROSS JOIN global g
+		LEFT JOIN names n ON n.meal_id = ids.meal_id
+		LEFT JOIN views v ON v.meal_id = ids.meal_id
+		LEFT JOIN favs f ON f.meal_id = ids.meal_id
+		LEFT JOIN recent_favs rf ON rf.meal_id = ids.meal_id
+		LEFT JOIN recent_reviews rr ON rr.meal_id = ids.meal_id
+		LEFT JOIN all_reviews ar ON ar.meal_id = ids.meal_id
+		-- user recipes are only ranked while they are public
+		WHERE ids.meal_id NOT LIKE 'u-%'
+		   OR EXISTS (SELECT 1 FROM user_recipes ur WHERE 'u-' || ur.id = ids.meal_id AND ur.visibility = 'public')
+	`, fmt.Sprintf("%d seconds", int(window.Seconds())), priorWeight)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) GetTrendingMeals(limit, page int) ([]meals_models.MealStats, int, error) {
+	return r.getMealStats(`trending_score > 0`, `trending_score DESC, meal_id`, limit, page)
+}
+
+func (r *repository) GetTopRatedMeals(limit, page int) ([]meals_models.MealStats, int, error) {
+	return r.getMealStats(`total_reviews > 0`, `bayesian_rating DESC, total_reviews DESC, meal_id`, limit, page)
+}
+
+func (r *repository) getMealStats(where, orderBy string, limit, page int) ([]meals_models.MealStats, int, error) {
+	var totalItems int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM meal_stats WHERE ` + where).Scan(&totalItems); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	rows, err := r.db.Query(`
+		SELECT meal_id, meal_name, meal_thumb, recent_views, favourite_count, recent_reviews,
+			total_reviews, avg_rating, bayesian_rating, trending_score, updated_at
+		FROM meal_stats
+		WHERE `+where+`
+		ORDER BY `+orderBy+`
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var stats []meals_models.MealStats
+	for rows.Next() {
+		var m meals_models.MealStats
+		if err := rows.Scan(
+			&m.MealID,
+			&m.MealName,
+			&m.MealThumbImage,
+			&m.RecentViews,
+			&m.FavouriteCount,
+			&m.RecentReviews,
+			&m.TotalReviews,
+			&m.AvgRating,
+			&m.BayesianRating,
+			&m.TrendingScore,
+			&m.UpdatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		m.AvgRating = math.Round(m.AvgRating*10) / 10
+		m.BayesianRating = math.Round(m.BayesianRating*100) / 100
+		m.TrendingScore = math.Round(m.TrendingScore*100) / 100
+		stats = append(stats, m)
+	}
+	return stats, totalItems, rows.Err()
+}
+
+// GetCoFavourites counts, for each other meal, how many users favourited it
+// alongside mealID
+func (r *repository) GetCoFavourites(ctx context.Context, mealID string, limit int) (map[string]int, error) {
+	rows, err := r.db.DB.QueryContext(ctx, `
+		SELECT f2.meal_id, COUNT(*) AS shared
+		FROM favourites f1
+		JOIN favourites f2 ON f2.user_id = f1.user_id AND f2.meal_id <> f1.meal_id
+		WHERE f1.meal_id = $1
+		GROUP BY f2.meal_id
+		ORDER BY shared DESC, f2.meal_id
+		LIMIT $2
+	`, mealID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coFavourites := map[string]int{}
+	for rows.Next() {
+		var id string
+		var shared int
+		if err := rows.Scan(&id, &shared); err != nil {
+			return nil, err
+		}
+		coFavourites[id] = shared
+	}
+	return coFavourites, rows.Err()
+}
+
+// ToggleHelpfulVote adds the user's helpful vote, or removes it if they had
+// already voted, keeping the review's helpful_count in step
+func (r *repository) ToggleHelpfulVote(reviewID int, userID string) (bool, int, error) {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	// Insert first: a concurrent first vote waits on the conflicting row
+	// and then removes it, instead of failing on the primary key
+	res, err := tx.Exec(`
+		INSERT INTO review_helpful_votes (review_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (review_id, user_id) DO NOTHING
+	`, reviewID, userID)
+	if err != nil {
+		return false, 0, err
+	}
+
+	voted := true
+	delta := 1
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := tx.Exec(`
+			DELETE FROM review_helpful_votes
+			WHERE review_id = $1 AND user_id = $2
+		`, reviewID, userID); err != nil {
+			return false, 0, err
+		}
+		voted = false
+		delta = -1
+	}
+
+	var helpfulCount int
+	if err := tx.QueryRow(`
+		UPDATE meal_reviews
+		SET helpful_count = GREATEST(helpful_count + $1, 0)
+		WHERE id = $2
+		RETURNING helpful_count
+	`, delta, reviewID).Scan(&helpfulCount); err != nil {
+		return false, 0, err
+	}
+
+	return voted, helpfulCount, tx.Commit()
+}
+
+// GetViewerVotes reports which of the given reviews the user marked helpful
+func (r *repository) GetViewerVotes(userID string, reviewIDs []int) (map[int]bool, error) {
+	votes := map[int]bool{}
+	if len(reviewIDs) == 0 {
+		return votes, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT review_id
+		FROM review_helpful_votes
+		WHERE user_id = $1 AND review_id = ANY($2)
+	`, userID, pq.Array(reviewIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		votes[id] = true
+	}
+	return votes, rows.Err()
+}
+
+// ReportReview files the user's report and hides the review once the number
+// of distinct reporters reaches hideThreshold. It returns whether the review
+// is now hidden.
+func (r *repository) ReportReview(reviewID int, userID, reason, details string, hideThreshold int) (bool, error) {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO review_reports (review_id, user_id, reason, details)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (review_id, user_id) DO NOTHING
+	`, reviewID, userID, reason, details)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, ErrAlreadyReported
+	}
+
+	var hidden bool
+	if err := tx.QueryRow(`
+		UPDATE meal_reviews
+		SET report_count = report_count + 1,
+			status = CASE WHEN status = 'approved' AND report_count + 1 >= $1 THEN 'pending' ELSE status END,
+			moderation_reason = CASE WHEN status = 'approved' AND report_count + 1 >= $1 THEN $2 ELSE moderation_reason END
+		WHERE id = $3
+		RETURNING status <> 'approved'
+	`, hideThreshold, reportedReason, reviewID).Scan(&hidden); err != nil {
+		return false, err
+	}
+
+	return hidden, tx.Commit()
+}
+
+// GetReviewsByStatus lists reviews in a moderation state, oldest first, with
+// the reports filed against each
+func (r *repository) GetReviewsByStatus(status string, limit, page int) ([]meals_models.ModerationItem, int, error) {
+	var totalItems int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM meal_reviews WHERE status = $1`, status).Scan(&totalItems); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	rows, err := r.db.Query(`
+		SELECT `+reviewColumns+`, r.report_count,
+			COALESCE(ARRAY(
+				SELECT DISTINCT rr.reason FROM review_reports rr WHERE rr.review_id = r.id
+			), '{}')
+		FROM meal_reviews r
+		WHERE r.status = $1
+		ORDER BY r.created_at, r.id
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []meals_models.ModerationItem{}
+	for rows.Next() {
+		var item meals_models.ModerationItem
+		review, err := scanReview(rows, &item.ReportCount, pq.Array(&item.ReportReasons))
+		if err != nil {
+			return nil, 0, err
+		}
+		item.Review = *review
+		items = append(items, item)
+	}
+	return items, totalItems, rows.Err()
+}
+
+// ModerateReview records a moderator's decision. Approving clears the report
+// count so the review can only be hidden again by new reports.
+func (r *repository) ModerateReview(reviewID int, status, reason, moderatorID string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE meal_reviews
+		SET status = $1,
+			moderation_reason = $2,
+			moderated_at = NOW(),
+			moderated_by = $3,
+			report_count = CASE WHEN $1 = 'approved' THEN 0 ELSE report_count END
+		WHERE id = $4
+	`, status, reason, moderatorID, reviewID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// AddReviewImages appends images to the end of a review's gallery. The review
+// row is locked so concurrent uploads cannot exceed maxImages.
+func (r *repository) AddReviewImages(reviewID int, images []meals_models.ReviewImage, maxImages int) ([]meals_models.ReviewImage, error) {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT 1 FROM meal_reviews WHERE id = $1 FOR UPDATE`, reviewID); err != nil {
+		return nil, err
+	}
+
+	var count, nextPosition int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(MAX(position) + 1, 0)
+		FROM review_images
+		WHERE review_id = $1
+	`, reviewID).Scan(&count, &nextPosition); err != nil {
+		return nil, err
+	}
+	if count+len(images) > maxImages {
+		return nil, ErrTooManyImages
+	}
+
+	added := make([]meals_models.ReviewImage, 0, len(images))
+	for i, img := range images {
+		img.ReviewID = reviewID
+		img.Position = nextPosition + i
+		if err := tx.QueryRow(`
+			INSERT INTO review_images (review_id, public_id, image_url, caption, position)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at
+		`, img.ReviewID, img.PublicID, img.ImageURL, img.Caption, img.Position).Scan(&img.ID, &img.CreatedAt); err != nil {
+			return nil, err
+		}
+		added = append(added, img)
+	}
+
+	return added, tx.Commit()
+}
+
+// GetReviewImages returns the images of each review in gallery order
+func (r *repository) GetReviewImages(reviewIDs []int) (map[int][]meals_models.ReviewImage, error) {
+	images := map[int][]meals_models.ReviewImage{}
+	if len(reviewIDs) == 0 {
+		return images, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, review_id, public_id, image_url, caption, position, created_at
+		FROM review_images
+		WHERE review_id = ANY($1)
+		ORDER BY review_id, position
+	`, pq.Array(reviewIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var img meals_models.ReviewImage
+		if err := rows.Scan(&img.ID, &img.ReviewID, &img.PublicID, &img.ImageURL, &img.Caption, &img.Position, &img.CreatedAt); err != nil {
+			return nil, err
+		}
+		images[img.ReviewID] = append(images[img.ReviewID], img)
+	}
+	return images, rows.Err()
+}
+
+// DeleteReviewImages removes images from a review, or all of them when
+// imageIDs is nil, and closes the gaps left in the ordering. It returns the
+// removed images so their stored files can be cleaned up.
+func (r *repository) DeleteReviewImages(reviewID int, imageIDs []int) ([]meals_models.ReviewImage, error) {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM review_images
+		WHERE review_id = $1
+		RETURNING id, review_id, public_id, image_url, caption, position, created_at
+	`
+	args := []interface{}{reviewID}
+	if imageIDs != nil {
+		query = `
+			DELETE FROM review_images
+			WHERE review_id = $1 AND id = ANY($2)
+			RETURNING id, review_id, public_id, image_url, caption, position, created_at
+		`
+		args = append(args, pq.Array(imageIDs))
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	removed := []meals_models.ReviewImage{}
+	for rows.Next() {
+		var img meals_models.ReviewImage
+		if err := rows.Scan(&img.ID, &img.ReviewID, &img.PublicID, &img.ImageURL, &img.Caption, &img.Position, &img.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		removed = append(removed, img)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE review_images i
+		SET position = o.position
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY position) - 1 AS position
+			FROM review_images
+			WHERE review_id = $1
+		) o
+		WHERE i.id = o.id AND i.position <> o.position
+	`, reviewID); err != nil {
+		return nil, err
+	}
+
+	return removed, tx.Commit()
+}
+
+// ArrangeReviewImages sets the order and captions of a review's images. The
+// list must name every image of the review exactly once.
+func (r *repository) ArrangeReviewImages(reviewID int, images []meals_models.ReviewImageUpdate) error {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id FROM review_images WHERE review_id = $1 FOR UPDATE`, reviewID)
+	if err != nil {
+		return err
+	}
+	existing := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(images) != len(existing) {
+		return ErrImageOrder
+	}
+	seen := map[int]bool{}
+	for _, img := range images {
+		if !existing[img.ID] || seen[img.ID] {
+			return ErrImageOrder
+		}
+		seen[img.ID] = true
+	}
+
+	for position, img := range images {
+		if _, err := tx.Exec(`
+			UPDATE review_images
+			SET position = $1, caption = $2
+			WHERE id = $3
+		`, position, img.Caption, img.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMealPhotos lists the images of a meal's approved reviews, newest review
+// first and in gallery order within a review
+func (r *repository) GetMealPhotos(mealID string, limit, page int) ([]meals_models.MealPhoto, int, error) {
+	var totalItems int
+	if err := r.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM review_images i
+		JOIN meal_reviews r ON r.id = i.review_id
+		WHERE r.meal_id = $1 AND r.`+visibleReviewCondition+`
+	`, mealID).Scan(&totalItems); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	rows, err := r.db.Query(`
+		SELECT i.id, i.review_id, i.public_id, i.image_url, i.caption, i.position, i.created_at, r.user_id, r.rating
+		FROM review_images i
+		JOIN meal_reviews r ON r.id = i.review_id
+		WHERE r.meal_id = $1 AND r.`+visibleReviewCondition+`
+		ORDER BY r.created_at DESC, r.id DESC, i.position
+		LIMIT $2 OFFSET $3
+	`, mealID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	photos := []meals_models.MealPhoto{}
+	for rows.Next() {
+		var p meals_models.MealPhoto
+		if err := rows.Scan(&p.ID, &p.ReviewID, &p.PublicID, &p.ImageURL, &p.Caption, &p.Position, &p.CreatedAt, &p.UserID, &p.Rating); err != nil {
+			return nil, 0, err
+		}
+		photos = append(photos, p)
+	}
+	return photos, totalItems, rows.Err()
+}
+
+// replyColumns is the column list scanned by scanReply
+const replyColumns = `id, review_id, user_id, reply_text, status, moderation_reason, created_at, edited_at`
+
+func scanReply(row rowScanner) (*meals_models.ReviewReply, error) {
+	var reply meals_models.ReviewReply
+	var editedAt sql.NullTime
+	if err := row.Scan(
+		&reply.ID,
+		&reply.ReviewID,
+		&reply.UserID,
+		&reply.ReplyText,
+		&reply.Status,
+		&reply.ModerationReason,
+		&reply.CreatedAt,
+		&editedAt,
+	); err != nil {
+		return nil, err
+	}
+	if editedAt.Valid {
+		reply.EditedAt = &editedAt.Time
+	}
+	return &reply, nil
+}
+
+func (r *repository) CreateReply(reply *meals_models.ReviewReply) error {
+	return r.db.QueryRow(`
+		INSERT INTO review_replies (review_id, user_id, reply_text, status, moderation_reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, reply.ReviewID, reply.UserID, reply.ReplyText, reply.Status, reply.ModerationReason).Scan(&reply.ID, &reply.CreatedAt)
+}
+
+func (r *repository) GetReplyByID(replyID int) (*meals_models.ReviewReply, error) {
+	reply, err := scanReply(r.db.QueryRow(`
+		SELECT `+replyColumns+`
+		FROM review_replies
+		WHERE id = $1
+	`, replyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return reply, err
+}
+
+func (r *repository) UpdateReply(reply *meals_models.ReviewReply) error {
+	var editedAt time.Time
+	err := r.db.QueryRow(`
+		UPDATE review_replies
+		SET reply_text = $1, status = $2, moderation_reason = $3, edited_at = NOW()
+		WHERE id = $4 AND user_id = $5
+		RETURNING edited_at
+	`, reply.ReplyText, reply.Status, reply.ModerationReason, reply.ID, reply.UserID).Scan(&editedAt)
+	if err != nil {
+		return err
+	}
+	reply.EditedAt = &editedAt
+	return nil
+}
+
+func (r *repository) DeleteReply(replyID int, userID string) error {
+	_, err := r.db.Exec(`
+		DELETE FROM review_replies
+		WHERE id = $1 AND user_id = $2
+	`, replyID, userID)
+	return err
+}
+
+// GetReplies lists a review's approved replies oldest first, so a thread reads
+// top to bottom. The viewer also sees their own replies awaiting moderation.
+func (r *repository) GetReplies(reviewID int, viewerID string, limit, page int) ([]meals_models.ReviewReply, int, error) {
+	var totalItems int
+	if err := r.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM review_replies
+		WHERE review_id = $1 AND (`+visibleReviewCondition+` OR user_id::text = $2)
+	`, reviewID, viewerID).Scan(&totalItems); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	rows, err := r.db.Query(`
+		SELECT `+replyColumns+`
+		FROM review_replies
+		WHERE review_id = $1 AND (`+visibleReviewCondition+` OR user_id::text = $2)
+		ORDER BY created_at, id
+		LIMIT $3 OFFSET $4
+	`, reviewID, viewerID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	replies := []meals_models.ReviewReply{}
+	for rows.Next() {
+		reply, err := scanReply(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		replies = append(replies, *reply)
+	}
+	return replies, totalItems, rows.Err()
+}
+
+// GetRepliesByStatus lists replies in a moderation state, oldest first
+func (r *repository) GetRepliesByStatus(status string, limit, page int) ([]meals_models.ReviewReply, int, error) {
+	var totalItems int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM review_replies WHERE status = $1`, status).Scan(&totalItems); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	rows, err := r.db.Query(`
+		SELECT `+replyColumns+`
+		FROM review_replies
+		WHERE status = $1
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	replies := []meals_models.ReviewReply{}
+	for rows.Next() {
+		reply, err := scanReply(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		replies = append(replies, *reply)
+	}
+	return replies, totalItems, rows.Err()
+}
+
+func (r *repository) ModerateReply(replyID int, status, reason, moderatorID string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE review_replies
+		SET status = $1,
+			moderation_reason = $2,
+			moderated_at = NOW(),
+			moderated_by = $3
+		WHERE id = $4
+	`, status, reason, moderatorID, replyID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
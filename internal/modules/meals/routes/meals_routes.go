@@ -1,23 +1,27 @@
 package meals_routes
 
 import (
+	"context"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/JonathanTriC/nomie-api/internal/database"
 	auth_middleware "github.com/JonathanTriC/nomie-api/internal/modules/auth/middleware"
 	meals_handlers "github.com/JonathanTriC/nomie-api/internal/modules/meals/handlers"
+	meals_jobs "github.com/JonathanTriC/nomie-api/internal/modules/meals/jobs"
 	meals_repository "github.com/JonathanTriC/nomie-api/internal/modules/meals/repository"
 	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
 )
 
-func RegisterRoutes(r *gin.Engine, db *database.Database, jwtSecret string) {
-	// Initialize repository
-	repo := meals_repository.NewRepository(db)
-
-	service := meals_services.NewService(repo)
+// RegisterRoutes serves the meals module with the service the other modules
+// share, so they all see the same caches
+func RegisterRoutes(ctx context.Context, r *gin.Engine, db *database.Database, repo meals_repository.Repository, service meals_services.Service, jwtSecret string) {
+	// Start background jobs
+	meals_jobs.StartStatsAggregator(ctx, repo)
+	lastSeen := meals_jobs.StartLastSeenRecorder(repo)
 
 	// Initialize handler
-	handler := meals_handlers.NewHandler(service, repo, db, []byte(jwtSecret))
+	handler := meals_handlers.NewHandler(service, repo, lastSeen, db, []byte(jwtSecret))
 
 	// Define routes
 	mealGroup := r.Group("/v1/meals")
@@ -32,17 +36,81 @@ func RegisterRoutes(r *gin.Engine, db *database.Database, jwtSecret string) {
 			// MARK: Straight from {Area} Kitchens
 			protected.GET("/cuisine-picks", handler.GetCuisinePicks)
 			protected.GET("/search/:query", handler.GetSearchMeals)
+			// MARK: Trending Right Now
+			protected.GET("/trending", handler.GetTrendingMeals)
+			// MARK: Top Rated by the Community
+			protected.GET("/top-rated", handler.GetTopRatedMeals)
+			// MARK: What Can I Cook?
+			protected.GET("/what-can-i-cook", handler.GetPantryMatches)
 			protected.GET("/detail/:mealId", handler.GetMealDetail)
+			// MARK: You Might Also Like
+			protected.GET("/:mealId/similar", handler.GetSimilarMeals)
+			// MARK: Photos from the Community
+			protected.GET("/:mealId/photos", handler.GetMealPhotos)
+			// MARK: Cooking Mode
+			protected.GET("/:mealId/steps", handler.GetMealSteps)
+			// MARK: Print & Share
+			protected.GET("/:mealId/export", handler.ExportMeal)
 			// MARK: Your Tasty Collection
 			protected.GET("/favourites", handler.GetFavourites)
 			protected.POST("/favourites", handler.SetFavourite)
 			protected.DELETE("/favourites/:mealId", handler.UnsetFavourite)
+			// MARK: Your Collections
+			protected.GET("/collections", handler.GetCollections)
+			protected.POST("/collections", handler.CreateCollection)
+			protected.GET("/collections/:collectionId", handler.GetCollection)
+			protected.PUT("/collections/:collectionId", handler.RenameCollection)
+			protected.DELETE("/collections/:collectionId", handler.DeleteCollection)
+			protected.POST("/collections/:collectionId/meals", handler.AddCollectionMeal)
+			protected.PUT("/collections/:collectionId/meals", handler.ReorderCollection)
+			protected.DELETE("/collections/:collectionId/meals/:mealId", handler.RemoveCollectionMeal)
+			protected.POST("/collections/:collectionId/meals/:mealId/move", handler.MoveCollectionMeal)
 			// MARK: Back for Another Bite?
 			protected.GET("/last-seen", handler.GetLastSeen)
 			protected.DELETE("/last-seen", handler.DeleteLastSeen)
+			protected.DELETE("/last-seen/:mealId", handler.DeleteLastSeenMeal)
 
 			protected.POST("/reviews/:mealId", handler.CreateReviewMeals)
+			protected.PUT("/reviews/:reviewId", handler.UpdateReviewMeals)
+			protected.DELETE("/reviews/:reviewId", handler.DeleteReviewMeals)
+
 			protected.GET("/all-reviews/:mealId", handler.GetAllMealsReview)
 		}
 	}
+
+	// Actions on a single review. These live outside /v1/meals/reviews because
+	// POST /v1/meals/reviews/:mealId already claims that wildcard.
+	reviewGroup := r.Group("/v1/reviews")
+	{
+		protected := reviewGroup.Group("")
+		protected.Use(auth_middleware.AuthMiddleware([]byte(jwtSecret)))
+		{
+			protected.POST("/:reviewId/helpful", handler.ToggleHelpfulVote)
+			protected.POST("/:reviewId/report", handler.ReportReview)
+			protected.PUT("/:reviewId/images", handler.ArrangeReviewImages)
+
+			protected.GET("/:reviewId/replies", handler.GetReviewReplies)
+			protected.POST("/:reviewId/replies", handler.CreateReply)
+			protected.PUT("/replies/:replyId", handler.UpdateReply)
+			protected.DELETE("/replies/:replyId", handler.DeleteReply)
+		}
+	}
+
+	// Moderation queues for admins
+	adminGroup := r.Group("/v1/admin")
+	{
+		protected := adminGroup.Group("")
+		protected.Use(auth_middleware.AuthMiddleware([]byte(jwtSecret)), auth_middleware.AdminMiddleware(db))
+		{
+			protected.GET("/reviews", handler.GetModerationQueue)
+			protected.POST("/reviews/:reviewId/approve", handler.ApproveReview)
+			protected.POST("/reviews/:reviewId/reject", handler.RejectReview)
+
+			protected.GET("/replies", handler.GetReplyModerationQueue)
+			protected.POST("/replies/:replyId/approve", handler.ApproveReply)
+			protected.POST("/replies/:replyId/reject", handler.RejectReply)
+
+			protected.PUT("/meals/:mealId/servings", handler.SetMealServings)
+		}
+	}
 }
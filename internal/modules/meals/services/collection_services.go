@@ -0,0 +1,151 @@
+package meals_services
+
+import (
+	"errors"
+	"math"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+)
+
+var ErrCollectionNotFound = errors.New("collection not found")
+
+func (s *service) GetCollections(userID string) ([]meals_models.FavouriteCollection, error) {
+	return s.repo.GetCollections(userID)
+}
+
+func (s *service) CreateCollection(userID, name string) (*meals_models.FavouriteCollection, error) {
+	collection := &meals_models.FavouriteCollection{UserID: userID, Name: name}
+	if err := s.repo.CreateCollection(collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (s *service) GetCollection(userID string, collectionID, limit, page int) (map[string]interface{}, error) {
+	collection, err := s.ownCollection(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.collectionPage(collection, limit, page)
+}
+
+func (s *service) RenameCollection(userID string, collectionID int, name string) (*meals_models.FavouriteCollection, error) {
+	if _, err := s.ownCollection(userID, collectionID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RenameCollection(collectionID, name); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCollection(collectionID)
+}
+
+func (s *service) DeleteCollection(userID string, collectionID int) error {
+	if _, err := s.ownCollection(userID, collectionID); err != nil {
+		return err
+	}
+	return s.repo.DeleteCollection(collectionID)
+}
+
+// AddCollectionMeal puts a meal in a collection, favouriting it first if
+// needed so the "All" favourites view always includes it
+func (s *service) AddCollectionMeal(userID string, collectionID int, mealID string) (*meals_models.FavouriteCollection, error) {
+	if _, err := s.ownCollection(userID, collectionID); err != nil {
+		return nil, err
+	}
+
+	favourites, err := s.repo.GetFavouriteStatuses(userID, []string{mealID})
+	if err != nil {
+		return nil, err
+	}
+	if !favourites[mealID] {
+		if err := s.SetFavourite(userID, mealID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.AddCollectionMeal(collectionID, mealID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCollection(collectionID)
+}
+
+// RemoveCollectionMeal takes a meal out of a collection; it stays a favourite
+func (s *service) RemoveCollectionMeal(userID string, collectionID int, mealID string) error {
+	if _, err := s.ownCollection(userID, collectionID); err != nil {
+		return err
+	}
+	found, err := s.repo.RemoveCollectionMeal(collectionID, mealID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrMealNotFound
+	}
+	return nil
+}
+
+func (s *service) MoveCollectionMeal(userID string, fromID, toID int, mealID string) error {
+	if _, err := s.ownCollection(userID, fromID); err != nil {
+		return err
+	}
+	if _, err := s.ownCollection(userID, toID); err != nil {
+		return err
+	}
+	if fromID == toID {
+		return nil
+	}
+
+	found, err := s.repo.MoveCollectionMeal(fromID, toID, mealID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrMealNotFound
+	}
+	return nil
+}
+
+func (s *service) ReorderCollection(userID string, collectionID int, mealIDs []string) error {
+	if _, err := s.ownCollection(userID, collectionID); err != nil {
+		return err
+	}
+	return s.repo.ReorderCollection(collectionID, mealIDs)
+}
+
+// ViewCollection shows one of ownerID's collections to someone else, as a
+// share link does
+func (s *service) ViewCollection(ownerID string, collectionID, limit, page int) (map[string]interface{}, error) {
+	collection, err := s.ownCollection(ownerID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.collectionPage(collection, limit, page)
+}
+
+// ownCollection loads a collection that belongs to userID. Other users'
+// collections are reported as not found.
+func (s *service) ownCollection(userID string, collectionID int) (*meals_models.FavouriteCollection, error) {
+	collection, err := s.repo.GetCollection(collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if collection == nil || collection.UserID != userID {
+		return nil, ErrCollectionNotFound
+	}
+	return collection, nil
+}
+
+func (s *service) collectionPage(collection *meals_models.FavouriteCollection, limit, page int) (map[string]interface{}, error) {
+	meals, totalItems, err := s.repo.GetCollectionMeals(collection.ID, limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"collection": collection,
+		"meals":      meals,
+		"page":       page,
+		"totalItems": totalItems,
+		"totalPages": int(math.Ceil(float64(totalItems) / float64(limit))),
+	}, nil
+}
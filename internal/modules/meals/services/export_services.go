@@ -0,0 +1,106 @@
+package meals_services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	"github.com/JonathanTriC/nomie-api/pkg/recipeexport"
+	"github.com/JonathanTriC/nomie-api/pkg/steps"
+)
+
+// ExportMeal prepares a meal for printing or sharing: its ingredients scaled
+// and converted as on the detail page, its method split into steps and where
+// it came from
+func (s *service) ExportMeal(userID, mealID string, opts meals_models.DetailOptions) (*recipeexport.Recipe, error) {
+	meal, err := s.LookupMeal(userID, mealID)
+	if err != nil {
+		return nil, err
+	}
+	system, err := s.measurementSystem(userID, opts.MeasurementSystem)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scaleMeal(meal, opts.Servings, system); err != nil {
+		return nil, err
+	}
+
+	recipe := &recipeexport.Recipe{
+		Title:    meal.MealName,
+		Subtitle: meal.MealAlternate,
+		ImageURL: meal.MealThumbImage,
+	}
+
+	addFact := func(label, value string) {
+		if value != "" {
+			recipe.Facts = append(recipe.Facts, recipeexport.Fact{Label: label, Value: value})
+		}
+	}
+	addFact("Category", meal.MealCategory)
+	addFact("Cuisine", meal.MealArea)
+	serves := strconv.Itoa(meal.Servings)
+	if meal.ServingsSource == meals_models.ServingsSourceEstimated && meal.Servings == meal.BaseServings {
+		serves += " (estimated)"
+	}
+	addFact("Serves", serves)
+	addFact("Prep time", formatMinutes(meal.PrepMinutes))
+	addFact("Cook time", formatMinutes(meal.CookMinutes))
+	addFact("Total time", formatMinutes(meal.TotalMinutes))
+	addFact("Tags", strings.ReplaceAll(meal.MealTags, ",", ", "))
+
+	names := make([]string, 0, len(meal.MealIngredient))
+	unscaled := false
+	for _, ingredient := range meal.MealIngredient {
+		measureText := ingredient.DisplayMeasure
+		if measureText == "" {
+			measureText = ingredient.IngredientMeasure
+		}
+		recipe.Ingredients = append(recipe.Ingredients, recipeexport.Ingredient{
+			Measure: strings.TrimSpace(measureText),
+			Name:    ingredient.IngredientName,
+		})
+		names = append(names, ingredient.IngredientName)
+		unscaled = unscaled || ingredient.Unscaled
+	}
+	if meal.Servings != meal.BaseServings {
+		recipe.IngredientNote = fmt.Sprintf("Measures scaled from %d to %d servings.", meal.BaseServings, meal.Servings)
+		if unscaled {
+			recipe.IngredientNote += " Some could not be scaled and are as written."
+		}
+	}
+
+	for _, step := range steps.Parse(meal.MealInstructions, names) {
+		recipe.Steps = append(recipe.Steps, step.Text)
+	}
+
+	if meal.MealSource != "" {
+		recipe.Links = append(recipe.Links, recipeexport.Link{Label: "Original recipe", URL: meal.MealSource})
+	}
+	if meal.MealYoutubeTutorial != "" {
+		recipe.Links = append(recipe.Links, recipeexport.Link{Label: "Video", URL: meal.MealYoutubeTutorial})
+	}
+	if meal.MealImageSource != "" {
+		recipe.Links = append(recipe.Links, recipeexport.Link{Label: "Image", URL: meal.MealImageSource})
+	}
+	if _, ok := meals_models.ParseUserRecipeID(meal.MealID); ok {
+		recipe.Attribution = "A recipe shared by a Nomie cook."
+	} else {
+		recipe.Attribution = "Recipe from TheMealDB (https://www.themealdb.com), exported from Nomie."
+	}
+	return recipe, nil
+}
+
+// formatMinutes writes a duration as "45 min" or "1 hr 30 min"; zero is
+// left blank
+func formatMinutes(minutes int) string {
+	switch {
+	case minutes <= 0:
+		return ""
+	case minutes < 60:
+		return fmt.Sprintf("%d min", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d hr", minutes/60)
+	}
+	return fmt.Sprintf("%d hr %d min", minutes/60, minutes%60)
+}
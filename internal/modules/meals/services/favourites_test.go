@@ -0,0 +1,81 @@
+package meals_services
+
+import (
+	"strconv"
+	"testing"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	meals_repository "github.com/JonathanTriC/nomie-api/internal/modules/meals/repository"
+)
+
+// countingRepo answers favourite lookups from memory and counts the queries
+// the real repository would have run
+type countingRepo struct {
+	meals_repository.Repository
+	favourites map[string]bool
+	// statusQueries holds the meal IDs of each bulk lookup
+	statusQueries  [][]string
+	perMealQueries int
+}
+
+func (r *countingRepo) IsFavourite(userID, mealID string) (bool, error) {
+	r.perMealQueries++
+	return r.favourites[mealID], nil
+}
+
+func (r *countingRepo) GetFavouriteStatuses(userID string, mealIDs []string) (map[string]bool, error) {
+	r.statusQueries = append(r.statusQueries, append([]string(nil), mealIDs...))
+	statuses := map[string]bool{}
+	for _, id := range mealIDs {
+		if r.favourites[id] {
+			statuses[id] = true
+		}
+	}
+	return statuses, nil
+}
+
+// favouriteFixture returns n meals, every third of them a favourite
+func favouriteFixture(n int) (*countingRepo, []*meals_models.Meal) {
+	repo := &countingRepo{favourites: map[string]bool{}}
+	meals := make([]*meals_models.Meal, n)
+	for i := range meals {
+		id := strconv.Itoa(52770 + i)
+		meals[i] = &meals_models.Meal{MealID: id}
+		if i%3 == 0 {
+			repo.favourites[id] = true
+		}
+	}
+	return repo, meals
+}
+
+func TestMarkFavouritesUsesOneQueryPerPage(t *testing.T) {
+	const pageSize = 10
+	repo, meals := favouriteFixture(25)
+	s := &service{repo: repo}
+
+	pages := 0
+	for start := 0; start < len(meals); start += pageSize {
+		end := min(start+pageSize, len(meals))
+		page := meals[start:end]
+		pages++
+
+		if err := s.markFavourites("1", page); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.statusQueries) != pages {
+			t.Fatalf("page %d: GetFavouriteStatuses calls = %d, want %d", pages, len(repo.statusQueries), pages)
+		}
+		if got := repo.statusQueries[pages-1]; len(got) != len(page) {
+			t.Errorf("page %d: looked up %d meals, want %d", pages, len(got), len(page))
+		}
+	}
+
+	if repo.perMealQueries != 0 {
+		t.Errorf("IsFavourite calls = %d, want 0", repo.perMealQueries)
+	}
+	for i, meal := range meals {
+		if want := i%3 == 0; meal.IsFavourite != want {
+			t.Errorf("meal %s IsFavourite = %v, want %v", meal.MealID, meal.IsFavourite, want)
+		}
+	}
+}
@@ -3,70 +3,142 @@ package meals_services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
-	"mime/multipart"
 	"net/http"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
 	meals_repository "github.com/JonathanTriC/nomie-api/internal/modules/meals/repository"
 	misc_services "github.com/JonathanTriC/nomie-api/internal/modules/misc/services"
+	recipes_repository "github.com/JonathanTriC/nomie-api/internal/modules/recipes/repository"
 	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/logger"
+	"github.com/JonathanTriC/nomie-api/pkg/recipeexport"
 	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+const (
+	// detailReviewLimit is how many of the newest reviews are embedded in the detail page
+	detailReviewLimit = 3
+	// searchRecipeLimit caps how many user recipes are added to search results
+	searchRecipeLimit = 50
+)
+
+var (
+	ErrMealNotFound    = errors.New("meal not found")
+	ErrUnknownCategory = errors.New("unknown category")
+	ErrUnknownArea     = errors.New("unknown area")
+	ErrReviewNotFound  = errors.New("review not found")
+	ErrNotReviewAuthor = errors.New("only the author can modify this review")
+	ErrOwnReview       = errors.New("you cannot vote on or report your own review")
 )
 
 type Service interface {
 	GetTodayRecommendation(userID string) (*meals_models.Meal, error)
-	GetPopularPicks(userID string, limit, page int) (map[string]interface{}, error)
-	GetCuisinePicks(userID string, limit, page int) (map[string]interface{}, error)
+	GetPopularPicks(userID, category string, limit, page int) (map[string]interface{}, error)
+	GetCuisinePicks(userID, area string, limit, page int) (map[string]interface{}, error)
 	GetSearchMeals(userID, query string, limit, page int) (map[string]interface{}, error)	
-	GetMealDetail(userID, mealID string) (*meals_models.Meal, error)
-	GetFavourites(userID string) ([]meals_models.Favourite, error)
-	SetFavourite(userID, mealID, mealName, mealThumbImage string) error
+	GetMealDetail(userID, mealID string, opts meals_models.DetailOptions) (*meals_models.Meal, error)
+	LookupMeal(userID, mealID string) (*meals_models.Meal, error)
+	SuggestMeals(userID, key string, slots []string, avoid []string) ([]meals_models.DailyRecommendation, error)
+	GetPreferences(userID string) (*meals_models.UserPreferences, error)
+	ScaleMeal(meal *meals_models.Meal, servings int) error
+	SetMealServings(moderatorID, mealID string, servings int) error
+	GetFavourites(userID, sort string, limit, page int) (map[string]interface{}, error)
+	SetFavourite(userID, mealID string) error
 	UnsetFavourite(userID, mealID string) error
-    CreateReview(userID, mealID string, rating int, reviewText string, reviewImageFile multipart.File, header *multipart.FileHeader) (*meals_models.MealReview, error)
+	GetCollections(userID string) ([]meals_models.FavouriteCollection, error)
+	CreateCollection(userID, name string) (*meals_models.FavouriteCollection, error)
+	GetCollection(userID string, collectionID, limit, page int) (map[string]interface{}, error)
+	RenameCollection(userID string, collectionID int, name string) (*meals_models.FavouriteCollection, error)
+	DeleteCollection(userID string, collectionID int) error
+	AddCollectionMeal(userID string, collectionID int, mealID string) (*meals_models.FavouriteCollection, error)
+	RemoveCollectionMeal(userID string, collectionID int, mealID string) error
+	MoveCollectionMeal(userID string, fromID, toID int, mealID string) error
+	ReorderCollection(userID string, collectionID int, mealIDs []string) error
+	ViewCollection(ownerID string, collectionID, limit, page int) (map[string]interface{}, error)
+    CreateReview(userID, mealID string, rating int, reviewText string, images []ReviewImageUpload) (*meals_models.MealReview, bool, error)
+	UpdateReview(userID string, reviewID, rating int, reviewText string, images []ReviewImageUpload, removeImageIDs []int, removeAllImages bool) (*meals_models.MealReview, error)
+	ArrangeReviewImages(userID string, reviewID int, images []meals_models.ReviewImageUpdate) (*meals_models.MealReview, error)
+	GetMealPhotos(mealID string, limit, page int) (map[string]interface{}, error)
+	DeleteReview(userID string, reviewID int) (*meals_models.MealReview, error)
+	GetMealReviews(userID, mealID string, q meals_models.ReviewQuery) (map[string]interface{}, error)
+	ToggleHelpfulVote(userID string, reviewID int) (map[string]interface{}, error)
+	ReportReview(userID string, reviewID int, reason, details string) error
+	GetPantryMatches(userID string, limit, page int) (map[string]interface{}, error)
+	GetTrendingMeals(userID string, limit, page int) (map[string]interface{}, error)
+	GetTopRatedMeals(userID string, limit, page int) (map[string]interface{}, error)
+	GetSimilarMeals(userID, mealID string, limit, page int) (map[string]interface{}, error)
+	GetMealSteps(userID, mealID string) (*meals_models.MealSteps, error)
+	ExportMeal(userID, mealID string, opts meals_models.DetailOptions) (*recipeexport.Recipe, error)
+	GetModerationQueue(status string, limit, page int) (map[string]interface{}, error)
+	ApproveReview(moderatorID string, reviewID int) error
+	RejectReview(moderatorID string, reviewID int, reason string) error
+	GetReviewReplies(userID string, reviewID, limit, page int) (map[string]interface{}, error)
+	CreateReply(userID string, reviewID int, replyText string) (*meals_models.ReviewReply, error)
+	UpdateReply(userID string, replyID int, replyText string) (*meals_models.ReviewReply, error)
+	DeleteReply(userID string, replyID int) error
+	GetReplyModerationQueue(status string, limit, page int) (map[string]interface{}, error)
+	ApproveReply(moderatorID string, replyID int) error
+	RejectReply(moderatorID string, replyID int, reason string) error
 }
 
 type service struct {
 	repo meals_repository.Repository
     Cld  *cloudinary.Cloudinary
+	// recipes resolves the namespaced meal IDs of user-authored recipes
+	recipes recipes_repository.Repository
+
+	// reportThreshold is how many reports hide a review pending moderation
+	reportThreshold int
+	// contentFilter holds reviews with blocked words or patterns for moderation
+	contentFilter *contentFilter
+	// holdImages sends every review with a new photo to the moderation queue
+	holdImages bool
+	// maxImages is how many images one review can have
+	maxImages int
+
+	similarMu    sync.Mutex
+	similarCache map[string]similarCacheEntry
 }
 
-func NewService(repo meals_repository.Repository) Service {
+func NewService(repo meals_repository.Repository, recipes recipes_repository.Repository) Service {
     var apiURL = utils.GetEnv("CDN_API_URL", "your_cdn_api_url")
     cld, err := cloudinary.NewFromURL(apiURL)
     if err != nil {
         log.Fatalf("failed to init cloudinary: %v", err)
     }
 
+    reportThreshold, err := strconv.Atoi(utils.GetEnv("REVIEW_REPORT_THRESHOLD", "3"))
+    if err != nil || reportThreshold <= 0 {
+        reportThreshold = 3
+    }
+
+    maxImages, err := strconv.Atoi(utils.GetEnv("REVIEW_MAX_IMAGES", "5"))
+    if err != nil || maxImages <= 0 {
+        maxImages = 5
+    }
+
     return &service{
         repo:  repo,
         Cld: cld,
+        recipes: recipes,
+        reportThreshold: reportThreshold,
+        contentFilter: loadContentFilter(),
+        holdImages: utils.GetEnv("MODERATION_HOLD_IMAGES", "false") == "true",
+        maxImages: maxImages,
+        similarCache: map[string]similarCacheEntry{},
     }
 }
 
-func (s *service) GetTodayRecommendation(userID string) (*meals_models.Meal, error) {
-	resp, err := http.Get("https://www.themealdb.com/api/json/v1/1/random.php")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var apiResp meals_models.MealAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, err
-	}
-	if len(apiResp.Meals) == 0 {
-		return nil, fmt.Errorf("no meals found")
-	}
-
-	return s.buildMealFromAPIData(apiResp.Meals[0], userID)
-}
-
-func (s *service) GetPopularPicks(userID string, limit, page int) (map[string]interface{}, error) {
+func (s *service) GetPopularPicks(userID, category string, limit, page int) (map[string]interface{}, error) {
     categories, err := misc_services.NewService().GetCategories()
     if err != nil {
         return nil, err
@@ -75,63 +147,30 @@ func (s *service) GetPopularPicks(userID string, limit, page int) (map[string]in
         return nil, fmt.Errorf("no categories found")
     }
 
-    dayIndex := time.Now().YearDay() % len(categories)
-    category := categories[dayIndex]
-
-    url := fmt.Sprintf("https://www.themealdb.com/api/json/v1/1/filter.php?c=%s", category)
-    resp, err := http.Get(url)
+    prefs, err := s.repo.GetPreferences(userID)
     if err != nil {
         return nil, err
     }
-    defer resp.Body.Close()
-
-    var mealsResp meals_models.MealAPIResponse
-    if err := json.NewDecoder(resp.Body).Decode(&mealsResp); err != nil {
-        return nil, err
-    }
 
-    totalItems := len(mealsResp.Meals)
-    totalPages := int(math.Ceil(float64(totalItems) / float64(limit)))
-
-    // Pagination math
-    start := (page - 1) * limit
-    end := start + limit
-    if start > totalItems {
-        return map[string]interface{}{
-            "categoryName": category,
-            "meals":        []map[string]interface{}{},
-            "page":         page,
-            "totalItems":   totalItems,
-            "totalPages":   totalPages,
-        }, nil
-    }
-    if end > totalItems {
-        end = totalItems
+    // Rotate daily, per user
+    period := time.Now().Format("2006-01-02")
+    category, reason, err := selectSection(userID, period, category, categories, prefs.FavouriteCategories)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %s", ErrUnknownCategory, category)
     }
 
-    var meals []map[string]interface{}
-    for _, m := range mealsResp.Meals[start:end] {
-        idMeal, _ := m["idMeal"].(string)
-        isFav, _ := s.repo.IsFavourite(userID, idMeal)
-
-        meals = append(meals, map[string]interface{}{
-            "mealName":       m["strMeal"],
-            "mealThumbImage": m["strMealThumb"],
-            "mealId":         idMeal,
-            "isFavourite":    isFav,
-        })
+    url := fmt.Sprintf("https://www.themealdb.com/api/json/v1/1/filter.php?c=%s", neturl.QueryEscape(category))
+    picks, err := s.buildPicksPage(userID, url, limit, page)
+    if err != nil {
+        return nil, err
     }
+    picks["categoryName"] = category
+    picks["selectionReason"] = reason
 
-    return map[string]interface{}{
-        "categoryName": category,
-        "meals":        meals,
-        "page":         page,
-        "totalItems":   totalItems,
-        "totalPages":   totalPages,
-    }, nil
+    return picks, nil
 }
 
-func (s *service) GetCuisinePicks(userID string, limit, page int) (map[string]interface{}, error) {
+func (s *service) GetCuisinePicks(userID, area string, limit, page int) (map[string]interface{}, error) {
     areas, err := misc_services.NewService().GetAreas()
     if err != nil {
         return nil, err
@@ -140,62 +179,85 @@ func (s *service) GetCuisinePicks(userID string, limit, page int) (map[string]in
         return nil, fmt.Errorf("no areas found")
     }
 
-    weekIndex := func() int {
-				_, week := time.Now().ISOWeek() 
-				return week % len(areas)        
-		}()
-		area := areas[weekIndex]
+    prefs, err := s.repo.GetPreferences(userID)
+    if err != nil {
+        return nil, err
+    }
 
-    url := fmt.Sprintf("https://www.themealdb.com/api/json/v1/1/filter.php?a=%s", area)
-    resp, err := http.Get(url)
+    // Rotate weekly, per user
+    year, week := time.Now().ISOWeek()
+    period := fmt.Sprintf("%d-W%02d", year, week)
+    area, reason, err := selectSection(userID, period, area, areas, prefs.FavouriteAreas)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %s", ErrUnknownArea, area)
+    }
+
+    url := fmt.Sprintf("https://www.themealdb.com/api/json/v1/1/filter.php?a=%s", neturl.QueryEscape(area))
+    picks, err := s.buildPicksPage(userID, url, limit, page)
     if err != nil {
         return nil, err
     }
-    defer resp.Body.Close()
+    picks["areaName"] = area
+    picks["selectionReason"] = reason
 
-    var mealsResp meals_models.MealAPIResponse
-    if err := json.NewDecoder(resp.Body).Decode(&mealsResp); err != nil {
+    return picks, nil
+}
+
+// buildPicksPage fetches a filtered meal list and returns one page of it.
+// Meals are sorted by name so pages stay stable between requests.
+func (s *service) buildPicksPage(userID, url string, limit, page int) (map[string]interface{}, error) {
+    mealsResp, err := fetchMealsAPI(url)
+    if err != nil {
         return nil, err
     }
 
-    totalItems := len(mealsResp.Meals)
+    sort.SliceStable(mealsResp, func(i, j int) bool {
+        ni, nj := valOrEmpty(mealsResp[i]["strMeal"]), valOrEmpty(mealsResp[j]["strMeal"])
+        if ni != nj {
+            return ni < nj
+        }
+        return valOrEmpty(mealsResp[i]["idMeal"]) < valOrEmpty(mealsResp[j]["idMeal"])
+    })
+
+    totalItems := len(mealsResp)
     totalPages := int(math.Ceil(float64(totalItems) / float64(limit)))
 
     // Pagination math
     start := (page - 1) * limit
     end := start + limit
     if start > totalItems {
-        return map[string]interface{}{
-            "areasName": area,
-            "meals":        []map[string]interface{}{},
-            "page":         page,
-            "totalItems":   totalItems,
-            "totalPages":   totalPages,
-        }, nil
+        start = totalItems
     }
     if end > totalItems {
         end = totalItems
     }
 
-    var meals []map[string]interface{}
-    for _, m := range mealsResp.Meals[start:end] {
-        idMeal, _ := m["idMeal"].(string)
-        isFav, _ := s.repo.IsFavourite(userID, idMeal)
+    pageMeals := mealsResp[start:end]
+    ids := make([]string, 0, len(pageMeals))
+    for _, m := range pageMeals {
+        ids = append(ids, valOrEmpty(m["idMeal"]))
+    }
+    favourites, err := s.repo.GetFavouriteStatuses(userID, ids)
+    if err != nil {
+        return nil, err
+    }
 
+    meals := []map[string]interface{}{}
+    for _, m := range pageMeals {
+        idMeal := valOrEmpty(m["idMeal"])
         meals = append(meals, map[string]interface{}{
             "mealName":       m["strMeal"],
             "mealThumbImage": m["strMealThumb"],
             "mealId":         idMeal,
-            "isFavourite":    isFav,
+            "isFavourite":    favourites[idMeal],
         })
     }
 
     return map[string]interface{}{
-        "areaName": area,
-        "meals":        meals,
-        "page":         page,
-        "totalItems":   totalItems,
-        "totalPages":   totalPages,
+        "meals":      meals,
+        "page":       page,
+        "totalItems": totalItems,
+        "totalPages": totalPages,
     }, nil
 }
 
@@ -211,11 +273,24 @@ func (s *service) GetSearchMeals(userID, query string, limit, page int) (map[str
     if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
         return nil, err
     }
-    if len(apiResp.Meals) == 0 {
+
+    // The viewer's own and public user recipes come before upstream meals
+    recipes, err := s.recipes.SearchRecipes(userID, query, searchRecipeLimit)
+    if err != nil {
+        return nil, err
+    }
+    found := make([]*meals_models.Meal, 0, len(recipes)+len(apiResp.Meals))
+    for i := range recipes {
+        found = append(found, &recipes[i].Meal)
+    }
+    for _, meal := range apiResp.Meals {
+        found = append(found, mealFromAPIData(meal))
+    }
+    if len(found) == 0 {
         return nil, fmt.Errorf("no meals found")
     }
 
-    totalItems := len(apiResp.Meals)
+    totalItems := len(found)
     totalPages := int(math.Ceil(float64(totalItems) / float64(limit)))
 
     start := (page - 1) * limit
@@ -232,13 +307,13 @@ func (s *service) GetSearchMeals(userID, query string, limit, page int) (map[str
         end = totalItems
     }
 
-    var meals []map[string]interface{}
-    for _, meal := range apiResp.Meals[start:end] {
-        builtMeal, err := s.buildMealFromAPIData(meal, userID)
-        if err != nil {
-            return nil, err
-        }
+    pageMeals := found[start:end]
+    if err := s.markFavourites(userID, pageMeals); err != nil {
+        return nil, err
+    }
 
+    var meals []map[string]interface{}
+    for _, builtMeal := range pageMeals {
         meals = append(meals, map[string]interface{}{
             "mealId":         builtMeal.MealID,
             "mealName":       builtMeal.MealName,
@@ -256,34 +331,54 @@ func (s *service) GetSearchMeals(userID, query string, limit, page int) (map[str
 }
 
 
-func (s *service) GetMealDetail(userID, mealID string) (*meals_models.Meal, error) {
-	url := fmt.Sprintf("https://www.themealdb.com/api/json/v1/1/lookup.php?i=%s", mealID)
-	resp, err := http.Get(url)
+// LookupMeal returns a meal from the meal source without the reviews and
+// other sections of the detail page
+func (s *service) LookupMeal(userID, mealID string) (*meals_models.Meal, error) {
+	meal, err := s.findMeal(userID, mealID)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	var apiResp meals_models.MealAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+	if err := s.markFavourites(userID, []*meals_models.Meal{meal}); err != nil {
 		return nil, err
 	}
-	if len(apiResp.Meals) == 0 {
-		return nil, fmt.Errorf("no meals found for id %s", mealID)
-	}
+	return meal, nil
+}
 
-	meal, err := s.buildMealFromAPIData(apiResp.Meals[0], userID)
+func (s *service) GetMealDetail(userID, mealID string, opts meals_models.DetailOptions) (*meals_models.Meal, error) {
+	meal, err := s.LookupMeal(userID, mealID)
 	if err != nil {
 		return nil, err
 	}
 
-	// get reviews from repo
-	reviews, err := s.repo.GetReviewsByMealID(mealID, true)
+	system, err := s.measurementSystem(userID, opts.MeasurementSystem)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.scaleMeal(meal, opts.Servings, system); err != nil {
+		return nil, err
+	}
+	meal.Nutrition = estimateNutrition(meal)
+
+	// get the latest reviews from repo
+	reviews, _, err := s.repo.GetReviewsByMealID(mealID, meals_models.ReviewQuery{
+		ViewerID: userID,
+		Sort:     meals_models.ReviewSortNewest,
+		Limit:    detailReviewLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decorateReviews(reviews, userID); err != nil {
+		return nil, err
+	}
 	meal.Reviews = reviews
 
+	histogram, err := s.repo.GetRatingHistogram(mealID)
+	if err != nil {
+		return nil, err
+	}
+	meal.RatingHistogram = histogram
+
     avgRating, totalReviews, err := s.repo.GetMealRating(mealID)
     if err != nil {
         return nil, err
@@ -291,53 +386,391 @@ func (s *service) GetMealDetail(userID, mealID string) (*meals_models.Meal, erro
     meal.AvgRating = avgRating
     meal.TotalReviews = totalReviews
 
+	// The similar section is a nice-to-have; never fail or hold up the detail
+	// page for it
+	meal.Similar = s.detailSimilar(meal, userID)
+
 	return meal, nil
 }
 
 
-func (s *service) GetFavourites(userID string) ([]meals_models.Favourite, error) {
-	return s.repo.GetFavourites(userID)
+func (s *service) GetFavourites(userID, sort string, limit, page int) (map[string]interface{}, error) {
+	favourites, totalItems, err := s.repo.GetFavouritesPage(userID, sort, limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"favourites": favourites,
+		"page":       page,
+		"totalPages": int(math.Ceil(float64(totalItems) / float64(limit))),
+		"totalItems": totalItems,
+	}, nil
 }
 
-func (s *service) SetFavourite(userID, mealID, mealName, mealThumbImage string) error {
-	return s.repo.AddFavourite(userID, mealID, mealName, mealThumbImage)
+// SetFavourite favourites a meal, storing the name and thumbnail from the meal
+// source so clients cannot save arbitrary values
+func (s *service) SetFavourite(userID, mealID string) error {
+	meal, err := s.findMeal(userID, mealID)
+	if err != nil {
+		return err
+	}
+	return s.repo.AddFavourite(userID, mealID, meal.MealName, meal.MealThumbImage)
 }
 
 func (s *service) UnsetFavourite(userID, mealID string) error {
 	return s.repo.RemoveFavourite(userID, mealID)
 }
 
-func (s *service) CreateReview(userID, mealID string, rating int, reviewText string, reviewImageFile multipart.File, header *multipart.FileHeader) (*meals_models.MealReview, error) {
-	var imageURL string
+func (s *service) CreateReview(userID, mealID string, rating int, reviewText string, images []ReviewImageUpload) (*meals_models.MealReview, bool, error) {
+	if err := s.checkRecipeAccess(userID, mealID); err != nil {
+		return nil, false, err
+	}
 
-	// upload to CDN if file provided
-	if reviewImageFile != nil {
-		ctx := context.Background()
-		uploadResult, err := s.Cld.Upload.Upload(ctx, reviewImageFile, uploader.UploadParams{
-			Folder:   "reviews",
-			PublicID: fmt.Sprintf("review_%s_%s", mealID, userID),
-		})
+	existing, err := s.repo.GetUserReviewForMeal(userID, mealID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	existingImages := 0
+	previousStatus := ""
+	if existing != nil {
+		current, err := s.repo.GetReviewImages([]int{existing.ID})
 		if err != nil {
-			return nil, fmt.Errorf("failed to upload image: %w", err)
+			return nil, false, err
 		}
-		imageURL = uploadResult.SecureURL
+		existingImages = len(current[existing.ID])
+		previousStatus = existing.Status
+	}
+	if err := s.checkReviewImages(images, existingImages); err != nil {
+		return nil, false, err
+	}
+
+	// upload to CDN if files provided
+	uploaded, err := s.uploadReviewImages(mealID, userID, images)
+	if err != nil {
+		return nil, false, err
 	}
 
 	review := &meals_models.MealReview{
-		UserID:      userID,
-		MealID:      mealID,
-		Rating:      rating,
-		ReviewText:  reviewText,
-		ReviewImage: imageURL,
+		UserID:     userID,
+		MealID:     mealID,
+		Rating:     rating,
+		ReviewText: reviewText,
 	}
+	s.moderateReview(review, len(images) > 0, previousStatus)
 
-	if err := s.repo.CreateReview(review); err != nil {
+	created, err := s.repo.UpsertReview(review)
+	if err != nil {
+		s.destroyReviewImages(uploaded)
+		return nil, false, err
+	}
+	if len(uploaded) > 0 {
+		if _, err := s.repo.AddReviewImages(review.ID, uploaded, s.maxImages); err != nil {
+			s.destroyReviewImages(uploaded)
+			return nil, false, err
+		}
+	}
+
+	if err := s.loadReviewImages(review); err != nil {
+		return nil, false, err
+	}
+	return review, created, nil
+}
+
+// UpdateReview edits the author's review. Images listed in removeImageIDs, or
+// all of them with removeAllImages, are deleted before new ones are appended.
+func (s *service) UpdateReview(userID string, reviewID, rating int, reviewText string, images []ReviewImageUpload, removeImageIDs []int, removeAllImages bool) (*meals_models.MealReview, error) {
+	review, err := s.authorReview(userID, reviewID)
+	if err != nil {
 		return nil, err
 	}
 
+	current, err := s.repo.GetReviewImages([]int{reviewID})
+	if err != nil {
+		return nil, err
+	}
+	remaining := 0
+	if !removeAllImages {
+		removing := map[int]bool{}
+		for _, id := range removeImageIDs {
+			removing[id] = true
+		}
+		for _, img := range current[reviewID] {
+			if !removing[img.ID] {
+				remaining++
+			}
+		}
+	}
+	if err := s.checkReviewImages(images, remaining); err != nil {
+		return nil, err
+	}
+
+	uploaded, err := s.uploadReviewImages(review.MealID, userID, images)
+	if err != nil {
+		return nil, err
+	}
+
+	previousStatus := review.Status
+	review.Rating = rating
+	review.ReviewText = reviewText
+	s.moderateReview(review, len(images) > 0, previousStatus)
+
+	if err := s.repo.UpdateReview(review); err != nil {
+		s.destroyReviewImages(uploaded)
+		return nil, err
+	}
+
+	var removed []meals_models.ReviewImage
+	switch {
+	case removeAllImages:
+		removed, err = s.repo.DeleteReviewImages(reviewID, nil)
+	case len(removeImageIDs) > 0:
+		removed, err = s.repo.DeleteReviewImages(reviewID, removeImageIDs)
+	}
+	if err != nil {
+		s.destroyReviewImages(uploaded)
+		return nil, err
+	}
+	s.destroyReviewImages(removed)
+
+	if len(uploaded) > 0 {
+		if _, err := s.repo.AddReviewImages(reviewID, uploaded, s.maxImages); err != nil {
+			s.destroyReviewImages(uploaded)
+			return nil, err
+		}
+	}
+
+	if err := s.loadReviewImages(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *service) DeleteReview(userID string, reviewID int) (*meals_models.MealReview, error) {
+	review, err := s.authorReview(userID, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := s.repo.GetReviewImages([]int{reviewID})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.DeleteReview(reviewID, userID); err != nil {
+		return nil, err
+	}
+
+	// Images are removed from the database with the review; their stored
+	// files have to be removed from the CDN separately
+	s.destroyReviewImages(images[reviewID])
+
 	return review, nil
 }
 
+func (s *service) GetMealReviews(userID, mealID string, q meals_models.ReviewQuery) (map[string]interface{}, error) {
+	if err := s.checkRecipeAccess(userID, mealID); err != nil {
+		return nil, err
+	}
+
+	q.ViewerID = userID
+	reviews, nextCursor, err := s.repo.GetReviewsByMealID(mealID, q)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decorateReviews(reviews, userID); err != nil {
+		return nil, err
+	}
+
+	avgRating, totalReviews, err := s.repo.GetMealRating(mealID)
+	if err != nil {
+		return nil, err
+	}
+
+	histogram, err := s.repo.GetRatingHistogram(mealID)
+	if err != nil {
+		return nil, err
+	}
+
+	viewerReview, err := s.repo.GetUserReviewForMeal(userID, mealID)
+	if err != nil {
+		return nil, err
+	}
+	if viewerReview != nil {
+		viewerReview.IsOwnReview = true
+		if err := s.loadReviewImages(viewerReview); err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{
+		"reviews":         reviews,
+		"nextCursor":      nextCursor,
+		"hasMore":         nextCursor != "",
+		"avgRating":       avgRating,
+		"totalReviews":    totalReviews,
+		"ratingHistogram": histogram,
+		"viewerReview":    viewerReview,
+	}, nil
+}
+
+// decorateReviews fills in the viewer-specific fields of a review listing
+func (s *service) decorateReviews(reviews []meals_models.MealReview, userID string) error {
+	ids := make([]int, 0, len(reviews))
+	for _, r := range reviews {
+		ids = append(ids, r.ID)
+	}
+
+	votes, err := s.repo.GetViewerVotes(userID, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range reviews {
+		reviews[i].IsOwnReview = reviews[i].UserID == userID
+		reviews[i].ViewerHasVoted = votes[reviews[i].ID]
+	}
+	return s.attachImages(reviews)
+}
+
+func (s *service) ToggleHelpfulVote(userID string, reviewID int) (map[string]interface{}, error) {
+	review, err := s.repo.GetReviewByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, ErrReviewNotFound
+	}
+	if review.UserID == userID {
+		return nil, ErrOwnReview
+	}
+
+	voted, helpfulCount, err := s.repo.ToggleHelpfulVote(reviewID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"reviewId":       reviewID,
+		"helpfulCount":   helpfulCount,
+		"viewerHasVoted": voted,
+	}, nil
+}
+
+func (s *service) ReportReview(userID string, reviewID int, reason, details string) error {
+	review, err := s.repo.GetReviewByID(reviewID)
+	if err != nil {
+		return err
+	}
+	if review == nil {
+		return ErrReviewNotFound
+	}
+	if review.UserID == userID {
+		return ErrOwnReview
+	}
+
+	hidden, err := s.repo.ReportReview(reviewID, userID, reason, details, s.reportThreshold)
+	if err != nil {
+		return err
+	}
+	if hidden {
+		logger.InfoLogger.Printf("review %d hidden pending moderation after reports", reviewID)
+	}
+	return nil
+}
+
+// authorReview loads a review and checks that userID wrote it
+func (s *service) authorReview(userID string, reviewID int) (*meals_models.MealReview, error) {
+	review, err := s.repo.GetReviewByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, ErrReviewNotFound
+	}
+	if review.UserID != userID {
+		return nil, ErrNotReviewAuthor
+	}
+	return review, nil
+}
+
+// fetchMealsAPI calls a TheMealDB endpoint and returns the raw meal entries
+func fetchMealsAPI(url string) ([]map[string]interface{}, error) {
+	return fetchMealsAPIContext(context.Background(), url)
+}
+
+// fetchMealsAPIContext is fetchMealsAPI, given up when ctx is done
+func fetchMealsAPIContext(ctx context.Context, url string) ([]map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var apiResp meals_models.MealAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+	return apiResp.Meals, nil
+}
+
+// lookupMealData fetches the full upstream record for a single meal
+func lookupMealData(mealID string) (map[string]interface{}, error) {
+	return lookupMealDataContext(context.Background(), mealID)
+}
+
+func lookupMealDataContext(ctx context.Context, mealID string) (map[string]interface{}, error) {
+	meals, err := fetchMealsAPIContext(ctx, fmt.Sprintf("https://www.themealdb.com/api/json/v1/1/lookup.php?i=%s", neturl.QueryEscape(mealID)))
+	if err != nil {
+		return nil, err
+	}
+	if len(meals) == 0 {
+		return nil, ErrMealNotFound
+	}
+	return meals[0], nil
+}
+
+// findMeal returns a meal from the meal source, or a user's recipe when the
+// ID is namespaced as one. Recipes only resolve for their author or when
+// public. The favourite flag is left for the caller to fill in.
+func (s *service) findMeal(userID, mealID string) (*meals_models.Meal, error) {
+	return s.findMealContext(context.Background(), userID, mealID)
+}
+
+func (s *service) findMealContext(ctx context.Context, userID, mealID string) (*meals_models.Meal, error) {
+	if recipeID, ok := meals_models.ParseUserRecipeID(mealID); ok {
+		recipe, err := s.recipes.GetRecipe(recipeID)
+		if err != nil {
+			return nil, err
+		}
+		if recipe == nil || !recipe.VisibleTo(userID) {
+			return nil, ErrMealNotFound
+		}
+		return &recipe.Meal, nil
+	}
+
+	data, err := lookupMealDataContext(ctx, mealID)
+	if err != nil {
+		return nil, err
+	}
+	return mealFromAPIData(data), nil
+}
+
+// checkRecipeAccess rejects user recipe IDs the viewer cannot see. Upstream
+// IDs are accepted unchecked.
+func (s *service) checkRecipeAccess(userID, mealID string) error {
+	if _, ok := meals_models.ParseUserRecipeID(mealID); !ok {
+		return nil
+	}
+	_, err := s.findMeal(userID, mealID)
+	return err
+}
+
 func valOrEmpty(v interface{}) string {
 	if v == nil {
 		return ""
@@ -345,7 +778,26 @@ func valOrEmpty(v interface{}) string {
 	return fmt.Sprintf("%v", v)
 }
 
-func (s *service) buildMealFromAPIData(m map[string]interface{}, userID string) (*meals_models.Meal, error) {
+// markFavourites sets IsFavourite on a list of meals with one query
+func (s *service) markFavourites(userID string, meals []*meals_models.Meal) error {
+	ids := make([]string, 0, len(meals))
+	for _, meal := range meals {
+		ids = append(ids, meal.MealID)
+	}
+
+	favourites, err := s.repo.GetFavouriteStatuses(userID, ids)
+	if err != nil {
+		return err
+	}
+	for _, meal := range meals {
+		meal.IsFavourite = favourites[meal.MealID]
+	}
+	return nil
+}
+
+// mealFromAPIData maps an upstream meal record; the favourite flag is left
+// for the caller to fill in
+func mealFromAPIData(m map[string]interface{}) *meals_models.Meal {
 	meal := &meals_models.Meal{
 		MealID:                       valOrEmpty(m["idMeal"]),
 		MealName:                     valOrEmpty(m["strMeal"]),
@@ -376,12 +828,5 @@ func (s *service) buildMealFromAPIData(m map[string]interface{}, userID string)
 		}
 	}
 
-	// Check favourite
-	isFav, err := s.repo.IsFavourite(userID, meal.MealID)
-	if err != nil {
-		return nil, err
-	}
-	meal.IsFavourite = isFav
-
-	return meal, nil
+	return meal
 }
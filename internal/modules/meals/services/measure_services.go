@@ -0,0 +1,141 @@
+package meals_services
+
+import (
+	"regexp"
+	"strconv"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	"github.com/JonathanTriC/nomie-api/pkg/measure"
+	"github.com/JonathanTriC/nomie-api/pkg/nutrition"
+)
+
+const (
+	// defaultServings is assumed when neither a stored count nor the recipe
+	// says how many a meal serves
+	defaultServings = 4
+	// dessertServings is assumed for desserts, which are usually cut into
+	// more portions
+	dessertServings = 8
+)
+
+// servesPatterns find a serving count stated in the instructions, like
+// "Serves 4" or "makes 6 portions"
+var servesPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:serves|feeds)\s+(\d{1,2})\b`),
+	regexp.MustCompile(`(?i)\bservings?\s*:?\s*(\d{1,2})\b`),
+	regexp.MustCompile(`(?i)\b(?:makes|for)\s+(\d{1,2})\s+(?:people|persons|portions|servings)\b`),
+}
+
+func (s *service) SetMealServings(moderatorID, mealID string, servings int) error {
+	if _, err := s.findMeal(moderatorID, mealID); err != nil {
+		return err
+	}
+	return s.repo.SetMealServings(mealID, servings, moderatorID)
+}
+
+// ScaleMeal sets how many the meal serves and scales its ingredient measures
+// to servings. Zero keeps the recipe's own amounts. Measures that cannot be
+// parsed are flagged as unscaled instead of being passed off as scaled.
+func (s *service) ScaleMeal(meal *meals_models.Meal, servings int) error {
+	return s.scaleMeal(meal, servings, "")
+}
+
+// scaleMeal scales the meal like ScaleMeal and converts its measures to
+// system in the same step, so each is rounded once. An empty system keeps
+// the recipe's units.
+func (s *service) scaleMeal(meal *meals_models.Meal, servings int, system measure.System) error {
+	base, source, err := s.baseServings(meal)
+	if err != nil {
+		return err
+	}
+	if servings <= 0 {
+		servings = base
+	}
+	meal.BaseServings = base
+	meal.Servings = servings
+	meal.ServingsSource = source
+
+	factor := float64(servings) / float64(base)
+	for i := range meal.MealIngredient {
+		ingredient := &meal.MealIngredient[i]
+		if ingredient.IngredientMeasure == "" {
+			continue
+		}
+		if ingredient.Measure == nil {
+			m := measure.Parse(ingredient.IngredientMeasure)
+			ingredient.Measure = &m
+		}
+
+		scaled := measure.ScaleTo(*ingredient.Measure, factor, system)
+		ingredient.Measure = &scaled
+		ingredient.Unscaled = factor != 1 && !scaled.Parsed
+		ingredient.DisplayMeasure = scaled.String()
+	}
+	return nil
+}
+
+// baseServings returns how many the recipe serves: a count given by the
+// author of a user recipe, one set by an admin, one stated in the
+// instructions, or an estimate from the category
+func (s *service) baseServings(meal *meals_models.Meal) (int, string, error) {
+	if meal.BaseServings > 0 {
+		return meal.BaseServings, meals_models.ServingsSourceRecipe, nil
+	}
+
+	stored, err := s.repo.GetMealServings(meal.MealID)
+	if err != nil {
+		return 0, "", err
+	}
+	if stored > 0 {
+		return stored, meals_models.ServingsSourceStored, nil
+	}
+
+	for _, re := range servesPatterns {
+		if match := re.FindStringSubmatch(meal.MealInstructions); match != nil {
+			if n, err := strconv.Atoi(match[1]); err == nil && n > 0 {
+				return n, meals_models.ServingsSourceRecipe, nil
+			}
+		}
+	}
+
+	if meal.MealCategory == "Dessert" {
+		return dessertServings, meals_models.ServingsSourceEstimated, nil
+	}
+	return defaultServings, meals_models.ServingsSourceEstimated, nil
+}
+
+// GetPreferences returns the user's taste preferences and measurement
+// system, with defaults when none are saved
+func (s *service) GetPreferences(userID string) (*meals_models.UserPreferences, error) {
+	return s.repo.GetPreferences(userID)
+}
+
+// measurementSystem returns the requested measurement system, or the user's
+// preferred one
+func (s *service) measurementSystem(userID, requested string) (measure.System, error) {
+	if system, ok := measure.ParseSystem(requested); ok {
+		return system, nil
+	}
+	prefs, err := s.repo.GetPreferences(userID)
+	if err != nil {
+		return "", err
+	}
+	if system, ok := measure.ParseSystem(prefs.MeasurementSystem); ok {
+		return system, nil
+	}
+	return measure.Metric, nil
+}
+
+// estimateNutrition estimates the meal's nutrition from its measures as
+// scaled, so the totals are for meal.Servings
+func estimateNutrition(meal *meals_models.Meal) *nutrition.Estimate {
+	ingredients := make([]nutrition.Ingredient, 0, len(meal.MealIngredient))
+	for _, ingredient := range meal.MealIngredient {
+		m := measure.Measure{Original: ingredient.IngredientMeasure}
+		if ingredient.Measure != nil {
+			m = *ingredient.Measure
+		}
+		ingredients = append(ingredients, nutrition.Ingredient{Name: ingredient.IngredientName, Measure: m})
+	}
+	return nutrition.Default().Estimate(ingredients, meal.Servings)
+}
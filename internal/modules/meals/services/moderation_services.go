@@ -0,0 +1,217 @@
+package meals_services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/logger"
+)
+
+const (
+	// maxReviewImageSize matches the avatar upload limit
+	maxReviewImageSize = 5 * 1024 * 1024
+	minReviewImageSide = 100
+	maxReviewImageSide = 8000
+)
+
+var ErrInvalidImage = errors.New("invalid image")
+
+// defaultBlockedTerms catch the most common spam in reviews. Deployments add
+// their own list through MODERATION_WORDLIST_FILE.
+var defaultBlockedTerms = []string{
+	"re:https?://",
+	"re:www\\.",
+	"buy now",
+	"click here",
+	"free money",
+	"casino",
+	"crypto giveaway",
+}
+
+// contentFilter flags text containing blocked words or matching blocked
+// patterns. Words match whole words, case-insensitively.
+type contentFilter struct {
+	patterns []*regexp.Regexp
+}
+
+// newContentFilter builds the filter from the default terms plus the optional
+// word-list file: one term per line, "re:" prefixes a regular expression and
+// "#" starts a comment
+func newContentFilter(path string) (*contentFilter, error) {
+	terms := append([]string(nil), defaultBlockedTerms...)
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			terms = append(terms, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	f := &contentFilter{}
+	for _, term := range terms {
+		expr := `(?i)\b` + regexp.QuoteMeta(term) + `\b`
+		if strings.HasPrefix(term, "re:") {
+			expr = "(?i)" + strings.TrimPrefix(term, "re:")
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid moderation pattern %q: %w", term, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+// Check returns a reason when the text should be held for moderation
+func (f *contentFilter) Check(text string) (bool, string) {
+	for _, re := range f.patterns {
+		if re.MatchString(text) {
+			return true, "Your text contains content that needs a moderator's review"
+		}
+	}
+	return false, ""
+}
+
+// loadContentFilter reads MODERATION_WORDLIST_FILE, falling back to the
+// default terms if the file cannot be used
+func loadContentFilter() *contentFilter {
+	f, err := newContentFilter(utils.GetEnv("MODERATION_WORDLIST_FILE", ""))
+	if err != nil {
+		logger.ErrorLogger.Printf("moderation word list: %v, using defaults", err)
+		f, _ = newContentFilter("")
+	}
+	return f
+}
+
+// checkReviewImage validates an uploaded review image: size, real content
+// type and dimensions. The file is rewound so it can still be uploaded.
+func checkReviewImage(file multipart.File, header *multipart.FileHeader) error {
+	if header != nil && header.Size > maxReviewImageSize {
+		return fmt.Errorf("%w: file size must be less than 5MB", ErrInvalidImage)
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: unreadable file", ErrInvalidImage)
+	}
+	switch http.DetectContentType(head[:n]) {
+	case "image/jpeg", "image/png":
+	default:
+		return fmt.Errorf("%w: file must be a .jpg, .jpeg, or .png image", ErrInvalidImage)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return fmt.Errorf("%w: corrupt image", ErrInvalidImage)
+	}
+	if cfg.Width < minReviewImageSide || cfg.Height < minReviewImageSide {
+		return fmt.Errorf("%w: image must be at least %dx%d", ErrInvalidImage, minReviewImageSide, minReviewImageSide)
+	}
+	if cfg.Width > maxReviewImageSide || cfg.Height > maxReviewImageSide {
+		return fmt.Errorf("%w: image must be at most %dx%d", ErrInvalidImage, maxReviewImageSide, maxReviewImageSide)
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	return err
+}
+
+// CheckImage validates an uploaded image by the rules used for review images,
+// for other modules that accept meal photos
+func CheckImage(file multipart.File, header *multipart.FileHeader) error {
+	return checkReviewImage(file, header)
+}
+
+// moderationStatus decides whether new or edited text goes live. Flagged text
+// and, when configured, any new photo go to the queue. Edits to content that a
+// moderator or the community already pulled stay in the queue.
+func (s *service) moderationStatus(text string, hasNewImage bool, previousStatus string) (string, string) {
+	if flagged, reason := s.contentFilter.Check(text); flagged {
+		return meals_models.ReviewStatusPending, reason
+	}
+	if hasNewImage && s.holdImages {
+		return meals_models.ReviewStatusPending, "Your photo is awaiting a moderator's review"
+	}
+	if previousStatus != "" && previousStatus != meals_models.ReviewStatusApproved {
+		return meals_models.ReviewStatusPending, "Your edit is awaiting a moderator's review"
+	}
+	return meals_models.ReviewStatusApproved, ""
+}
+
+func (s *service) moderateReview(review *meals_models.MealReview, hasNewImage bool, previousStatus string) {
+	review.Status, review.ModerationReason = s.moderationStatus(review.ReviewText, hasNewImage, previousStatus)
+}
+
+func (s *service) GetModerationQueue(status string, limit, page int) (map[string]interface{}, error) {
+	items, totalItems, err := s.repo.GetReviewsByStatus(status, limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := make([]meals_models.MealReview, len(items))
+	for i, item := range items {
+		reviews[i] = item.Review
+	}
+	if err := s.attachImages(reviews); err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Review = reviews[i]
+	}
+
+	return map[string]interface{}{
+		"reviews":    items,
+		"status":     status,
+		"page":       page,
+		"totalItems": totalItems,
+		"totalPages": int(math.Ceil(float64(totalItems) / float64(limit))),
+	}, nil
+}
+
+func (s *service) ApproveReview(moderatorID string, reviewID int) error {
+	return s.moderate(moderatorID, reviewID, meals_models.ReviewStatusApproved, "")
+}
+
+func (s *service) RejectReview(moderatorID string, reviewID int, reason string) error {
+	return s.moderate(moderatorID, reviewID, meals_models.ReviewStatusRejected, reason)
+}
+
+func (s *service) moderate(moderatorID string, reviewID int, status, reason string) error {
+	found, err := s.repo.ModerateReview(reviewID, status, reason, moderatorID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrReviewNotFound
+	}
+	return nil
+}
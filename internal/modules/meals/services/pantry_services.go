@@ -0,0 +1,263 @@
+package meals_services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+)
+
+const (
+	// pantrySearchLimit caps how many pantry ingredients are used to find candidates
+	pantrySearchLimit = 10
+	// pantryCandidateLimit caps how many candidate meals are fully looked up
+	pantryCandidateLimit = 30
+	// lookupConcurrency bounds parallel lookups against the meal source
+	lookupConcurrency = 5
+)
+
+type pantryMatch struct {
+	meal    *meals_models.Meal
+	matched int
+	missing []meals_models.MealIngredient
+}
+
+func (s *service) GetPantryMatches(userID string, limit, page int) (map[string]interface{}, error) {
+	pantry, err := s.repo.GetPantryIngredients(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pantry) == 0 {
+		return map[string]interface{}{
+			"meals":               []map[string]interface{}{},
+			"pantrySize":          0,
+			"searchedIngredients": []string{},
+			"truncated":           false,
+			"page":                page,
+			"totalItems":          0,
+			"totalPages":          0,
+		}, nil
+	}
+
+	candidateIDs, searched, err := pantryCandidates(pantry)
+	if err != nil {
+		return nil, err
+	}
+
+	meals, err := s.lookupMeals(candidateIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []pantryMatch
+	for _, meal := range meals {
+		match := pantryMatch{meal: meal}
+		for _, ingredient := range meal.MealIngredient {
+			if matchesAnyIngredient(pantry, ingredient.IngredientName) {
+				match.matched++
+			} else {
+				match.missing = append(match.missing, ingredient)
+			}
+		}
+		if match.matched > 0 {
+			matches = append(matches, match)
+		}
+	}
+
+	// Best coverage first, then fewest missing ingredients
+	sort.SliceStable(matches, func(i, j int) bool {
+		ci, cj := matches[i].coverage(), matches[j].coverage()
+		if ci != cj {
+			return ci > cj
+		}
+		if len(matches[i].missing) != len(matches[j].missing) {
+			return len(matches[i].missing) < len(matches[j].missing)
+		}
+		return matches[i].meal.MealName < matches[j].meal.MealName
+	})
+
+	totalItems := len(matches)
+	totalPages := int(math.Ceil(float64(totalItems) / float64(limit)))
+
+	start := (page - 1) * limit
+	end := start + limit
+	if start > totalItems {
+		start = totalItems
+	}
+	if end > totalItems {
+		end = totalItems
+	}
+
+	result := []map[string]interface{}{}
+	for _, match := range matches[start:end] {
+		missing := match.missing
+		if missing == nil {
+			missing = []meals_models.MealIngredient{}
+		}
+
+		result = append(result, map[string]interface{}{
+			"mealId":             match.meal.MealID,
+			"mealName":           match.meal.MealName,
+			"mealThumbImage":     match.meal.MealThumbImage,
+			"isFavourite":        match.meal.IsFavourite,
+			"matchedCount":       match.matched,
+			"totalIngredients":   len(match.meal.MealIngredient),
+			"coverage":           math.Round(match.coverage()*100) / 100,
+			"missingIngredients": missing,
+		})
+	}
+
+	// Every pantry item counts towards coverage, but only the searched ones
+	// bring in candidate meals
+	return map[string]interface{}{
+		"meals":               result,
+		"pantrySize":          len(pantry),
+		"searchedIngredients": searched,
+		"truncated":           len(searched) < len(pantry),
+		"page":                page,
+		"totalItems":          totalItems,
+		"totalPages":          totalPages,
+	}, nil
+}
+
+func (m pantryMatch) coverage() float64 {
+	total := len(m.meal.MealIngredient)
+	if total == 0 {
+		return 0
+	}
+	return float64(m.matched) / float64(total)
+}
+
+// pantryCandidates finds meals that use at least one pantry ingredient, ordered
+// by how many pantry ingredients they were found under. It also returns the
+// pantry ingredients it searched, which are the first pantrySearchLimit.
+func pantryCandidates(pantry []string) ([]string, []string, error) {
+	searched := pantry
+	if len(searched) > pantrySearchLimit {
+		searched = searched[:pantrySearchLimit]
+	}
+
+	hits := map[string]int{}
+	for _, ingredient := range searched {
+		query := url.QueryEscape(strings.ReplaceAll(ingredient, " ", "_"))
+		meals, err := fetchMealsAPI(fmt.Sprintf("https://www.themealdb.com/api/json/v1/1/filter.php?i=%s", query))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, m := range meals {
+			if id := valOrEmpty(m["idMeal"]); id != "" {
+				hits[id]++
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(hits))
+	for id := range hits {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if hits[ids[i]] != hits[ids[j]] {
+			return hits[ids[i]] > hits[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	if len(ids) > pantryCandidateLimit {
+		ids = ids[:pantryCandidateLimit]
+	}
+	return ids, searched, nil
+}
+
+// lookupMeals resolves full meal records in parallel, preserving the order of ids.
+// Meals that no longer exist, or that the user cannot see, are skipped.
+func (s *service) lookupMeals(ids []string, userID string) ([]*meals_models.Meal, error) {
+	return s.lookupMealsContext(context.Background(), ids, userID)
+}
+
+// lookupMealsContext is lookupMeals, stopping early when ctx is done
+func (s *service) lookupMealsContext(ctx context.Context, ids []string, userID string) ([]*meals_models.Meal, error) {
+	results := make([]*meals_models.Meal, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, lookupConcurrency)
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			meal, err := s.findMealContext(ctx, userID, id)
+			if errors.Is(err, ErrMealNotFound) {
+				return
+			}
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i] = meal
+		}(i, id)
+	}
+	wg.Wait()
+
+	meals := make([]*meals_models.Meal, 0, len(ids))
+	for i, meal := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if meal != nil {
+			meals = append(meals, meal)
+		}
+	}
+	if err := s.markFavourites(userID, meals); err != nil {
+		return nil, err
+	}
+	return meals, nil
+}
+
+// matchesAnyIngredient reports whether any of the normalized items matches the
+// given ingredient, either exactly or as a whole-word match. Matching is one
+// way only: "chicken" covers "chicken breast", but "chicken stock" does not
+// cover "chicken".
+func matchesAnyIngredient(items []string, ingredientName string) bool {
+	ingredient := utils.NormalizeIngredient(ingredientName)
+	for _, item := range items {
+		if item == ingredient || singular(item) == singular(ingredient) {
+			return true
+		}
+		if containsWords(ingredient, item) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWords(haystack, needle string) bool {
+	return strings.Contains(" "+haystack+" ", " "+needle+" ")
+}
+
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
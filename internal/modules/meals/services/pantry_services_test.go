@@ -0,0 +1,29 @@
+package meals_services
+
+import "testing"
+
+func TestMatchesAnyIngredient(t *testing.T) {
+	tests := []struct {
+		name       string
+		items      []string
+		ingredient string
+		want       bool
+	}{
+		{"exact", []string{"chicken"}, "Chicken", true},
+		{"plural", []string{"tomatoes"}, "Tomato", true},
+		{"item within ingredient", []string{"chicken"}, "Chicken Breast", true},
+		{"pantry item does not cover a shorter ingredient", []string{"chicken stock"}, "Chicken", false},
+		{"excluding peanut butter keeps butter", []string{"peanut butter"}, "Butter", false},
+		{"excluding peanut drops peanut butter", []string{"peanut"}, "Peanut Butter", true},
+		{"partial word", []string{"egg"}, "Eggplant", false},
+		{"no items", nil, "Salt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesAnyIngredient(tt.items, tt.ingredient); got != tt.want {
+				t.Errorf("matchesAnyIngredient(%q, %q) = %v, want %v", tt.items, tt.ingredient, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package meals_services
+
+import (
+	"math"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+)
+
+func (s *service) GetTrendingMeals(userID string, limit, page int) (map[string]interface{}, error) {
+	stats, totalItems, err := s.repo.GetTrendingMeals(limit, page)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildRankingPage(userID, stats, totalItems, limit, page)
+}
+
+func (s *service) GetTopRatedMeals(userID string, limit, page int) (map[string]interface{}, error) {
+	stats, totalItems, err := s.repo.GetTopRatedMeals(limit, page)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildRankingPage(userID, stats, totalItems, limit, page)
+}
+
+func (s *service) buildRankingPage(userID string, stats []meals_models.MealStats, totalItems, limit, page int) (map[string]interface{}, error) {
+	// Meals only known from reviews have no stored name or thumbnail
+	var missing []string
+	for _, m := range stats {
+		if m.MealName == "" {
+			missing = append(missing, m.MealID)
+		}
+	}
+	resolved := map[string]*meals_models.Meal{}
+	if len(missing) > 0 {
+		meals, err := s.lookupMeals(missing, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, meal := range meals {
+			resolved[meal.MealID] = meal
+		}
+	}
+
+	ids := make([]string, 0, len(stats))
+	for _, m := range stats {
+		ids = append(ids, m.MealID)
+	}
+	favourites, err := s.repo.GetFavouriteStatuses(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	meals := []map[string]interface{}{}
+	for _, m := range stats {
+		if meal, ok := resolved[m.MealID]; ok {
+			m.MealName = meal.MealName
+			m.MealThumbImage = meal.MealThumbImage
+		}
+
+		meals = append(meals, map[string]interface{}{
+			"mealId":         m.MealID,
+			"mealName":       m.MealName,
+			"mealThumbImage": m.MealThumbImage,
+			"isFavourite":    favourites[m.MealID],
+			"avgRating":      m.AvgRating,
+			"bayesianRating": m.BayesianRating,
+			"totalReviews":   m.TotalReviews,
+			"trendingScore":  m.TrendingScore,
+		})
+	}
+
+	result := map[string]interface{}{
+		"meals":      meals,
+		"page":       page,
+		"totalItems": totalItems,
+		"totalPages": int(math.Ceil(float64(totalItems) / float64(limit))),
+	}
+	if len(stats) > 0 {
+		result["updatedAt"] = stats[0].UpdatedAt
+	}
+	return result, nil
+}
@@ -0,0 +1,550 @@
+package meals_services
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	misc_services "github.com/JonathanTriC/nomie-api/internal/modules/misc/services"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+)
+
+const (
+	// recentlySeenWindow excludes meals the user opened recently from the daily pick
+	recentlySeenWindow = 14 * 24 * time.Hour
+	// historyWindow is how far back viewing history counts as a taste signal
+	historyWindow = 30 * 24 * time.Hour
+	// maxPickAttempts bounds how many candidates are checked against exclusions
+	maxPickAttempts = 5
+
+	// Signal weights used when scoring categories and areas
+	preferenceWeight = 4.0
+	favouriteWeight  = 3.0
+	recentViewWeight = 1.0
+
+	fallbackExplanation  = "A fresh pick to help you discover something new"
+	breakfastExplanation = "A breakfast to start the day"
+
+	// breakfastCategory is drawn from for breakfast slots; it and
+	// dessertCategory are kept out of lunch and dinner
+	breakfastCategory = "Breakfast"
+	dessertCategory   = "Dessert"
+)
+
+// errNoMeals is returned when every candidate meal has been used up
+var errNoMeals = errors.New("no meals found")
+
+type recommendationTheme struct {
+	kind         string
+	value        string
+	score        float64
+	explanation  string
+	signalWeight float64
+}
+
+// recommendationSignals holds what is known about a user's taste, gathered
+// once and reused when several meals are picked in a row
+type recommendationSignals struct {
+	themes   []*recommendationTheme
+	avoid    map[string]bool
+	excluded []string
+	// themeMeals caches upstream filter results by "kind:value"
+	themeMeals map[string][]string
+	// unsuitable holds meals kept out of the current pick, such as
+	// desserts for a dinner slot
+	unsuitable map[string]bool
+}
+
+type recommendationSeed struct {
+	mealID      string
+	weight      float64
+	explanation string
+}
+
+func (s *service) GetTodayRecommendation(userID string) (*meals_models.Meal, error) {
+	today := time.Now().Format("2006-01-02")
+
+	rec, err := s.repo.GetDailyRecommendation(userID, today)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		pick, err := s.pickDailyRecommendation(userID, today)
+		if err != nil {
+			return nil, err
+		}
+		if rec, err = s.repo.SaveDailyRecommendation(pick); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := lookupMealData(rec.MealID)
+	if errors.Is(err, ErrMealNotFound) {
+		// The meal was removed from the meal source after it was picked
+		var pick *meals_models.DailyRecommendation
+		if pick, err = s.pickDailyRecommendation(userID, today, rec.MealID); err != nil {
+			return nil, err
+		}
+		if rec, err = s.repo.ReplaceDailyRecommendation(pick, rec.MealID); err != nil {
+			return nil, err
+		}
+		data, err = lookupMealData(rec.MealID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	meal := mealFromAPIData(data)
+	if err := s.markFavourites(userID, []*meals_models.Meal{meal}); err != nil {
+		return nil, err
+	}
+	meal.Explanation = rec.Explanation
+
+	return meal, nil
+}
+
+// pickDailyRecommendation scores categories and areas from the user's
+// preferences, favourites, ratings and viewing history, then picks a meal from
+// the chosen theme, skipping the meals in avoid. Randomness is seeded by user
+// and date so the same inputs always produce the same pick.
+func (s *service) pickDailyRecommendation(userID, date string, avoid ...string) (*meals_models.DailyRecommendation, error) {
+	rng := seededRand(userID, date)
+
+	signals, err := s.gatherSignals(userID, rng)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range avoid {
+		signals.avoid[id] = true
+	}
+	return s.pickFromSignals(userID, date, rng, signals)
+}
+
+// SuggestMeals picks a different meal for each of the plan slots (breakfast,
+// lunch or dinner) with the same signals as the daily recommendation, skipping
+// the meals in avoid. key seeds the draw so the same request gives the same
+// suggestions. When the candidates run out it returns the suggestions for the
+// slots before.
+func (s *service) SuggestMeals(userID, key string, slots []string, avoid []string) ([]meals_models.DailyRecommendation, error) {
+	rng := seededRand(userID, key)
+
+	signals, err := s.gatherSignals(userID, rng)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range avoid {
+		signals.avoid[id] = true
+	}
+
+	suggestions := make([]meals_models.DailyRecommendation, 0, len(slots))
+	for _, slot := range slots {
+		pick, err := s.pickForSlot(userID, key, slot, rng, signals)
+		if errors.Is(err, errNoMeals) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		signals.avoid[pick.MealID] = true
+		suggestions = append(suggestions, *pick)
+	}
+	return suggestions, nil
+}
+
+// pickForSlot draws a meal that suits a plan slot: a breakfast for breakfast,
+// and anything but breakfasts and desserts for lunch and dinner
+func (s *service) pickForSlot(userID, key, slot string, rng *rand.Rand, signals *recommendationSignals) (*meals_models.DailyRecommendation, error) {
+	if slot == "breakfast" {
+		mealID, err := s.pickFromTheme(rng, "category", breakfastCategory, signals)
+		if err != nil {
+			return nil, err
+		}
+		if mealID == "" {
+			return nil, errNoMeals
+		}
+		return &meals_models.DailyRecommendation{
+			UserID:      userID,
+			Date:        key,
+			MealID:      mealID,
+			Explanation: breakfastExplanation,
+		}, nil
+	}
+
+	unsuitable := map[string]bool{}
+	for _, category := range []string{breakfastCategory, dessertCategory} {
+		ids, err := s.themeMealIDs("category", category, signals)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			unsuitable[id] = true
+		}
+	}
+	signals.unsuitable = unsuitable
+	defer func() { signals.unsuitable = nil }()
+
+	return s.pickFromSignals(userID, key, rng, signals)
+}
+
+// gatherSignals scores categories and areas for the user and collects the
+// meals they should not be offered
+func (s *service) gatherSignals(userID string, rng *rand.Rand) (*recommendationSignals, error) {
+	prefs, err := s.repo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	favourites, err := s.repo.GetFavourites(userID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := s.repo.GetReviewsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	seen, err := s.repo.GetRecentlySeen(userID, time.Now().Add(-historyWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	// Meals the user has just looked at or already reviewed are not surprising
+	avoid := map[string]bool{}
+	for _, m := range seen {
+		if time.Since(m.SeenAt) <= recentlySeenWindow {
+			avoid[m.MealID] = true
+		}
+	}
+	for _, r := range reviews {
+		avoid[r.MealID] = true
+	}
+
+	themes := map[string]*recommendationTheme{}
+	addTheme := func(kind, value string, weight float64, explanation string) {
+		if value == "" {
+			return
+		}
+		key := kind + ":" + value
+		t, ok := themes[key]
+		if !ok {
+			t = &recommendationTheme{kind: kind, value: value}
+			themes[key] = t
+		}
+		t.score += weight
+		if weight > t.signalWeight {
+			t.signalWeight = weight
+			t.explanation = explanation
+		}
+	}
+
+	for _, c := range prefs.FavouriteCategories {
+		addTheme("category", c, preferenceWeight, fmt.Sprintf("Because you enjoy %s dishes", c))
+	}
+	for _, a := range prefs.FavouriteAreas {
+		addTheme("area", a, preferenceWeight, fmt.Sprintf("Because you love %s cuisine", a))
+	}
+
+	seedMeals, seeds, err := s.resolveSeeds(userID, rng, favourites, reviews, seen)
+	if err != nil {
+		return nil, err
+	}
+	for _, meal := range seedMeals {
+		seed := seeds[meal.MealID]
+		explanation := ""
+		if seed.explanation != "" {
+			explanation = fmt.Sprintf(seed.explanation, meal.MealName)
+		}
+		addTheme("category", meal.MealCategory, seed.weight, explanation)
+		addTheme("area", meal.MealArea, seed.weight, explanation)
+	}
+
+	ranked := make([]*recommendationTheme, 0, len(themes))
+	for _, t := range themes {
+		if t.score > 0 {
+			ranked = append(ranked, t)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].kind+ranked[i].value < ranked[j].kind+ranked[j].value
+	})
+
+	return &recommendationSignals{
+		themes:     ranked,
+		avoid:      avoid,
+		excluded:   prefs.ExcludedIngredients,
+		themeMeals: map[string][]string{},
+	}, nil
+}
+
+// pickFromSignals draws a theme and a meal from it, falling back to a draw
+// over all categories
+func (s *service) pickFromSignals(userID, date string, rng *rand.Rand, signals *recommendationSignals) (*meals_models.DailyRecommendation, error) {
+	ranked := signals.themes
+
+	// Weighted draw for the first theme so strong signals win most days without
+	// locking the user into a single category forever
+	if len(ranked) > 1 {
+		chosen := weightedPick(rng, ranked)
+		ranked[0], ranked[chosen] = ranked[chosen], ranked[0]
+	}
+
+	for _, theme := range ranked {
+		mealID, err := s.pickFromTheme(rng, theme.kind, theme.value, signals)
+		if err != nil {
+			return nil, err
+		}
+		if mealID != "" {
+			return &meals_models.DailyRecommendation{
+				UserID:      userID,
+				Date:        date,
+				MealID:      mealID,
+				Explanation: theme.explanation,
+			}, nil
+		}
+	}
+
+	return s.pickFallback(userID, date, rng, signals)
+}
+
+// resolveSeeds samples the user's strongest signals and looks up the meals
+// behind them so their categories and areas can be scored
+func (s *service) resolveSeeds(
+	userID string,
+	rng *rand.Rand,
+	favourites []meals_models.Favourite,
+	reviews []meals_models.MealReview,
+	seen []meals_models.SeenMeal,
+) ([]*meals_models.Meal, map[string]recommendationSeed, error) {
+	seeds := map[string]recommendationSeed{}
+	var order []string
+	addSeed := func(seed recommendationSeed) {
+		if _, ok := seeds[seed.mealID]; ok {
+			return
+		}
+		seeds[seed.mealID] = seed
+		order = append(order, seed.mealID)
+	}
+
+	favs := append([]meals_models.Favourite(nil), favourites...)
+	sort.Slice(favs, func(i, j int) bool { return favs[i].MealID < favs[j].MealID })
+	rng.Shuffle(len(favs), func(i, j int) { favs[i], favs[j] = favs[j], favs[i] })
+	for i, f := range favs {
+		if i == 4 {
+			break
+		}
+		addSeed(recommendationSeed{mealID: f.MealID, weight: favouriteWeight, explanation: "Because you liked %s"})
+	}
+
+	// Reviews are newest first; well-rated meals pull their theme up, poorly
+	// rated ones push it down
+	liked, disliked := 0, 0
+	for _, r := range reviews {
+		switch {
+		case r.Rating >= 4 && liked < 3:
+			liked++
+			addSeed(recommendationSeed{
+				mealID:      r.MealID,
+				weight:      float64(r.Rating - 2),
+				explanation: "Because you rated %s " + fmt.Sprintf("%d stars", r.Rating),
+			})
+		case r.Rating <= 2 && disliked < 2:
+			disliked++
+			addSeed(recommendationSeed{mealID: r.MealID, weight: -float64(3 - r.Rating)})
+		}
+	}
+
+	for i, m := range seen {
+		if i == 3 {
+			break
+		}
+		addSeed(recommendationSeed{mealID: m.MealID, weight: recentViewWeight, explanation: "Because you recently viewed %s"})
+	}
+
+	meals, err := s.lookupMeals(order, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return meals, seeds, nil
+}
+
+// pickFromTheme draws a meal from a category or area that the user has not
+// seen recently and that contains none of their excluded ingredients
+func (s *service) pickFromTheme(rng *rand.Rand, kind, value string, signals *recommendationSignals) (string, error) {
+	ids, err := s.themeMealIDs(kind, value, signals)
+	if err != nil {
+		return "", err
+	}
+
+	var candidates []string
+	for _, id := range ids {
+		if !signals.avoid[id] && !signals.unsuitable[id] {
+			candidates = append(candidates, id)
+		}
+	}
+	sort.Strings(candidates)
+	rng.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+
+	for i, id := range candidates {
+		if i == maxPickAttempts {
+			break
+		}
+		if len(signals.excluded) == 0 {
+			return id, nil
+		}
+
+		data, err := lookupMealData(id)
+		if err != nil {
+			continue
+		}
+		if !containsExcludedIngredient(mealFromAPIData(data), signals.excluded) {
+			return id, nil
+		}
+	}
+
+	return "", nil
+}
+
+// themeMealIDs lists the meals of a category or area, fetching each once
+func (s *service) themeMealIDs(kind, value string, signals *recommendationSignals) ([]string, error) {
+	key := kind + ":" + value
+	if ids, ok := signals.themeMeals[key]; ok {
+		return ids, nil
+	}
+
+	filter := "c"
+	if kind == "area" {
+		filter = "a"
+	}
+	meals, err := fetchMealsAPI(fmt.Sprintf("https://www.themealdb.com/api/json/v1/1/filter.php?%s=%s", filter, url.QueryEscape(value)))
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, m := range meals {
+		if id := valOrEmpty(m["idMeal"]); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	signals.themeMeals[key] = ids
+	return ids, nil
+}
+
+// pickFallback is used for users without any signals yet: a seeded draw over
+// all categories so new users still get a stable pick for the day
+func (s *service) pickFallback(userID, date string, rng *rand.Rand, signals *recommendationSignals) (*meals_models.DailyRecommendation, error) {
+	categories, err := misc_services.NewService().GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("no categories found")
+	}
+
+	sorted := append([]string(nil), categories...)
+	sort.Strings(sorted)
+	rng.Shuffle(len(sorted), func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] })
+
+	for _, category := range sorted {
+		mealID, err := s.pickFromTheme(rng, "category", category, signals)
+		if err != nil {
+			return nil, err
+		}
+		if mealID != "" {
+			return &meals_models.DailyRecommendation{
+				UserID:      userID,
+				Date:        date,
+				MealID:      mealID,
+				Explanation: fallbackExplanation,
+			}, nil
+		}
+	}
+
+	return nil, errNoMeals
+}
+
+func containsExcludedIngredient(meal *meals_models.Meal, excluded []string) bool {
+	normalized := make([]string, 0, len(excluded))
+	for _, e := range excluded {
+		normalized = append(normalized, utils.NormalizeIngredient(e))
+	}
+	for _, ingredient := range meal.MealIngredient {
+		if matchesAnyIngredient(normalized, ingredient.IngredientName) {
+			return true
+		}
+	}
+	return false
+}
+
+func weightedPick(rng *rand.Rand, themes []*recommendationTheme) int {
+	var total float64
+	for _, t := range themes {
+		total += t.score
+	}
+
+	target := rng.Float64() * total
+	for i, t := range themes {
+		target -= t.score
+		if target < 0 {
+			return i
+		}
+	}
+	return len(themes) - 1
+}
+
+// seededRand returns a generator that is stable for a given user and day
+func seededRand(parts ...string) *rand.Rand {
+	h := fnv.New64a()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// selectSection picks the category or area shown in a picks section. An
+// explicit request wins; otherwise the user's preferred options are rotated
+// through, falling back to every option. Selection uses rendezvous hashing
+// so an option added upstream only changes the pick when it wins outright.
+func selectSection(userID, period, requested string, options, preferred []string) (string, string, error) {
+	if requested != "" {
+		for _, o := range options {
+			if strings.EqualFold(o, requested) {
+				return o, "requested", nil
+			}
+		}
+		return requested, "", fmt.Errorf("unknown section %q", requested)
+	}
+
+	var pool []string
+	for _, p := range preferred {
+		for _, o := range options {
+			if strings.EqualFold(o, p) {
+				pool = append(pool, o)
+				break
+			}
+		}
+	}
+	reason := "preference"
+	if len(pool) == 0 {
+		pool = options
+		reason = "rotation"
+	}
+
+	var best string
+	var bestScore uint64
+	for _, o := range pool {
+		h := fnv.New64a()
+		h.Write([]byte(userID + "\x00" + period + "\x00" + o))
+		if score := h.Sum64(); best == "" || score > bestScore {
+			best, bestScore = o, score
+		}
+	}
+	return best, reason, nil
+}
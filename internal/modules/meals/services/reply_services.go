@@ -0,0 +1,140 @@
+package meals_services
+
+import (
+	"errors"
+	"math"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+)
+
+var (
+	ErrReplyNotFound  = errors.New("reply not found")
+	ErrNotReplyAuthor = errors.New("only the author can modify this reply")
+)
+
+func (s *service) GetReviewReplies(userID string, reviewID, limit, page int) (map[string]interface{}, error) {
+	if _, err := s.visibleReview(userID, reviewID); err != nil {
+		return nil, err
+	}
+
+	replies, totalItems, err := s.repo.GetReplies(reviewID, userID, limit, page)
+	if err != nil {
+		return nil, err
+	}
+	for i := range replies {
+		replies[i].IsOwnReply = replies[i].UserID == userID
+	}
+
+	return map[string]interface{}{
+		"replies":    replies,
+		"page":       page,
+		"totalItems": totalItems,
+		"totalPages": int(math.Ceil(float64(totalItems) / float64(limit))),
+	}, nil
+}
+
+// CreateReply answers a review. Replies go through the same content checks
+// as reviews.
+func (s *service) CreateReply(userID string, reviewID int, replyText string) (*meals_models.ReviewReply, error) {
+	if _, err := s.visibleReview(userID, reviewID); err != nil {
+		return nil, err
+	}
+
+	reply := &meals_models.ReviewReply{
+		ReviewID:   reviewID,
+		UserID:     userID,
+		ReplyText:  replyText,
+		IsOwnReply: true,
+	}
+	reply.Status, reply.ModerationReason = s.moderationStatus(replyText, false, "")
+
+	if err := s.repo.CreateReply(reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (s *service) UpdateReply(userID string, replyID int, replyText string) (*meals_models.ReviewReply, error) {
+	reply, err := s.authorReply(userID, replyID)
+	if err != nil {
+		return nil, err
+	}
+
+	reply.ReplyText = replyText
+	reply.Status, reply.ModerationReason = s.moderationStatus(replyText, false, reply.Status)
+
+	if err := s.repo.UpdateReply(reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (s *service) DeleteReply(userID string, replyID int) error {
+	if _, err := s.authorReply(userID, replyID); err != nil {
+		return err
+	}
+	return s.repo.DeleteReply(replyID, userID)
+}
+
+// visibleReview loads a review the user is allowed to see: an approved one,
+// or their own whatever its status
+func (s *service) visibleReview(userID string, reviewID int) (*meals_models.MealReview, error) {
+	review, err := s.repo.GetReviewByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil || (review.Status != meals_models.ReviewStatusApproved && review.UserID != userID) {
+		return nil, ErrReviewNotFound
+	}
+	return review, nil
+}
+
+// authorReply loads a reply and checks that userID wrote it
+func (s *service) authorReply(userID string, replyID int) (*meals_models.ReviewReply, error) {
+	reply, err := s.repo.GetReplyByID(replyID)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrReplyNotFound
+	}
+	if reply.UserID != userID {
+		return nil, ErrNotReplyAuthor
+	}
+	reply.IsOwnReply = true
+	return reply, nil
+}
+
+func (s *service) GetReplyModerationQueue(status string, limit, page int) (map[string]interface{}, error) {
+	replies, totalItems, err := s.repo.GetRepliesByStatus(status, limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"replies":    replies,
+		"status":     status,
+		"page":       page,
+		"totalItems": totalItems,
+		"totalPages": int(math.Ceil(float64(totalItems) / float64(limit))),
+	}, nil
+}
+
+func (s *service) ApproveReply(moderatorID string, replyID int) error {
+	return s.moderateReply(moderatorID, replyID, meals_models.ReviewStatusApproved, "")
+}
+
+func (s *service) RejectReply(moderatorID string, replyID int, reason string) error {
+	return s.moderateReply(moderatorID, replyID, meals_models.ReviewStatusRejected, reason)
+}
+
+func (s *service) moderateReply(moderatorID string, replyID int, status, reason string) error {
+	found, err := s.repo.ModerateReply(replyID, status, reason, moderatorID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrReplyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package meals_services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"mime/multipart"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	meals_repository "github.com/JonathanTriC/nomie-api/internal/modules/meals/repository"
+	"github.com/JonathanTriC/nomie-api/pkg/logger"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// ReviewImageUpload is one image attached to a review form
+type ReviewImageUpload struct {
+	File    multipart.File
+	Header  *multipart.FileHeader
+	Caption string
+}
+
+// checkReviewImages validates new images against the per-review limit, given
+// how many images the review keeps
+func (s *service) checkReviewImages(images []ReviewImageUpload, existing int) error {
+	if existing+len(images) > s.maxImages {
+		return fmt.Errorf("%w: a review can have at most %d images", meals_repository.ErrTooManyImages, s.maxImages)
+	}
+	for _, img := range images {
+		if err := checkReviewImage(img.File, img.Header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadReviewImages uploads each image under its own public ID, so a review
+// never overwrites another review's files. Already uploaded files are removed
+// if a later upload fails.
+func (s *service) uploadReviewImages(mealID, userID string, images []ReviewImageUpload) ([]meals_models.ReviewImage, error) {
+	uploaded := make([]meals_models.ReviewImage, 0, len(images))
+	for _, img := range images {
+		suffix := make([]byte, 8)
+		if _, err := rand.Read(suffix); err != nil {
+			s.destroyReviewImages(uploaded)
+			return nil, err
+		}
+
+		uploadResult, err := s.Cld.Upload.Upload(context.Background(), img.File, uploader.UploadParams{
+			Folder:   "reviews",
+			PublicID: fmt.Sprintf("review_%s_%s_%s", mealID, userID, hex.EncodeToString(suffix)),
+		})
+		if err != nil {
+			s.destroyReviewImages(uploaded)
+			return nil, fmt.Errorf("failed to upload image: %w", err)
+		}
+
+		uploaded = append(uploaded, meals_models.ReviewImage{
+			PublicID: uploadResult.PublicID,
+			ImageURL: uploadResult.SecureURL,
+			Caption:  img.Caption,
+		})
+	}
+	return uploaded, nil
+}
+
+// destroyReviewImages removes stored files from the CDN. Failures are only
+// logged since the database no longer references the files.
+func (s *service) destroyReviewImages(images []meals_models.ReviewImage) {
+	for _, img := range images {
+		if _, err := s.Cld.Upload.Destroy(context.Background(), uploader.DestroyParams{PublicID: img.PublicID}); err != nil {
+			logger.ErrorLogger.Printf("failed to delete review image %s: %v", img.PublicID, err)
+		}
+	}
+}
+
+// attachImages fills in the image gallery of each review
+func (s *service) attachImages(reviews []meals_models.MealReview) error {
+	ids := make([]int, 0, len(reviews))
+	for _, r := range reviews {
+		ids = append(ids, r.ID)
+	}
+
+	images, err := s.repo.GetReviewImages(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range reviews {
+		reviews[i].Images = images[reviews[i].ID]
+		if reviews[i].Images == nil {
+			reviews[i].Images = []meals_models.ReviewImage{}
+		}
+		reviews[i].ReviewImage = ""
+		if len(reviews[i].Images) > 0 {
+			reviews[i].ReviewImage = reviews[i].Images[0].ImageURL
+		}
+	}
+	return nil
+}
+
+func (s *service) loadReviewImages(review *meals_models.MealReview) error {
+	reviews := []meals_models.MealReview{*review}
+	if err := s.attachImages(reviews); err != nil {
+		return err
+	}
+	*review = reviews[0]
+	return nil
+}
+
+func (s *service) ArrangeReviewImages(userID string, reviewID int, images []meals_models.ReviewImageUpdate) (*meals_models.MealReview, error) {
+	review, err := s.authorReview(userID, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ArrangeReviewImages(reviewID, images); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadReviewImages(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *service) GetMealPhotos(mealID string, limit, page int) (map[string]interface{}, error) {
+	photos, totalItems, err := s.repo.GetMealPhotos(mealID, limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"photos":     photos,
+		"page":       page,
+		"totalItems": totalItems,
+		"totalPages": int(math.Ceil(float64(totalItems) / float64(limit))),
+	}, nil
+}
@@ -0,0 +1,341 @@
+package meals_services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/logger"
+)
+
+const (
+	// detailSimilarLimit is how many similar meals are embedded in the detail page
+	detailSimilarLimit = 6
+	// similarCandidateLimit caps how many candidates are looked up for scoring
+	similarCandidateLimit = 24
+	// similarCacheTTL is how long a computed similar list is reused
+	similarCacheTTL = 6 * time.Hour
+	// similarCacheSize caps how many meals' similar lists are kept
+	similarCacheSize = 1000
+	// detailSimilarTimeout is how long the detail page waits for similar meals
+	detailSimilarTimeout = 2 * time.Second
+
+	// Weights of each similarity signal; they add up to 1
+	ingredientSimilarityWeight = 0.45
+	coFavouriteWeight          = 0.20
+	categorySimilarityWeight   = 0.15
+	areaSimilarityWeight       = 0.10
+	tagSimilarityWeight        = 0.10
+)
+
+type similarCacheEntry struct {
+	meals     []meals_models.SimilarMeal
+	expiresAt time.Time
+}
+
+func (s *service) GetSimilarMeals(userID, mealID string, limit, page int) (map[string]interface{}, error) {
+	meal, err := s.LookupMeal(userID, mealID)
+	if err != nil {
+		return nil, err
+	}
+
+	similar, err := s.similarMeals(context.Background(), meal, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	totalItems := len(similar)
+	totalPages := int(math.Ceil(float64(totalItems) / float64(limit)))
+
+	start := (page - 1) * limit
+	end := start + limit
+	if start > totalItems {
+		start = totalItems
+	}
+	if end > totalItems {
+		end = totalItems
+	}
+
+	return map[string]interface{}{
+		"meals":      similar[start:end],
+		"page":       page,
+		"totalItems": totalItems,
+		"totalPages": totalPages,
+	}, nil
+}
+
+// detailSimilar returns the first similar meals for the detail page, or none
+// if they take longer than detailSimilarTimeout. A slow ranking is cancelled
+// along with its upstream lookups.
+func (s *service) detailSimilar(meal *meals_models.Meal, userID string) []meals_models.SimilarMeal {
+	ctx, cancel := context.WithTimeout(context.Background(), detailSimilarTimeout)
+	defer cancel()
+
+	done := make(chan []meals_models.SimilarMeal, 1)
+	go func() {
+		similar, err := s.similarMeals(ctx, meal, userID)
+		if err != nil && ctx.Err() == nil {
+			logger.ErrorLogger.Printf("similar meals for %s: %v", meal.MealID, err)
+		}
+		done <- similar
+	}()
+
+	select {
+	case similar := <-done:
+		if len(similar) > detailSimilarLimit {
+			return similar[:detailSimilarLimit]
+		}
+		return similar
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// similarMeals ranks meals by ingredient overlap, shared category, area and
+// tags, and how often they are favourited by the same users. The ranking does
+// not depend on the viewer, so it is cached per meal; only the favourite flag
+// is resolved per request.
+func (s *service) similarMeals(ctx context.Context, meal *meals_models.Meal, userID string) ([]meals_models.SimilarMeal, error) {
+	ranked, err := s.rankSimilarMeals(ctx, meal, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(ranked))
+	for _, m := range ranked {
+		ids = append(ids, m.MealID)
+	}
+	favourites, err := s.repo.GetFavouriteStatuses(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	similar := make([]meals_models.SimilarMeal, 0, len(ranked))
+	for _, m := range ranked {
+		m.IsFavourite = favourites[m.MealID]
+		similar = append(similar, m)
+	}
+	return similar, nil
+}
+
+func (s *service) rankSimilarMeals(ctx context.Context, meal *meals_models.Meal, userID string) ([]meals_models.SimilarMeal, error) {
+	if cached, ok := s.cachedSimilar(meal.MealID); ok {
+		return cached, nil
+	}
+
+	sameCategory, err := filterMealIDs(ctx, "c", meal.MealCategory)
+	if err != nil {
+		return nil, err
+	}
+	sameArea, err := filterMealIDs(ctx, "a", meal.MealArea)
+	if err != nil {
+		return nil, err
+	}
+	coFavourites, err := s.repo.GetCoFavourites(ctx, meal.MealID, similarCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	maxShared := 0
+	for _, shared := range coFavourites {
+		if shared > maxShared {
+			maxShared = shared
+		}
+	}
+	coFavouriteScore := func(id string) float64 {
+		if maxShared == 0 {
+			return 0
+		}
+		return float64(coFavourites[id]) / float64(maxShared)
+	}
+
+	// Cheap pre-score from signals that need no lookup, to pick which
+	// candidates are worth fetching in full
+	preScore := map[string]float64{}
+	for id := range sameCategory {
+		preScore[id] += categorySimilarityWeight
+	}
+	for id := range sameArea {
+		preScore[id] += areaSimilarityWeight
+	}
+	for id := range coFavourites {
+		preScore[id] += coFavouriteWeight * coFavouriteScore(id)
+	}
+	delete(preScore, meal.MealID)
+
+	candidates := make([]string, 0, len(preScore))
+	for id := range preScore {
+		candidates = append(candidates, id)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if preScore[candidates[i]] != preScore[candidates[j]] {
+			return preScore[candidates[i]] > preScore[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) > similarCandidateLimit {
+		candidates = candidates[:similarCandidateLimit]
+	}
+
+	candidateMeals, err := s.lookupMealsContext(ctx, candidates, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	baseIngredients := ingredientSet(meal)
+	baseTags := tagSet(meal.MealTags)
+
+	ranked := []meals_models.SimilarMeal{}
+	for _, c := range candidateMeals {
+		var score float64
+		var reasons []string
+
+		ingredients := ingredientSet(c)
+		if shared := overlap(baseIngredients, ingredients); shared > 0 {
+			score += ingredientSimilarityWeight * jaccard(baseIngredients, ingredients)
+			reasons = append(reasons, fmt.Sprintf("Shares %d ingredients", shared))
+		}
+		if c.MealCategory != "" && c.MealCategory == meal.MealCategory {
+			score += categorySimilarityWeight
+			reasons = append(reasons, fmt.Sprintf("Also a %s dish", c.MealCategory))
+		}
+		if c.MealArea != "" && c.MealArea == meal.MealArea {
+			score += areaSimilarityWeight
+			reasons = append(reasons, fmt.Sprintf("Also %s cuisine", c.MealArea))
+		}
+		tags := tagSet(c.MealTags)
+		if overlap(baseTags, tags) > 0 {
+			score += tagSimilarityWeight * jaccard(baseTags, tags)
+			reasons = append(reasons, "Similar tags")
+		}
+		if coFavourites[c.MealID] > 0 {
+			score += coFavouriteWeight * coFavouriteScore(c.MealID)
+			reasons = append(reasons, "Favourited by people who like this meal")
+		}
+
+		if score > 0 {
+			ranked = append(ranked, meals_models.SimilarMeal{
+				MealID:         c.MealID,
+				MealName:       c.MealName,
+				MealThumbImage: c.MealThumbImage,
+				Score:          math.Round(score*100) / 100,
+				Reasons:        reasons,
+			})
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].MealID < ranked[j].MealID
+	})
+
+	s.cacheSimilar(meal.MealID, ranked)
+	return ranked, nil
+}
+
+// cachedSimilar returns a meal's cached similar list, dropping it once expired
+func (s *service) cachedSimilar(mealID string) ([]meals_models.SimilarMeal, bool) {
+	s.similarMu.Lock()
+	defer s.similarMu.Unlock()
+
+	entry, ok := s.similarCache[mealID]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(s.similarCache, mealID)
+		return nil, false
+	}
+	return entry.meals, true
+}
+
+// cacheSimilar stores a meal's similar list. When the cache is full, expired
+// lists are dropped first and then the one closest to expiring.
+func (s *service) cacheSimilar(mealID string, ranked []meals_models.SimilarMeal) {
+	s.similarMu.Lock()
+	defer s.similarMu.Unlock()
+
+	now := time.Now()
+	if _, ok := s.similarCache[mealID]; !ok && len(s.similarCache) >= similarCacheSize {
+		oldest := ""
+		for id, entry := range s.similarCache {
+			if !now.Before(entry.expiresAt) {
+				delete(s.similarCache, id)
+				continue
+			}
+			if oldest == "" || entry.expiresAt.Before(s.similarCache[oldest].expiresAt) {
+				oldest = id
+			}
+		}
+		if len(s.similarCache) >= similarCacheSize {
+			delete(s.similarCache, oldest)
+		}
+	}
+	s.similarCache[mealID] = similarCacheEntry{meals: ranked, expiresAt: now.Add(similarCacheTTL)}
+}
+
+// filterMealIDs returns the IDs of meals in a category ("c") or area ("a")
+func filterMealIDs(ctx context.Context, filter, value string) (map[string]bool, error) {
+	ids := map[string]bool{}
+	if value == "" {
+		return ids, nil
+	}
+
+	meals, err := fetchMealsAPIContext(ctx, fmt.Sprintf("https://www.themealdb.com/api/json/v1/1/filter.php?%s=%s", filter, url.QueryEscape(value)))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range meals {
+		if id := valOrEmpty(m["idMeal"]); id != "" {
+			ids[id] = true
+		}
+	}
+	return ids, nil
+}
+
+func ingredientSet(meal *meals_models.Meal) map[string]bool {
+	set := map[string]bool{}
+	for _, ingredient := range meal.MealIngredient {
+		if name := singular(utils.NormalizeIngredient(ingredient.IngredientName)); name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+func tagSet(tags string) map[string]bool {
+	set := map[string]bool{}
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			set[tag] = true
+		}
+	}
+	return set
+}
+
+func overlap(a, b map[string]bool) int {
+	n := 0
+	for k := range a {
+		if b[k] {
+			n++
+		}
+	}
+	return n
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	shared := overlap(a, b)
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
@@ -0,0 +1,34 @@
+package meals_services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSimilarCacheDropsExpiredEntries(t *testing.T) {
+	s := &service{similarCache: map[string]similarCacheEntry{
+		"52772": {expiresAt: time.Now().Add(-time.Minute)},
+	}}
+
+	if _, ok := s.cachedSimilar("52772"); ok {
+		t.Fatal("expired entry was returned")
+	}
+	if len(s.similarCache) != 0 {
+		t.Errorf("cache holds %d entries, want 0", len(s.similarCache))
+	}
+}
+
+func TestSimilarCacheIsCapped(t *testing.T) {
+	s := &service{similarCache: map[string]similarCacheEntry{}}
+	for i := 0; i <= similarCacheSize; i++ {
+		s.cacheSimilar(strconv.Itoa(i), nil)
+	}
+
+	if len(s.similarCache) != similarCacheSize {
+		t.Errorf("cache holds %d entries, want %d", len(s.similarCache), similarCacheSize)
+	}
+	if _, ok := s.cachedSimilar(strconv.Itoa(similarCacheSize)); !ok {
+		t.Error("newest entry was evicted")
+	}
+}
@@ -0,0 +1,30 @@
+package meals_services
+
+import (
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	"github.com/JonathanTriC/nomie-api/pkg/steps"
+)
+
+// GetMealSteps splits the meal's instructions into steps for cooking mode,
+// linking each step to the ingredients it uses
+func (s *service) GetMealSteps(userID, mealID string) (*meals_models.MealSteps, error) {
+	meal, err := s.LookupMeal(userID, mealID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(meal.MealIngredient))
+	for _, ingredient := range meal.MealIngredient {
+		names = append(names, ingredient.IngredientName)
+	}
+	parsed := steps.Parse(meal.MealInstructions, names)
+
+	return &meals_models.MealSteps{
+		MealID:              meal.MealID,
+		MealName:            meal.MealName,
+		MealThumbImage:      meal.MealThumbImage,
+		MealYoutubeTutorial: meal.MealYoutubeTutorial,
+		Steps:               parsed,
+		TotalTimerSeconds:   steps.TotalSeconds(parsed),
+	}, nil
+}
@@ -0,0 +1,153 @@
+package planner_handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	planner_models "github.com/JonathanTriC/nomie-api/internal/modules/planner/models"
+	planner_services "github.com/JonathanTriC/nomie-api/internal/modules/planner/services"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service planner_services.Service
+}
+
+func NewHandler(service planner_services.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetPlan(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	days, err := h.service.GetPlan(userID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		h.plannerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"days": days})
+}
+
+func (h *Handler) AssignMeal(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	var req planner_models.AssignMealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.service.AssignMeal(userID, c.Param("date"), c.Param("slot"), strings.TrimSpace(req.MealID), req.Servings)
+	if err != nil {
+		h.plannerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Meal added to your plan",
+		"entry":   entry,
+	})
+}
+
+func (h *Handler) RemoveMeal(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	if err := h.service.RemoveMeal(userID, c.Param("date"), c.Param("slot")); err != nil {
+		h.plannerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Meal removed from your plan"})
+}
+
+func (h *Handler) CopyLastWeek(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	var req planner_models.CopyWeekRequest
+	// The body is optional
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	days, copied, err := h.service.CopyLastWeek(userID, req.WeekStart, req.Overwrite)
+	if err != nil {
+		h.plannerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Last week's plan copied",
+		"copied":  copied,
+		"days":    days,
+	})
+}
+
+func (h *Handler) AutoFill(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	var req planner_models.AutoFillRequest
+	// The body is optional
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	days, filled, err := h.service.AutoFill(userID, req.From, req.To, req.Slots, req.Servings)
+	if err != nil {
+		h.plannerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Empty slots filled with recommendations",
+		"filled":  filled,
+		"days":    days,
+	})
+}
+
+// plannerError maps planner errors to their HTTP status
+func (h *Handler) plannerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, planner_services.ErrInvalidDate),
+		errors.Is(err, planner_services.ErrInvalidRange),
+		errors.Is(err, planner_services.ErrInvalidSlot):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, planner_services.ErrEntryNotFound),
+		errors.Is(err, meals_services.ErrMealNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
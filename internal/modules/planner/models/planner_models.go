@@ -0,0 +1,56 @@
+package planner_models
+
+import "time"
+
+// Slots of a plan day, in the order they are served
+const (
+	SlotBreakfast = "breakfast"
+	SlotLunch     = "lunch"
+	SlotDinner    = "dinner"
+)
+
+var Slots = []string{SlotBreakfast, SlotLunch, SlotDinner}
+
+// Where a plan entry came from
+const (
+	SourceManual = "manual"
+	SourceAuto   = "auto"
+	SourceCopied = "copied"
+)
+
+type PlanEntry struct {
+	Date           string    `json:"date"`
+	Slot           string    `json:"slot"`
+	MealID         string    `json:"mealId"`
+	MealName       string    `json:"mealName"`
+	MealThumbImage string    `json:"mealThumbImage"`
+	Servings       int       `json:"servings"`
+	Source         string    `json:"source"`
+	Explanation    string    `json:"explanation,omitempty"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type PlanDay struct {
+	Date      string     `json:"date"`
+	Weekday   string     `json:"weekday"`
+	Breakfast *PlanEntry `json:"breakfast"`
+	Lunch     *PlanEntry `json:"lunch"`
+	Dinner    *PlanEntry `json:"dinner"`
+}
+
+type AssignMealRequest struct {
+	MealID   string `json:"mealId" binding:"required"`
+	Servings int    `json:"servings" binding:"omitempty,min=1,max=50"`
+}
+
+type CopyWeekRequest struct {
+	WeekStart string `json:"weekStart"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+type AutoFillRequest struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Slots    []string `json:"slots"`
+	Servings int      `json:"servings" binding:"omitempty,min=1,max=50"`
+}
@@ -0,0 +1,116 @@
+package planner_repository
+
+import (
+	"time"
+
+	"github.com/JonathanTriC/nomie-api/internal/database"
+	planner_models "github.com/JonathanTriC/nomie-api/internal/modules/planner/models"
+)
+
+// dateLayout is how plan dates are exchanged with clients
+const dateLayout = "2006-01-02"
+
+type Repository interface {
+	GetEntries(userID, from, to string) ([]planner_models.PlanEntry, error)
+	SaveEntry(userID string, entry *planner_models.PlanEntry) error
+	DeleteEntry(userID, date, slot string) (bool, error)
+	CopyEntries(userID, fromStart, toStart string, days int, overwrite bool) (int, error)
+}
+
+type repository struct {
+	db *database.Database
+}
+
+func NewRepository(db *database.Database) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetEntries(userID, from, to string) ([]planner_models.PlanEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT plan_date, slot, meal_id, meal_name, meal_thumb, servings, source, explanation, updated_at
+		FROM meal_plan_entries
+		WHERE user_id = $1 AND plan_date BETWEEN $2::date AND $3::date
+		ORDER BY plan_date
+	`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []planner_models.PlanEntry{}
+	for rows.Next() {
+		var e planner_models.PlanEntry
+		var date time.Time
+		if err := rows.Scan(&date, &e.Slot, &e.MealID, &e.MealName, &e.MealThumbImage, &e.Servings, &e.Source, &e.Explanation, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		e.Date = date.Format(dateLayout)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// SaveEntry puts a meal in a slot, replacing whatever was planned there
+func (r *repository) SaveEntry(userID string, entry *planner_models.PlanEntry) error {
+	return r.db.QueryRow(`
+		INSERT INTO meal_plan_entries (user_id, plan_date, slot, meal_id, meal_name, meal_thumb, servings, source, explanation)
+		VALUES ($1, $2::date, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, plan_date, slot) DO UPDATE SET
+			meal_id = EXCLUDED.meal_id,
+			meal_name = EXCLUDED.meal_name,
+			meal_thumb = EXCLUDED.meal_thumb,
+			servings = EXCLUDED.servings,
+			source = EXCLUDED.source,
+			explanation = EXCLUDED.explanation,
+			updated_at = NOW()
+		RETURNING updated_at
+	`, userID, entry.Date, entry.Slot, entry.MealID, entry.MealName, entry.MealThumbImage, entry.Servings, entry.Source, entry.Explanation).
+		Scan(&entry.UpdatedAt)
+}
+
+func (r *repository) DeleteEntry(userID, date, slot string) (bool, error) {
+	res, err := r.db.DB.Exec(`
+		DELETE FROM meal_plan_entries
+		WHERE user_id = $1 AND plan_date = $2::date AND slot = $3
+	`, userID, date, slot)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// CopyEntries copies the days starting at fromStart to the same weekdays
+// starting at toStart. Slots that are already planned are kept unless
+// overwrite is set. It returns how many slots were written.
+func (r *repository) CopyEntries(userID, fromStart, toStart string, days int, overwrite bool) (int, error) {
+	conflict := `DO NOTHING`
+	if overwrite {
+		conflict = `DO UPDATE SET
+			meal_id = EXCLUDED.meal_id,
+			meal_name = EXCLUDED.meal_name,
+			meal_thumb = EXCLUDED.meal_thumb,
+			servings = EXCLUDED.servings,
+			source = EXCLUDED.source,
+			explanation = EXCLUDED.explanation,
+			updated_at = NOW()`
+	}
+
+	res, err := r.db.DB.Exec(`
+		INSERT INTO meal_plan_entries (user_id, plan_date, slot, meal_id, meal_name, meal_thumb, servings, source, explanation)
+		SELECT user_id, plan_date + ($3::date - $2::date), slot, meal_id, meal_name, meal_thumb, servings, 'copied', explanation
+		FROM meal_plan_entries
+		WHERE user_id = $1 AND plan_date >= $2::date AND plan_date < $2::date + $4::int
+		ON CONFLICT (user_id, plan_date, slot) `+conflict, userID, fromStart, toStart, days)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
@@ -0,0 +1,27 @@
+package planner_routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	auth_middleware "github.com/JonathanTriC/nomie-api/internal/modules/auth/middleware"
+	planner_handlers "github.com/JonathanTriC/nomie-api/internal/modules/planner/handlers"
+	planner_services "github.com/JonathanTriC/nomie-api/internal/modules/planner/services"
+)
+
+func RegisterRoutes(r *gin.Engine, service planner_services.Service, jwtSecret string) {
+	handler := planner_handlers.NewHandler(service)
+
+	plannerGroup := r.Group("/v1/planner")
+	{
+		protected := plannerGroup.Group("")
+		protected.Use(auth_middleware.AuthMiddleware([]byte(jwtSecret)))
+		{
+			// MARK: Your Week of Meals
+			protected.GET("", handler.GetPlan)
+			protected.PUT("/:date/:slot", handler.AssignMeal)
+			protected.DELETE("/:date/:slot", handler.RemoveMeal)
+			protected.POST("/copy-last-week", handler.CopyLastWeek)
+			protected.POST("/auto-fill", handler.AutoFill)
+		}
+	}
+}
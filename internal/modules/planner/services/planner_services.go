@@ -0,0 +1,311 @@
+package planner_services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	planner_models "github.com/JonathanTriC/nomie-api/internal/modules/planner/models"
+	planner_repository "github.com/JonathanTriC/nomie-api/internal/modules/planner/repository"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// maxPlanDays is the longest range that can be fetched at once
+	maxPlanDays = 31
+	// maxAutoFillDays is the longest range auto-fill works on, as every empty
+	// slot costs a recommendation draw
+	maxAutoFillDays = 14
+	defaultServings = 2
+)
+
+var (
+	ErrInvalidDate   = errors.New("dates must use the YYYY-MM-DD format")
+	ErrInvalidRange  = errors.New("invalid date range")
+	ErrInvalidSlot   = errors.New("slot must be one of breakfast, lunch or dinner")
+	ErrEntryNotFound = errors.New("nothing is planned for this slot")
+)
+
+type Service interface {
+	GetPlan(userID, from, to string) ([]planner_models.PlanDay, error)
+	AssignMeal(userID, date, slot, mealID string, servings int) (*planner_models.PlanEntry, error)
+	RemoveMeal(userID, date, slot string) error
+	CopyLastWeek(userID, weekStart string, overwrite bool) ([]planner_models.PlanDay, int, error)
+	AutoFill(userID, from, to string, slots []string, servings int) ([]planner_models.PlanDay, int, error)
+}
+
+type service struct {
+	repo  planner_repository.Repository
+	meals meals_services.Service
+}
+
+func NewService(repo planner_repository.Repository, meals meals_services.Service) Service {
+	return &service{repo: repo, meals: meals}
+}
+
+func (s *service) GetPlan(userID, from, to string) ([]planner_models.PlanDay, error) {
+	start, end, err := planRange(from, to, maxPlanDays)
+	if err != nil {
+		return nil, err
+	}
+	return s.planDays(userID, start, end)
+}
+
+func (s *service) AssignMeal(userID, date, slot, mealID string, servings int) (*planner_models.PlanEntry, error) {
+	day, err := parseDate(date)
+	if err != nil {
+		return nil, err
+	}
+	if !validSlot(slot) {
+		return nil, ErrInvalidSlot
+	}
+	if servings <= 0 {
+		servings = defaultServings
+	}
+
+	meal, err := s.meals.LookupMeal(userID, mealID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &planner_models.PlanEntry{
+		Date:           day.Format(dateLayout),
+		Slot:           slot,
+		MealID:         meal.MealID,
+		MealName:       meal.MealName,
+		MealThumbImage: meal.MealThumbImage,
+		Servings:       servings,
+		Source:         planner_models.SourceManual,
+	}
+	if err := s.repo.SaveEntry(userID, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *service) RemoveMeal(userID, date, slot string) error {
+	day, err := parseDate(date)
+	if err != nil {
+		return err
+	}
+	if !validSlot(slot) {
+		return ErrInvalidSlot
+	}
+
+	found, err := s.repo.DeleteEntry(userID, day.Format(dateLayout), slot)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrEntryNotFound
+	}
+	return nil
+}
+
+// CopyLastWeek copies the plan of the week before weekStart into the week of
+// weekStart. Weeks start on Monday; an empty weekStart means the current week.
+func (s *service) CopyLastWeek(userID, weekStart string, overwrite bool) ([]planner_models.PlanDay, int, error) {
+	day := today()
+	if weekStart != "" {
+		var err error
+		if day, err = parseDate(weekStart); err != nil {
+			return nil, 0, err
+		}
+	}
+	start := startOfWeek(day)
+	previous := start.AddDate(0, 0, -7)
+
+	copied, err := s.repo.CopyEntries(userID, previous.Format(dateLayout), start.Format(dateLayout), 7, overwrite)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	days, err := s.planDays(userID, start, start.AddDate(0, 0, 6))
+	if err != nil {
+		return nil, 0, err
+	}
+	return days, copied, nil
+}
+
+// AutoFill puts recommended meals in the empty slots of the range. The
+// suggestions follow the user's preferences and excluded ingredients, and a
+// meal is not planned twice in the same range.
+func (s *service) AutoFill(userID, from, to string, slots []string, servings int) ([]planner_models.PlanDay, int, error) {
+	start, end, err := planRange(from, to, maxAutoFillDays)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(slots) == 0 {
+		slots = planner_models.Slots
+	}
+	for _, slot := range slots {
+		if !validSlot(slot) {
+			return nil, 0, ErrInvalidSlot
+		}
+	}
+	if servings <= 0 {
+		servings = defaultServings
+	}
+
+	entries, err := s.repo.GetEntries(userID, start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		return nil, 0, err
+	}
+	planned := map[string]bool{}
+	var avoid []string
+	for _, e := range entries {
+		planned[e.Date+"/"+e.Slot] = true
+		avoid = append(avoid, e.MealID)
+	}
+
+	var empty []planner_models.PlanEntry
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		for _, slot := range planner_models.Slots {
+			if containsSlot(slots, slot) && !planned[date+"/"+slot] {
+				empty = append(empty, planner_models.PlanEntry{Date: date, Slot: slot})
+			}
+		}
+	}
+
+	filled := 0
+	if len(empty) > 0 {
+		key := fmt.Sprintf("plan:%s:%s", start.Format(dateLayout), end.Format(dateLayout))
+		emptySlots := make([]string, len(empty))
+		for i, entry := range empty {
+			emptySlots[i] = entry.Slot
+		}
+		// Suggestions come in slot order and stop early when meals run out
+		suggestions, err := s.meals.SuggestMeals(userID, key, emptySlots, avoid)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for i, suggestion := range suggestions {
+			meal, err := s.meals.LookupMeal(userID, suggestion.MealID)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			entry := empty[i]
+			entry.MealID = meal.MealID
+			entry.MealName = meal.MealName
+			entry.MealThumbImage = meal.MealThumbImage
+			entry.Servings = servings
+			entry.Source = planner_models.SourceAuto
+			entry.Explanation = suggestion.Explanation
+			if err := s.repo.SaveEntry(userID, &entry); err != nil {
+				return nil, 0, err
+			}
+			filled++
+		}
+	}
+
+	days, err := s.planDays(userID, start, end)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Fewer meals may have been suggested than there were empty slots
+	return days, filled, nil
+}
+
+// planDays returns every day of the range with its planned slots, including
+// days with nothing planned
+func (s *service) planDays(userID string, start, end time.Time) ([]planner_models.PlanDay, error) {
+	entries, err := s.repo.GetEntries(userID, start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := map[string]*planner_models.PlanDay{}
+	days := []planner_models.PlanDay{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, planner_models.PlanDay{
+			Date:    day.Format(dateLayout),
+			Weekday: day.Weekday().String(),
+		})
+	}
+	for i := range days {
+		byDate[days[i].Date] = &days[i]
+	}
+
+	for i := range entries {
+		day, ok := byDate[entries[i].Date]
+		if !ok {
+			continue
+		}
+		switch entries[i].Slot {
+		case planner_models.SlotBreakfast:
+			day.Breakfast = &entries[i]
+		case planner_models.SlotLunch:
+			day.Lunch = &entries[i]
+		case planner_models.SlotDinner:
+			day.Dinner = &entries[i]
+		}
+	}
+	return days, nil
+}
+
+// planRange parses an inclusive date range. Without dates it is the current
+// week; with only from it is the week starting at from.
+func planRange(from, to string, maxDays int) (time.Time, time.Time, error) {
+	if from == "" && to == "" {
+		start := startOfWeek(today())
+		return start, start.AddDate(0, 0, 6), nil
+	}
+	if from == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from is required when to is set", ErrInvalidRange)
+	}
+
+	start, err := parseDate(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end := start.AddDate(0, 0, 6)
+	if to != "" {
+		if end, err = parseDate(to); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: to must not be before from", ErrInvalidRange)
+	}
+	if end.Sub(start) >= time.Duration(maxDays)*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: at most %d days at a time", ErrInvalidRange, maxDays)
+	}
+	return start, end, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	day, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	return day, nil
+}
+
+func today() time.Time {
+	day, _ := time.Parse(dateLayout, time.Now().Format(dateLayout))
+	return day
+}
+
+// startOfWeek returns the Monday of the week containing day
+func startOfWeek(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func validSlot(slot string) bool {
+	return containsSlot(planner_models.Slots, slot)
+}
+
+func containsSlot(slots []string, slot string) bool {
+	for _, s := range slots {
+		if s == slot {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package recipes_handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	recipes_models "github.com/JonathanTriC/nomie-api/internal/modules/recipes/models"
+	recipes_services "github.com/JonathanTriC/nomie-api/internal/modules/recipes/services"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/recipeimport"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service recipes_services.Service
+}
+
+func NewHandler(service recipes_services.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetRecipes(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	result, err := h.service.GetRecipes(userID, limit, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) GetRecipe(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	recipeID, ok := idParam(c, "recipeId")
+	if !ok {
+		return
+	}
+
+	recipe, err := h.service.GetRecipe(userID, recipeID)
+	if err != nil {
+		h.recipeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recipe": recipe})
+}
+
+func (h *Handler) CreateRecipe(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	var req recipes_models.RecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipe, err := h.service.CreateRecipe(userID, req)
+	if err != nil {
+		h.recipeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Recipe created successfully",
+		"recipe":  recipe,
+	})
+}
+
+func (h *Handler) UpdateRecipe(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	recipeID, ok := idParam(c, "recipeId")
+	if !ok {
+		return
+	}
+
+	var req recipes_models.RecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipe, err := h.service.UpdateRecipe(userID, recipeID, req)
+	if err != nil {
+		h.recipeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Recipe updated successfully",
+		"recipe":  recipe,
+	})
+}
+
+func (h *Handler) DeleteRecipe(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	recipeID, ok := idParam(c, "recipeId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRecipe(userID, recipeID); err != nil {
+		h.recipeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recipe deleted successfully"})
+}
+
+// SetRecipeImage takes the photo from the "image" field of a multipart form
+func (h *Handler) SetRecipeImage(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	recipeID, ok := idParam(c, "recipeId")
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is required"})
+		return
+	}
+	defer file.Close()
+
+	recipe, err := h.service.SetRecipeImage(userID, recipeID, file, header)
+	if err != nil {
+		h.recipeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Recipe image updated successfully",
+		"recipe":  recipe,
+	})
+}
+
+func (h *Handler) RemoveRecipeImage(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	recipeID, ok := idParam(c, "recipeId")
+	if !ok {
+		return
+	}
+
+	recipe, err := h.service.RemoveRecipeImage(userID, recipeID)
+	if err != nil {
+		h.recipeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Recipe image removed successfully",
+		"recipe":  recipe,
+	})
+}
+
+// recipeError maps recipe errors to their HTTP status
+// ImportRecipe saves the recipe on a web page as one of the user's recipes
+func (h *Handler) ImportRecipe(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	var req recipes_models.ImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipe, err := h.service.ImportRecipe(userID, req.URL, req.Visibility)
+	if err != nil {
+		h.recipeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Recipe imported successfully",
+		"recipe":  recipe,
+	})
+}
+
+func (h *Handler) recipeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, recipes_services.ErrRecipeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, recipes_services.ErrInvalidRecipe),
+		errors.Is(err, meals_services.ErrInvalidImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, recipeimport.ErrInvalidURL),
+		errors.Is(err, recipeimport.ErrBlockedAddress):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, recipeimport.ErrTooLarge),
+		errors.Is(err, recipeimport.ErrNotHTML),
+		errors.Is(err, recipeimport.ErrNoRecipe):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, recipeimport.ErrFetchFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func idParam(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a number"})
+		return 0, false
+	}
+	return id, true
+}
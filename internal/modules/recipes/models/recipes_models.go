@@ -0,0 +1,60 @@
+package recipes_models
+
+import (
+	"time"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+)
+
+// Who can see a recipe: private recipes only resolve for their author
+const (
+	VisibilityPrivate = "private"
+	VisibilityPublic  = "public"
+)
+
+// Recipe is a user-authored recipe. It has the shape of an upstream meal,
+// with MealID set to the namespaced ID it is served under.
+type Recipe struct {
+	meals_models.Meal
+	ID            int       `json:"recipeId"`
+	OwnerID       string    `json:"ownerId"`
+	Visibility    string    `json:"visibility"`
+	ImagePublicID string    `json:"-"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// VisibleTo reports whether userID may see the recipe
+func (r *Recipe) VisibleTo(userID string) bool {
+	return r.OwnerID == userID || r.Visibility == VisibilityPublic
+}
+
+// RecipeRequest creates or replaces a recipe. Field names follow the meal
+// shape so clients can reuse their meal forms.
+type RecipeRequest struct {
+	MealName            string             `json:"mealName" binding:"required,max=120"`
+	MealAlternate       string             `json:"mealAlternate" binding:"max=120"`
+	MealCategory        string             `json:"mealCategory" binding:"max=60"`
+	MealArea            string             `json:"mealArea" binding:"max=60"`
+	MealInstructions    string             `json:"mealInstructions" binding:"required,max=20000"`
+	MealTags            string             `json:"mealTags" binding:"max=200"`
+	MealYoutubeTutorial string             `json:"mealYoutubeTutorial" binding:"omitempty,url,max=500"`
+	MealSource          string             `json:"mealSource" binding:"omitempty,url,max=500"`
+	MealIngredient      []RecipeIngredient `json:"mealIngredient" binding:"required,min=1,max=50,dive"`
+	Servings            int                `json:"servings" binding:"omitempty,min=1,max=100"`
+	PrepMinutes         int                `json:"prepMinutes" binding:"omitempty,min=0,max=10080"`
+	CookMinutes         int                `json:"cookMinutes" binding:"omitempty,min=0,max=10080"`
+	TotalMinutes        int                `json:"totalMinutes" binding:"omitempty,min=0,max=10080"`
+	Visibility          string             `json:"visibility" binding:"omitempty,oneof=private public"`
+}
+
+// ImportRequest names a web page to import a recipe from
+type ImportRequest struct {
+	URL        string `json:"url" binding:"required,url,max=2000"`
+	Visibility string `json:"visibility" binding:"omitempty,oneof=private public"`
+}
+
+type RecipeIngredient struct {
+	IngredientName    string `json:"ingredientName" binding:"required,max=100"`
+	IngredientMeasure string `json:"ingredientMeasure" binding:"max=100"`
+}
@@ -0,0 +1,288 @@
+package recipes_repository
+
+import (
+	"database/sql"
+
+	"github.com/JonathanTriC/nomie-api/internal/database"
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	recipes_models "github.com/JonathanTriC/nomie-api/internal/modules/recipes/models"
+	"github.com/lib/pq"
+)
+
+type Repository interface {
+	GetRecipe(recipeID int) (*recipes_models.Recipe, error)
+	GetRecipes(userID string, limit, page int) ([]recipes_models.Recipe, int, error)
+	SearchRecipes(viewerID, query string, limit int) ([]recipes_models.Recipe, error)
+	CreateRecipe(recipe *recipes_models.Recipe) error
+	UpdateRecipe(recipe *recipes_models.Recipe) error
+	SetRecipeImage(recipeID int, imageURL, publicID string) error
+	DeleteRecipe(recipeID int) error
+}
+
+type repository struct {
+	db *database.Database
+}
+
+func NewRepository(db *database.Database) Repository {
+	return &repository{db: db}
+}
+
+// recipeColumns is the column list scanned by scanRecipe
+const recipeColumns = `id, user_id, name, alternate_name, category, area, instructions, tags,
+	youtube_url, source_url, image_url, image_public_id, COALESCE(servings, 0), prep_minutes, cook_minutes,
+	total_minutes, visibility, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRecipe(row rowScanner) (*recipes_models.Recipe, error) {
+	var r recipes_models.Recipe
+	err := row.Scan(&r.ID, &r.OwnerID, &r.MealName, &r.MealAlternate, &r.MealCategory, &r.MealArea,
+		&r.MealInstructions, &r.MealTags, &r.MealYoutubeTutorial, &r.MealSource, &r.MealThumbImage,
+		&r.ImagePublicID, &r.BaseServings, &r.PrepMinutes, &r.CookMinutes, &r.TotalMinutes, &r.Visibility,
+		&r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	r.MealID = meals_models.UserRecipeMealID(r.ID)
+	r.DateModified = r.UpdatedAt.Format("2006-01-02 15:04:05")
+	r.MealIngredient = []meals_models.MealIngredient{}
+	return &r, nil
+}
+
+func (r *repository) GetRecipe(recipeID int) (*recipes_models.Recipe, error) {
+	recipe, err := scanRecipe(r.db.QueryRow(`
+		SELECT `+recipeColumns+`
+		FROM user_recipes
+		WHERE id = $1
+	`, recipeID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := []recipes_models.Recipe{*recipe}
+	if err := r.attachIngredients(recipes); err != nil {
+		return nil, err
+	}
+	return &recipes[0], nil
+}
+
+// GetRecipes returns one page of a user's recipes, most recently edited first
+func (r *repository) GetRecipes(userID string, limit, page int) ([]recipes_models.Recipe, int, error) {
+	var totalItems int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM user_recipes WHERE user_id = $1`, userID).Scan(&totalItems); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+recipeColumns+`
+		FROM user_recipes
+		WHERE user_id = $1
+		ORDER BY updated_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	recipes, err := scanRecipes(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := r.attachIngredients(recipes); err != nil {
+		return nil, 0, err
+	}
+	return recipes, totalItems, nil
+}
+
+// SearchRecipes finds recipes whose name contains query among the viewer's
+// own and everyone's public recipes. Ingredients are not loaded.
+func (r *repository) SearchRecipes(viewerID, query string, limit int) ([]recipes_models.Recipe, error) {
+	rows, err := r.db.Query(`
+		SELECT `+recipeColumns+`
+		FROM user_recipes
+		WHERE (user_id = $1 OR visibility = 'public')
+		  AND POSITION(LOWER($2) IN LOWER(name)) > 0
+		ORDER BY LOWER(name), id
+		LIMIT $3
+	`, viewerID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRecipes(rows)
+}
+
+func scanRecipes(rows *sql.Rows) ([]recipes_models.Recipe, error) {
+	recipes := []recipes_models.Recipe{}
+	for rows.Next() {
+		recipe, err := scanRecipe(rows)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, *recipe)
+	}
+	return recipes, rows.Err()
+}
+
+// attachIngredients loads the ingredients of each recipe with one query
+func (r *repository) attachIngredients(recipes []recipes_models.Recipe) error {
+	if len(recipes) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(recipes))
+	index := map[int]int{}
+	for i, recipe := range recipes {
+		ids = append(ids, recipe.ID)
+		index[recipe.ID] = i
+	}
+
+	rows, err := r.db.Query(`
+		SELECT recipe_id, name, measure
+		FROM user_recipe_ingredients
+		WHERE recipe_id = ANY($1)
+		ORDER BY recipe_id, position
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recipeID int
+		var ingredient meals_models.MealIngredient
+		if err := rows.Scan(&recipeID, &ingredient.IngredientName, &ingredient.IngredientMeasure); err != nil {
+			return err
+		}
+		i := index[recipeID]
+		recipes[i].MealIngredient = append(recipes[i].MealIngredient, ingredient)
+	}
+	return rows.Err()
+}
+
+// CreateRecipe saves a recipe with its ingredients in one transaction
+func (r *repository) CreateRecipe(recipe *recipes_models.Recipe) error {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO user_recipes (user_id, name, alternate_name, category, area, instructions, tags,
+			youtube_url, source_url, servings, prep_minutes, cook_minutes, total_minutes, visibility)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, 0), $11, $12, $13, $14)
+		RETURNING id, created_at, updated_at
+	`, recipe.OwnerID, recipe.MealName, recipe.MealAlternate, recipe.MealCategory, recipe.MealArea,
+		recipe.MealInstructions, recipe.MealTags, recipe.MealYoutubeTutorial, recipe.MealSource,
+		recipe.BaseServings, recipe.PrepMinutes, recipe.CookMinutes, recipe.TotalMinutes, recipe.Visibility).
+		Scan(&recipe.ID, &recipe.CreatedAt, &recipe.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertIngredients(tx, recipe.ID, recipe.MealIngredient); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	recipe.MealID = meals_models.UserRecipeMealID(recipe.ID)
+	recipe.DateModified = recipe.UpdatedAt.Format("2006-01-02 15:04:05")
+	return nil
+}
+
+// UpdateRecipe replaces a recipe's fields and ingredients. The image is set
+// separately through SetRecipeImage.
+func (r *repository) UpdateRecipe(recipe *recipes_models.Recipe) error {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		UPDATE user_recipes
+		SET name = $2, alternate_name = $3, category = $4, area = $5, instructions = $6, tags = $7,
+			youtube_url = $8, source_url = $9, servings = NULLIF($10, 0), prep_minutes = $11,
+			cook_minutes = $12, total_minutes = $13, visibility = $14, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, recipe.ID, recipe.MealName, recipe.MealAlternate, recipe.MealCategory, recipe.MealArea,
+		recipe.MealInstructions, recipe.MealTags, recipe.MealYoutubeTutorial, recipe.MealSource,
+		recipe.BaseServings, recipe.PrepMinutes, recipe.CookMinutes, recipe.TotalMinutes, recipe.Visibility).
+		Scan(&recipe.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_recipe_ingredients WHERE recipe_id = $1`, recipe.ID); err != nil {
+		return err
+	}
+	if err := insertIngredients(tx, recipe.ID, recipe.MealIngredient); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	recipe.DateModified = recipe.UpdatedAt.Format("2006-01-02 15:04:05")
+	return nil
+}
+
+func insertIngredients(tx *sql.Tx, recipeID int, ingredients []meals_models.MealIngredient) error {
+	for i, ingredient := range ingredients {
+		_, err := tx.Exec(`
+			INSERT INTO user_recipe_ingredients (recipe_id, position, name, measure)
+			VALUES ($1, $2, $3, $4)
+		`, recipeID, i, ingredient.IngredientName, ingredient.IngredientMeasure)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repository) SetRecipeImage(recipeID int, imageURL, publicID string) error {
+	_, err := r.db.Exec(`
+		UPDATE user_recipes
+		SET image_url = $2, image_public_id = $3, updated_at = NOW()
+		WHERE id = $1
+	`, recipeID, imageURL, publicID)
+	return err
+}
+
+// DeleteRecipe removes a recipe and the favourites, collection entries,
+// viewing history and plan entries that point at its meal ID. Reviews are
+// kept with the meal ID but can no longer be reached.
+func (r *repository) DeleteRecipe(recipeID int) error {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	mealID := meals_models.UserRecipeMealID(recipeID)
+	for _, query := range []string{
+		`DELETE FROM favourites WHERE meal_id = $1`,
+		`DELETE FROM favourite_collection_meals WHERE meal_id = $1`,
+		`DELETE FROM user_last_seen WHERE meal_id = $1`,
+		`DELETE FROM meal_plan_entries WHERE meal_id = $1`,
+	} {
+		if _, err := tx.Exec(query, mealID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_recipes WHERE id = $1`, recipeID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,36 @@
+package recipes_routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/JonathanTriC/nomie-api/internal/database"
+	auth_middleware "github.com/JonathanTriC/nomie-api/internal/modules/auth/middleware"
+	recipes_handlers "github.com/JonathanTriC/nomie-api/internal/modules/recipes/handlers"
+	recipes_repository "github.com/JonathanTriC/nomie-api/internal/modules/recipes/repository"
+	recipes_services "github.com/JonathanTriC/nomie-api/internal/modules/recipes/services"
+)
+
+// RegisterRoutes registers recipe authoring. Recipes are read, searched,
+// favourited and reviewed through the meals endpoints under their "u-" ID.
+func RegisterRoutes(r *gin.Engine, db *database.Database, jwtSecret string) {
+	repo := recipes_repository.NewRepository(db)
+	service := recipes_services.NewService(repo)
+	handler := recipes_handlers.NewHandler(service)
+
+	recipeGroup := r.Group("/v1/recipes")
+	{
+		protected := recipeGroup.Group("")
+		protected.Use(auth_middleware.AuthMiddleware([]byte(jwtSecret)))
+		{
+			// MARK: Your Own Recipes
+			protected.GET("", handler.GetRecipes)
+			protected.POST("", handler.CreateRecipe)
+			protected.POST("/import", handler.ImportRecipe)
+			protected.GET("/:recipeId", handler.GetRecipe)
+			protected.PUT("/:recipeId", handler.UpdateRecipe)
+			protected.DELETE("/:recipeId", handler.DeleteRecipe)
+			protected.PUT("/:recipeId/image", handler.SetRecipeImage)
+			protected.DELETE("/:recipeId/image", handler.RemoveRecipeImage)
+		}
+	}
+}
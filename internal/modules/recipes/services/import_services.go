@@ -0,0 +1,176 @@
+package recipes_services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	recipes_models "github.com/JonathanTriC/nomie-api/internal/modules/recipes/models"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/logger"
+	"github.com/JonathanTriC/nomie-api/pkg/measure"
+	"github.com/JonathanTriC/nomie-api/pkg/recipeimport"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// Limits of an imported recipe, matching those of RecipeRequest
+const (
+	maxImportNameLength         = 120
+	maxImportCategoryLength     = 60
+	maxImportTagsLength         = 200
+	maxImportInstructionsLength = 20000
+	maxImportURLLength          = 500
+	maxImportServings           = 100
+	maxImportIngredients        = 50
+	maxImportIngredientLength   = 100
+)
+
+// newImporter reads the fetch limits from RECIPE_IMPORT_TIMEOUT and
+// RECIPE_IMPORT_MAX_BYTES
+func newImporter() *recipeimport.Importer {
+	opts := recipeimport.Options{}
+	if d, err := time.ParseDuration(utils.GetEnv("RECIPE_IMPORT_TIMEOUT", "")); err == nil && d > 0 {
+		opts.Timeout = d
+	}
+	if n, err := strconv.ParseInt(utils.GetEnv("RECIPE_IMPORT_MAX_BYTES", ""), 10, 64); err == nil && n > 0 {
+		opts.MaxBytes = n
+	}
+	return recipeimport.New(opts)
+}
+
+// ImportRecipe saves the recipe published on a web page as one of the
+// user's recipes. The first image of the page is copied to the CDN; the
+// recipe is still saved if that fails.
+func (s *service) ImportRecipe(userID, pageURL, visibility string) (*recipes_models.Recipe, error) {
+	imported, err := s.importer.Import(context.Background(), pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	recipe, err := s.CreateRecipe(userID, importRequest(imported, visibility))
+	if errors.Is(err, ErrInvalidRecipe) {
+		// The page's markup lacks something every recipe needs
+		return nil, fmt.Errorf("%w: %v", recipeimport.ErrNoRecipe, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, imageURL := range imported.Images {
+		// The CDN fetches the file itself, so it never passes through here
+		uploadResult, err := s.cld.Upload.Upload(context.Background(), imageURL, uploader.UploadParams{
+			Folder:   "recipes",
+			PublicID: fmt.Sprintf("recipe_%d_%s", recipe.ID, utils.GenerateRandomID()),
+		})
+		if err != nil || uploadResult.SecureURL == "" {
+			logger.ErrorLogger.Printf("failed to copy image %s of imported recipe %d: %v", imageURL, recipe.ID, err)
+			continue
+		}
+		if err := s.repo.SetRecipeImage(recipe.ID, uploadResult.SecureURL, uploadResult.PublicID); err != nil {
+			s.destroyImage(uploadResult.PublicID)
+			return nil, err
+		}
+		return s.repo.GetRecipe(recipe.ID)
+	}
+	return recipe, nil
+}
+
+// importRequest maps an imported recipe onto a create request, cut to the
+// limits a hand-written recipe has
+func importRequest(imported *recipeimport.Recipe, visibility string) recipes_models.RecipeRequest {
+	req := recipes_models.RecipeRequest{
+		MealName:         truncate(imported.Name, maxImportNameLength),
+		MealCategory:     truncate(imported.Category, maxImportCategoryLength),
+		MealArea:         truncate(imported.Cuisine, maxImportCategoryLength),
+		MealInstructions: truncate(strings.Join(imported.Instructions, "\r\n"), maxImportInstructionsLength),
+		Servings:         imported.Servings,
+		PrepMinutes:      minutes(imported.PrepTime),
+		CookMinutes:      minutes(imported.CookTime),
+		TotalMinutes:     minutes(imported.TotalTime),
+		Visibility:       visibility,
+	}
+	if req.Servings > maxImportServings {
+		req.Servings = 0
+	}
+	// Cutting a URL would break it, so long ones are left out
+	if utf8.RuneCountInString(imported.SourceURL) <= maxImportURLLength {
+		req.MealSource = imported.SourceURL
+	}
+	if isYoutubeURL(imported.VideoURL) && utf8.RuneCountInString(imported.VideoURL) <= maxImportURLLength {
+		req.MealYoutubeTutorial = imported.VideoURL
+	}
+
+	var tags []string
+	length := 0
+	for _, keyword := range imported.Keywords {
+		keyword = strings.ReplaceAll(keyword, ",", " ")
+		if length+utf8.RuneCountInString(keyword)+1 > maxImportTagsLength {
+			break
+		}
+		tags = append(tags, keyword)
+		length += utf8.RuneCountInString(keyword) + 1
+	}
+	req.MealTags = strings.Join(tags, ",")
+
+	for _, line := range imported.Ingredients {
+		if len(req.MealIngredient) == maxImportIngredients {
+			break
+		}
+		measureText, name := splitIngredient(line)
+		req.MealIngredient = append(req.MealIngredient, recipes_models.RecipeIngredient{
+			IngredientName:    truncate(name, maxImportIngredientLength),
+			IngredientMeasure: truncate(measureText, maxImportIngredientLength),
+		})
+	}
+	return req
+}
+
+// maxMeasureWords is the most words a measure at the start of an ingredient
+// line is looked for in, as in "2 x 400g tins"
+const maxMeasureWords = 4
+
+// splitIngredient splits an ingredient line such as "200g plain flour" into
+// its measure and name. The longest leading run of words that reads as a
+// measure with nothing left over is taken; lines with no measure are all name.
+func splitIngredient(line string) (string, string) {
+	words := strings.Fields(line)
+	for n := min(maxMeasureWords, len(words)-1); n > 0; n-- {
+		prefix := strings.Join(words[:n], " ")
+		if m := measure.Parse(prefix); m.Parsed && m.Note == "" {
+			name := strings.Join(words[n:], " ")
+			name = strings.TrimPrefix(name, "of ")
+			return prefix, name
+		}
+	}
+	return "", strings.Join(words, " ")
+}
+
+func isYoutubeURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	return host == "youtube.com" || host == "m.youtube.com" || host == "youtu.be"
+}
+
+// minutes rounds d to whole minutes, dropping times past a week as bad markup
+func minutes(d time.Duration) int {
+	m := int(d.Round(time.Minute) / time.Minute)
+	if m > 10080 {
+		return 0
+	}
+	return m
+}
+
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:max]))
+}
@@ -0,0 +1,236 @@
+package recipes_services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"mime/multipart"
+	"strings"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	recipes_models "github.com/JonathanTriC/nomie-api/internal/modules/recipes/models"
+	recipes_repository "github.com/JonathanTriC/nomie-api/internal/modules/recipes/repository"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/logger"
+	"github.com/JonathanTriC/nomie-api/pkg/recipeimport"
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+var (
+	ErrRecipeNotFound = errors.New("recipe not found")
+	ErrInvalidRecipe  = errors.New("invalid recipe")
+)
+
+type Service interface {
+	GetRecipes(userID string, limit, page int) (map[string]interface{}, error)
+	GetRecipe(userID string, recipeID int) (*recipes_models.Recipe, error)
+	CreateRecipe(userID string, req recipes_models.RecipeRequest) (*recipes_models.Recipe, error)
+	UpdateRecipe(userID string, recipeID int, req recipes_models.RecipeRequest) (*recipes_models.Recipe, error)
+	DeleteRecipe(userID string, recipeID int) error
+	SetRecipeImage(userID string, recipeID int, file multipart.File, header *multipart.FileHeader) (*recipes_models.Recipe, error)
+	RemoveRecipeImage(userID string, recipeID int) (*recipes_models.Recipe, error)
+	ImportRecipe(userID, pageURL, visibility string) (*recipes_models.Recipe, error)
+}
+
+type service struct {
+	repo     recipes_repository.Repository
+	cld      *cloudinary.Cloudinary
+	importer *recipeimport.Importer
+}
+
+func NewService(repo recipes_repository.Repository) Service {
+	cld, err := cloudinary.NewFromURL(utils.GetEnv("CDN_API_URL", "your_cdn_api_url"))
+	if err != nil {
+		log.Fatalf("failed to init cloudinary: %v", err)
+	}
+	return &service{repo: repo, cld: cld, importer: newImporter()}
+}
+
+func (s *service) GetRecipes(userID string, limit, page int) (map[string]interface{}, error) {
+	recipes, totalItems, err := s.repo.GetRecipes(userID, limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"recipes":    recipes,
+		"page":       page,
+		"totalItems": totalItems,
+		"totalPages": int(math.Ceil(float64(totalItems) / float64(limit))),
+	}, nil
+}
+
+// GetRecipe returns one of the user's own recipes for editing. Other users'
+// public recipes are read through the meals endpoints.
+func (s *service) GetRecipe(userID string, recipeID int) (*recipes_models.Recipe, error) {
+	return s.ownRecipe(userID, recipeID)
+}
+
+func (s *service) CreateRecipe(userID string, req recipes_models.RecipeRequest) (*recipes_models.Recipe, error) {
+	recipe := &recipes_models.Recipe{OwnerID: userID, Visibility: recipes_models.VisibilityPrivate}
+	if err := applyRequest(recipe, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateRecipe(recipe); err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+// UpdateRecipe replaces the recipe's fields and ingredients. The image, and
+// the visibility when none is given, are kept.
+func (s *service) UpdateRecipe(userID string, recipeID int, req recipes_models.RecipeRequest) (*recipes_models.Recipe, error) {
+	recipe, err := s.ownRecipe(userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyRequest(recipe, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateRecipe(recipe); err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+func (s *service) DeleteRecipe(userID string, recipeID int) error {
+	recipe, err := s.ownRecipe(userID, recipeID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteRecipe(recipeID); err != nil {
+		return err
+	}
+	s.destroyImage(recipe.ImagePublicID)
+	return nil
+}
+
+// SetRecipeImage uploads a new photo for the recipe and removes the one it
+// replaces
+func (s *service) SetRecipeImage(userID string, recipeID int, file multipart.File, header *multipart.FileHeader) (*recipes_models.Recipe, error) {
+	recipe, err := s.ownRecipe(userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := meals_services.CheckImage(file, header); err != nil {
+		return nil, err
+	}
+
+	uploadResult, err := s.cld.Upload.Upload(context.Background(), file, uploader.UploadParams{
+		Folder:   "recipes",
+		PublicID: fmt.Sprintf("recipe_%d_%s", recipeID, utils.GenerateRandomID()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload image: %w", err)
+	}
+
+	if err := s.repo.SetRecipeImage(recipeID, uploadResult.SecureURL, uploadResult.PublicID); err != nil {
+		s.destroyImage(uploadResult.PublicID)
+		return nil, err
+	}
+	s.destroyImage(recipe.ImagePublicID)
+
+	return s.repo.GetRecipe(recipeID)
+}
+
+func (s *service) RemoveRecipeImage(userID string, recipeID int) (*recipes_models.Recipe, error) {
+	recipe, err := s.ownRecipe(userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetRecipeImage(recipeID, "", ""); err != nil {
+		return nil, err
+	}
+	s.destroyImage(recipe.ImagePublicID)
+
+	return s.repo.GetRecipe(recipeID)
+}
+
+// ownRecipe loads a recipe and checks that userID wrote it. Someone else's
+// recipe is reported as not found.
+func (s *service) ownRecipe(userID string, recipeID int) (*recipes_models.Recipe, error) {
+	recipe, err := s.repo.GetRecipe(recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if recipe == nil || recipe.OwnerID != userID {
+		return nil, ErrRecipeNotFound
+	}
+	return recipe, nil
+}
+
+// destroyImage removes a stored photo from the CDN. Failures are only logged
+// since the recipe no longer references the file.
+func (s *service) destroyImage(publicID string) {
+	if publicID == "" {
+		return
+	}
+	if _, err := s.cld.Upload.Destroy(context.Background(), uploader.DestroyParams{PublicID: publicID}); err != nil {
+		logger.ErrorLogger.Printf("failed to delete recipe image %s: %v", publicID, err)
+	}
+}
+
+// applyRequest copies a create or update request onto the recipe, trimming
+// text and dropping blank ingredients
+func applyRequest(recipe *recipes_models.Recipe, req recipes_models.RecipeRequest) error {
+	recipe.MealName = strings.TrimSpace(req.MealName)
+	recipe.MealAlternate = strings.TrimSpace(req.MealAlternate)
+	recipe.MealCategory = strings.TrimSpace(req.MealCategory)
+	recipe.MealArea = strings.TrimSpace(req.MealArea)
+	recipe.MealInstructions = strings.TrimSpace(req.MealInstructions)
+	recipe.MealTags = normalizeTags(req.MealTags)
+	recipe.MealYoutubeTutorial = strings.TrimSpace(req.MealYoutubeTutorial)
+	recipe.MealSource = strings.TrimSpace(req.MealSource)
+	recipe.BaseServings = req.Servings
+	recipe.PrepMinutes = req.PrepMinutes
+	recipe.CookMinutes = req.CookMinutes
+	recipe.TotalMinutes = req.TotalMinutes
+	if recipe.TotalMinutes == 0 {
+		recipe.TotalMinutes = recipe.PrepMinutes + recipe.CookMinutes
+	}
+	if req.Visibility != "" {
+		recipe.Visibility = req.Visibility
+	}
+
+	recipe.MealIngredient = []meals_models.MealIngredient{}
+	for _, ingredient := range req.MealIngredient {
+		name := strings.TrimSpace(ingredient.IngredientName)
+		if name == "" {
+			continue
+		}
+		recipe.MealIngredient = append(recipe.MealIngredient, meals_models.MealIngredient{
+			IngredientName:    name,
+			IngredientMeasure: strings.TrimSpace(ingredient.IngredientMeasure),
+		})
+	}
+
+	switch {
+	case recipe.MealName == "":
+		return fmt.Errorf("%w: mealName is required", ErrInvalidRecipe)
+	case recipe.MealInstructions == "":
+		return fmt.Errorf("%w: mealInstructions is required", ErrInvalidRecipe)
+	case len(recipe.MealIngredient) == 0:
+		return fmt.Errorf("%w: at least one ingredient is required", ErrInvalidRecipe)
+	}
+	return nil
+}
+
+// normalizeTags writes tags the way upstream meals do: comma separated with
+// no spaces
+func normalizeTags(tags string) string {
+	var out []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			out = append(out, tag)
+		}
+	}
+	return strings.Join(out, ",")
+}
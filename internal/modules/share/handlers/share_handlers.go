@@ -0,0 +1,320 @@
+package share_handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	planner_services "github.com/JonathanTriC/nomie-api/internal/modules/planner/services"
+	share_models "github.com/JonathanTriC/nomie-api/internal/modules/share/models"
+	share_services "github.com/JonathanTriC/nomie-api/internal/modules/share/services"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// sharedPath is where a share link opens without an account
+const sharedPath = "/v1/shared/"
+
+type Handler struct {
+	service share_services.Service
+}
+
+func NewHandler(service share_services.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetLinks(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	links, err := h.service.GetLinks(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range links {
+		setLinkURLs(c, &links[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+func (h *Handler) CreateLink(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	var req share_models.ShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := h.service.CreateLink(userID, req)
+	if err != nil {
+		h.shareError(c, err)
+		return
+	}
+	setLinkURLs(c, link)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Share link created successfully",
+		"link":    link,
+	})
+}
+
+func (h *Handler) RevokeLink(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	if err := h.service.RevokeLink(userID, c.Param("linkId")); err != nil {
+		h.shareError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked successfully"})
+}
+
+// ShareCollection shares one of the user's collections with a link that does
+// not expire
+func (h *Handler) ShareCollection(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	collectionID, err := strconv.Atoi(c.Param("collectionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "collectionId must be a number"})
+		return
+	}
+
+	link, err := h.service.ShareCollection(userID, collectionID)
+	if err != nil {
+		h.shareError(c, err)
+		return
+	}
+	setLinkURLs(c, link)
+
+	c.JSON(http.StatusOK, gin.H{
+		"shareToken": link.Token,
+		"sharePath":  sharedPath + link.Token,
+		"link":       link,
+	})
+}
+
+// UnshareCollection revokes every link to one of the user's collections
+func (h *Handler) UnshareCollection(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	collectionID, err := strconv.Atoi(c.Param("collectionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "collectionId must be a number"})
+		return
+	}
+
+	if err := h.service.UnshareCollection(userID, collectionID); err != nil {
+		h.shareError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection is no longer shared"})
+}
+
+// GetLegacyCollection opens a collection shared by its old token
+func (h *Handler) GetLegacyCollection(c *gin.Context) {
+	limit, page := pageQuery(c)
+
+	result, err := h.service.GetLegacyCollection(c.Param("token"), limit, page)
+	if err != nil {
+		h.shareError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetShared shows what a share link points at to anyone with the link
+func (h *Handler) GetShared(c *gin.Context) {
+	limit, page := pageQuery(c)
+
+	result, err := h.service.GetShared(c.Param("token"), limit, page)
+	if err != nil {
+		h.shareError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPreview serves the page to paste into chats: its Open Graph and Twitter
+// card tags give the link a title, description and thumbnail when unfurled
+func (h *Handler) GetPreview(c *gin.Context) {
+	token := c.Param("token")
+	status := http.StatusOK
+	preview, err := h.service.GetPreview(token)
+	if err != nil {
+		status = previewStatus(err)
+		if status == http.StatusInternalServerError {
+			logger.ErrorLogger.Printf("share link preview: %v", err)
+		}
+		preview = &share_models.Preview{
+			Title:       "This link is no longer available",
+			Description: "What was shared with you on Nomie may have been removed, or the link has expired.",
+		}
+	}
+
+	page := previewPage{
+		Preview: *preview,
+		URL:     baseURL(c) + sharedPath + token + "/preview",
+	}
+	if appURL := strings.TrimSuffix(utils.GetEnv("SHARE_APP_URL", ""), "/"); appURL != "" && status == http.StatusOK {
+		page.AppURL = appURL + "/shared/" + token
+	}
+	page.Card = "summary"
+	if page.ImageURL != "" {
+		page.Card = "summary_large_image"
+	}
+
+	var body bytes.Buffer
+	if err := previewTemplate.Execute(&body, page); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", body.Bytes())
+}
+
+func (h *Handler) shareError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, share_services.ErrLinkNotFound),
+		errors.Is(err, meals_services.ErrMealNotFound),
+		errors.Is(err, meals_services.ErrCollectionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, share_services.ErrLinkExpired),
+		errors.Is(err, share_services.ErrLinkRevoked):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, share_services.ErrInvalidTarget),
+		errors.Is(err, planner_services.ErrInvalidDate),
+		errors.Is(err, planner_services.ErrInvalidRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// previewStatus is the status a preview page is served with when its link
+// does not open
+func previewStatus(err error) int {
+	switch {
+	case errors.Is(err, share_services.ErrLinkExpired),
+		errors.Is(err, share_services.ErrLinkRevoked):
+		return http.StatusGone
+	case errors.Is(err, share_services.ErrLinkNotFound),
+		errors.Is(err, meals_services.ErrMealNotFound),
+		errors.Is(err, meals_services.ErrCollectionNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func pageQuery(c *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	return limit, page
+}
+
+// setLinkURLs fills in the absolute URLs a link opens at
+func setLinkURLs(c *gin.Context, link *share_models.ShareLink) {
+	link.URL = baseURL(c) + sharedPath + link.Token
+	link.PreviewURL = link.URL + "/preview"
+}
+
+// baseURL is PUBLIC_BASE_URL, or the scheme and host the request came in on
+func baseURL(c *gin.Context) string {
+	if base := utils.GetEnv("PUBLIC_BASE_URL", ""); base != "" {
+		return strings.TrimSuffix(base, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+type previewPage struct {
+	share_models.Preview
+	URL    string
+	AppURL string
+	Card   string
+}
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · Nomie</title>
+<meta name="description" content="{{.Description}}">
+<meta property="og:site_name" content="Nomie">
+<meta property="og:type" content="{{if eq .Kind "meal"}}article{{else}}website{{end}}">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.URL}}">
+{{if .ImageURL}}<meta property="og:image" content="{{.ImageURL}}">
+<meta property="og:image:alt" content="{{.Title}}">
+{{end}}<meta name="twitter:card" content="{{.Card}}">
+<meta name="twitter:title" content="{{.Title}}">
+<meta name="twitter:description" content="{{.Description}}">
+{{if .ImageURL}}<meta name="twitter:image" content="{{.ImageURL}}">
+{{end}}<meta name="robots" content="noindex">
+<style>
+body { margin: 0; padding: 40px 16px; background: #faf7f2; color: #222; font: 16px/1.5 "Helvetica Neue", Arial, sans-serif; }
+main { max-width: 480px; margin: 0 auto; overflow: hidden; background: #fff; border-radius: 12px; box-shadow: 0 2px 12px rgba(0, 0, 0, .08); }
+img { display: block; width: 100%; max-height: 320px; object-fit: cover; }
+div { padding: 20px 24px 24px; }
+h1 { margin: 0 0 8px; font-size: 24px; line-height: 1.25; }
+p { margin: 0 0 16px; color: #555; }
+a { display: inline-block; padding: 10px 18px; border-radius: 8px; background: #e4572e; color: #fff; font-weight: 600; text-decoration: none; }
+</style>
+</head>
+<body>
+<main>
+{{if .ImageURL}}<img src="{{.ImageURL}}" alt="{{.Title}}">{{end}}
+<div>
+<h1>{{.Title}}</h1>
+<p>{{.Description}}</p>
+{{if .AppURL}}<a href="{{.AppURL}}">Open in Nomie</a>{{end}}
+</div>
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,71 @@
+package share_models
+
+import "time"
+
+// What a share link points at
+const (
+	KindMeal       = "meal"
+	KindCollection = "collection"
+	KindPlan       = "plan"
+)
+
+// Whether a share link still opens
+const (
+	StatusActive  = "active"
+	StatusExpired = "expired"
+	StatusRevoked = "revoked"
+)
+
+// ShareLink is a public, read-only link to something a user shared. Target
+// is a meal ID, a collection ID or a plan range as "from/to".
+type ShareLink struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"-"`
+	Kind      string     `json:"kind"`
+	Target    string     `json:"target"`
+	Title     string     `json:"title"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Status    string     `json:"status"`
+	// Legacy links are collection share tokens from before share links. They
+	// open unsigned, at the old collection path, until they expire.
+	Legacy bool `json:"legacy,omitempty"`
+	// Token opens the link; URL is its JSON view and PreviewURL the page to
+	// paste into chats, which carries the link preview tags
+	Token      string `json:"token"`
+	URL        string `json:"url"`
+	PreviewURL string `json:"previewUrl"`
+}
+
+// StatusAt reports whether the link is active, expired or revoked at now
+func (l *ShareLink) StatusAt(now time.Time) string {
+	switch {
+	case l.RevokedAt != nil:
+		return StatusRevoked
+	case l.ExpiresAt != nil && !now.Before(*l.ExpiresAt):
+		return StatusExpired
+	}
+	return StatusActive
+}
+
+// ShareLinkRequest names what to share. Collections are given by ID and
+// plans by the range From to To, which defaults to the week from From.
+type ShareLinkRequest struct {
+	Kind         string `json:"kind" binding:"required,oneof=meal collection plan"`
+	MealID       string `json:"mealId"`
+	CollectionID int    `json:"collectionId"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	// ExpiresInDays limits how long the link opens; without it the link
+	// works until revoked
+	ExpiresInDays int `json:"expiresInDays" binding:"omitempty,min=1,max=365"`
+}
+
+// Preview is what a link preview shows of a shared item
+type Preview struct {
+	Kind        string
+	Title       string
+	Description string
+	ImageURL    string
+}
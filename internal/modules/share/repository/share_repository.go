@@ -0,0 +1,121 @@
+package share_repository
+
+import (
+	"database/sql"
+
+	"github.com/JonathanTriC/nomie-api/internal/database"
+	share_models "github.com/JonathanTriC/nomie-api/internal/modules/share/models"
+)
+
+// maxLinks is how many of a user's links are listed, newest first
+const maxLinks = 200
+
+type Repository interface {
+	GetLink(linkID string) (*share_models.ShareLink, error)
+	GetLinks(userID string) ([]share_models.ShareLink, error)
+	CreateLink(link *share_models.ShareLink) error
+	RevokeLink(linkID string) error
+	GetTargetLinks(userID, kind, target string) ([]share_models.ShareLink, error)
+	RevokeTargetLinks(userID, kind, target string) error
+}
+
+type repository struct {
+	db *database.Database
+}
+
+func NewRepository(db *database.Database) Repository {
+	return &repository{db: db}
+}
+
+// linkColumns is the column list scanned by scanLink
+const linkColumns = `id, user_id, kind, target, title, expires_at, revoked_at, created_at, legacy`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLink(row rowScanner) (*share_models.ShareLink, error) {
+	var l share_models.ShareLink
+	if err := row.Scan(&l.ID, &l.UserID, &l.Kind, &l.Target, &l.Title, &l.ExpiresAt, &l.RevokedAt, &l.CreatedAt, &l.Legacy); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *repository) GetLink(linkID string) (*share_models.ShareLink, error) {
+	link, err := scanLink(r.db.QueryRow(`
+		SELECT `+linkColumns+`
+		FROM share_links
+		WHERE id = $1
+	`, linkID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return link, err
+}
+
+func (r *repository) GetLinks(userID string) ([]share_models.ShareLink, error) {
+	return r.queryLinks(`
+		SELECT `+linkColumns+`
+		FROM share_links
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, maxLinks)
+}
+
+// GetTargetLinks lists the user's links to one item, newest first
+func (r *repository) GetTargetLinks(userID, kind, target string) ([]share_models.ShareLink, error) {
+	return r.queryLinks(`
+		SELECT `+linkColumns+`
+		FROM share_links
+		WHERE user_id = $1 AND kind = $2 AND target = $3
+		ORDER BY created_at DESC
+	`, userID, kind, target)
+}
+
+func (r *repository) queryLinks(query string, args ...interface{}) ([]share_models.ShareLink, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []share_models.ShareLink{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
+
+func (r *repository) CreateLink(link *share_models.ShareLink) error {
+	return r.db.QueryRow(`
+		INSERT INTO share_links (id, user_id, kind, target, title, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`, link.ID, link.UserID, link.Kind, link.Target, link.Title, link.ExpiresAt).Scan(&link.CreatedAt)
+}
+
+// RevokeLink stops a link from opening. Revoking twice keeps the first time.
+func (r *repository) RevokeLink(linkID string) error {
+	_, err := r.db.Exec(`
+		UPDATE share_links
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1
+	`, linkID)
+	return err
+}
+
+// RevokeTargetLinks revokes every link the user made to one item
+func (r *repository) RevokeTargetLinks(userID, kind, target string) error {
+	_, err := r.db.Exec(`
+		UPDATE share_links
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND kind = $2 AND target = $3 AND revoked_at IS NULL
+	`, userID, kind, target)
+	return err
+}
@@ -0,0 +1,67 @@
+package share_routes
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/JonathanTriC/nomie-api/internal/database"
+	auth_middleware "github.com/JonathanTriC/nomie-api/internal/modules/auth/middleware"
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	planner_services "github.com/JonathanTriC/nomie-api/internal/modules/planner/services"
+	share_handlers "github.com/JonathanTriC/nomie-api/internal/modules/share/handlers"
+	share_repository "github.com/JonathanTriC/nomie-api/internal/modules/share/repository"
+	share_services "github.com/JonathanTriC/nomie-api/internal/modules/share/services"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+)
+
+// RegisterRoutes reads shared meals, collections and plans through the shared
+// meals and planner services
+func RegisterRoutes(r *gin.Engine, db *database.Database, meals meals_services.Service, planner planner_services.Service, jwtSecret string) {
+	// Links are signed with SHARE_LINK_SECRET; rotating it revokes them all
+	repo := share_repository.NewRepository(db)
+	service := share_services.NewService(repo, meals, planner, linkSecret(jwtSecret))
+	handler := share_handlers.NewHandler(service)
+
+	shareGroup := r.Group("/v1/share-links")
+	{
+		protected := shareGroup.Group("")
+		protected.Use(auth_middleware.AuthMiddleware([]byte(jwtSecret)))
+		{
+			// MARK: Your Share Links
+			protected.GET("", handler.GetLinks)
+			protected.POST("", handler.CreateLink)
+			protected.DELETE("/:linkId", handler.RevokeLink)
+		}
+	}
+
+	// Collections are still shared from the collection itself, as share links
+	collectionGroup := r.Group("/v1/meals/collections")
+	{
+		protected := collectionGroup.Group("")
+		protected.Use(auth_middleware.AuthMiddleware([]byte(jwtSecret)))
+		{
+			protected.POST("/:collectionId/share", handler.ShareCollection)
+			protected.DELETE("/:collectionId/share", handler.UnshareCollection)
+		}
+	}
+
+	// Share links open without an account
+	r.GET("/v1/shared/:token", handler.GetShared)
+	r.GET("/v1/shared/:token/preview", handler.GetPreview)
+	// Collections shared before share links, until their links expire
+	r.GET("/v1/shared/collections/:token", handler.GetLegacyCollection)
+}
+
+// linkSecret is SHARE_LINK_SECRET or, without one, a key derived from the JWT
+// secret, so share links and sessions are never signed with the same key
+func linkSecret(jwtSecret string) string {
+	if secret := utils.GetEnv("SHARE_LINK_SECRET", ""); secret != "" {
+		return secret
+	}
+	mac := hmac.New(sha256.New, []byte(jwtSecret))
+	mac.Write([]byte("share-links"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,390 @@
+package share_services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	planner_models "github.com/JonathanTriC/nomie-api/internal/modules/planner/models"
+	planner_services "github.com/JonathanTriC/nomie-api/internal/modules/planner/services"
+	share_models "github.com/JonathanTriC/nomie-api/internal/modules/share/models"
+	share_repository "github.com/JonathanTriC/nomie-api/internal/modules/share/repository"
+)
+
+var (
+	ErrLinkNotFound  = errors.New("share link not found")
+	ErrLinkExpired   = errors.New("share link has expired")
+	ErrLinkRevoked   = errors.New("share link has been revoked")
+	ErrInvalidTarget = errors.New("invalid share target")
+)
+
+type Service interface {
+	CreateLink(userID string, req share_models.ShareLinkRequest) (*share_models.ShareLink, error)
+	GetLinks(userID string) ([]share_models.ShareLink, error)
+	RevokeLink(userID, linkID string) error
+	GetShared(token string, limit, page int) (map[string]interface{}, error)
+	GetPreview(token string) (*share_models.Preview, error)
+	ShareCollection(userID string, collectionID int) (*share_models.ShareLink, error)
+	UnshareCollection(userID string, collectionID int) error
+	GetLegacyCollection(token string, limit, page int) (map[string]interface{}, error)
+}
+
+type service struct {
+	repo    share_repository.Repository
+	meals   meals_services.Service
+	planner planner_services.Service
+	secret  []byte
+}
+
+// NewService signs links with secret. Changing it invalidates every link
+// handed out before.
+func NewService(repo share_repository.Repository, meals meals_services.Service, planner planner_services.Service, secret string) Service {
+	return &service{repo: repo, meals: meals, planner: planner, secret: []byte(secret)}
+}
+
+// CreateLink shares a meal the user can see, one of their collections or a
+// range of their meal plan
+func (s *service) CreateLink(userID string, req share_models.ShareLinkRequest) (*share_models.ShareLink, error) {
+	link := &share_models.ShareLink{UserID: userID, Kind: req.Kind}
+
+	switch req.Kind {
+	case share_models.KindMeal:
+		if strings.TrimSpace(req.MealID) == "" {
+			return nil, fmt.Errorf("%w: mealId is required", ErrInvalidTarget)
+		}
+		meal, err := s.meals.LookupMeal(userID, strings.TrimSpace(req.MealID))
+		if err != nil {
+			return nil, err
+		}
+		link.Target, link.Title = meal.MealID, meal.MealName
+	case share_models.KindCollection:
+		if req.CollectionID <= 0 {
+			return nil, fmt.Errorf("%w: collectionId is required", ErrInvalidTarget)
+		}
+		result, err := s.meals.ViewCollection(userID, req.CollectionID, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		link.Target = strconv.Itoa(req.CollectionID)
+		link.Title = result["collection"].(*meals_models.FavouriteCollection).Name
+	case share_models.KindPlan:
+		days, err := s.planner.GetPlan(userID, req.From, req.To)
+		if err != nil {
+			return nil, err
+		}
+		from, to := days[0].Date, days[len(days)-1].Date
+		link.Target = from + "/" + to
+		link.Title = planTitle(from, to)
+	default:
+		return nil, fmt.Errorf("%w: kind must be one of meal, collection, plan", ErrInvalidTarget)
+	}
+
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays).UTC().Truncate(time.Second)
+		link.ExpiresAt = &expiresAt
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	link.ID = id
+
+	if err := s.repo.CreateLink(link); err != nil {
+		return nil, err
+	}
+	s.decorate(link)
+	return link, nil
+}
+
+// GetLinks lists the user's links, including expired and revoked ones
+func (s *service) GetLinks(userID string) ([]share_models.ShareLink, error) {
+	links, err := s.repo.GetLinks(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		s.decorate(&links[i])
+	}
+	return links, nil
+}
+
+// RevokeLink stops one of the user's links from opening. Other users' links
+// are reported as not found.
+func (s *service) RevokeLink(userID, linkID string) error {
+	link, err := s.repo.GetLink(linkID)
+	if err != nil {
+		return err
+	}
+	if link == nil || link.UserID != userID {
+		return ErrLinkNotFound
+	}
+	return s.repo.RevokeLink(linkID)
+}
+
+// ShareCollection returns a link to one of the user's collections that does
+// not expire, reusing the newest one if the collection is already shared
+func (s *service) ShareCollection(userID string, collectionID int) (*share_models.ShareLink, error) {
+	if _, err := s.meals.ViewCollection(userID, collectionID, 1, 1); err != nil {
+		return nil, err
+	}
+
+	links, err := s.repo.GetTargetLinks(userID, share_models.KindCollection, strconv.Itoa(collectionID))
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		link := &links[i]
+		if !link.Legacy && link.ExpiresAt == nil && link.StatusAt(time.Now()) == share_models.StatusActive {
+			s.decorate(link)
+			return link, nil
+		}
+	}
+
+	return s.CreateLink(userID, share_models.ShareLinkRequest{Kind: share_models.KindCollection, CollectionID: collectionID})
+}
+
+// UnshareCollection revokes every link to one of the user's collections,
+// however it was shared
+func (s *service) UnshareCollection(userID string, collectionID int) error {
+	if _, err := s.meals.ViewCollection(userID, collectionID, 1, 1); err != nil {
+		return err
+	}
+	return s.repo.RevokeTargetLinks(userID, share_models.KindCollection, strconv.Itoa(collectionID))
+}
+
+// GetLegacyCollection opens a collection shared before share links by its old
+// token. Signed links do not open this way.
+func (s *service) GetLegacyCollection(token string, limit, page int) (map[string]interface{}, error) {
+	link, err := s.repo.GetLink(token)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || !link.Legacy || link.Kind != share_models.KindCollection {
+		return nil, ErrLinkNotFound
+	}
+	if err := linkStatusError(link); err != nil {
+		return nil, err
+	}
+	return s.sharedCollection(link, limit, page)
+}
+
+// GetShared returns the read-only view a link opens. It shows the item as it
+// is now, under its current name, so a meal or collection deleted since is
+// not found.
+func (s *service) GetShared(token string, limit, page int) (map[string]interface{}, error) {
+	link, err := s.openLink(token)
+	if err != nil {
+		return nil, err
+	}
+
+	title := link.Title
+	var result map[string]interface{}
+	switch link.Kind {
+	case share_models.KindMeal:
+		meal, err := s.sharedMeal(link)
+		if err != nil {
+			return nil, err
+		}
+		title = meal.MealName
+		result = map[string]interface{}{"meal": meal}
+	case share_models.KindCollection:
+		if result, err = s.sharedCollection(link, limit, page); err != nil {
+			return nil, err
+		}
+		title = result["collection"].(*meals_models.FavouriteCollection).Name
+	case share_models.KindPlan:
+		days, err := s.sharedPlan(link)
+		if err != nil {
+			return nil, err
+		}
+		result = map[string]interface{}{"days": days}
+	default:
+		return nil, ErrLinkNotFound
+	}
+
+	result["kind"] = link.Kind
+	result["title"] = title
+	result["expiresAt"] = link.ExpiresAt
+	return result, nil
+}
+
+// GetPreview returns what a chat app's link preview shows for a link
+func (s *service) GetPreview(token string) (*share_models.Preview, error) {
+	link, err := s.openLink(token)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &share_models.Preview{Kind: link.Kind, Title: link.Title}
+	switch link.Kind {
+	case share_models.KindMeal:
+		meal, err := s.sharedMeal(link)
+		if err != nil {
+			return nil, err
+		}
+		preview.Title = meal.MealName
+		preview.ImageURL = meal.MealThumbImage
+		preview.Description = mealDescription(meal)
+	case share_models.KindCollection:
+		result, err := s.sharedCollection(link, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		collection := result["collection"].(*meals_models.FavouriteCollection)
+		preview.Title = collection.Name
+		preview.ImageURL = collection.CoverImage
+		preview.Description = fmt.Sprintf("A collection of %d %s on Nomie", collection.MealCount, plural(collection.MealCount, "meal", "meals"))
+	case share_models.KindPlan:
+		days, err := s.sharedPlan(link)
+		if err != nil {
+			return nil, err
+		}
+		count := 0
+		for _, day := range days {
+			for _, entry := range [...]*planner_models.PlanEntry{day.Breakfast, day.Lunch, day.Dinner} {
+				if entry == nil {
+					continue
+				}
+				if preview.ImageURL == "" {
+					preview.ImageURL = entry.MealThumbImage
+				}
+				count++
+			}
+		}
+		preview.Description = fmt.Sprintf("%d %s planned over %d %s on Nomie",
+			count, plural(count, "meal", "meals"), len(days), plural(len(days), "day", "days"))
+	default:
+		return nil, ErrLinkNotFound
+	}
+	return preview, nil
+}
+
+// sharedMeal looks the meal up as the person who shared it, so a link opens
+// what its sharer can see: a user recipe made private since keeps opening
+// only on links its author made
+func (s *service) sharedMeal(link *share_models.ShareLink) (*meals_models.Meal, error) {
+	meal, err := s.meals.LookupMeal(link.UserID, link.Target)
+	if err != nil {
+		return nil, err
+	}
+	// Whether the sharer favourited it is theirs to know
+	meal.IsFavourite = false
+	return meal, nil
+}
+
+func (s *service) sharedCollection(link *share_models.ShareLink, limit, page int) (map[string]interface{}, error) {
+	collectionID, err := strconv.Atoi(link.Target)
+	if err != nil {
+		return nil, ErrLinkNotFound
+	}
+	return s.meals.ViewCollection(link.UserID, collectionID, limit, page)
+}
+
+func (s *service) sharedPlan(link *share_models.ShareLink) ([]planner_models.PlanDay, error) {
+	from, to, ok := strings.Cut(link.Target, "/")
+	if !ok {
+		return nil, ErrLinkNotFound
+	}
+	return s.planner.GetPlan(link.UserID, from, to)
+}
+
+// openLink checks a token's signature and that its link is still active
+func (s *service) openLink(token string) (*share_models.ShareLink, error) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return nil, ErrLinkNotFound
+	}
+	link, err := s.repo.GetLink(id)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || !hmac.Equal([]byte(signature), []byte(s.sign(link))) {
+		return nil, ErrLinkNotFound
+	}
+	if err := linkStatusError(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// linkStatusError reports why a link no longer opens, if it does not
+func linkStatusError(link *share_models.ShareLink) error {
+	switch link.StatusAt(time.Now()) {
+	case share_models.StatusRevoked:
+		return ErrLinkRevoked
+	case share_models.StatusExpired:
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+// decorate sets the link's token and status
+func (s *service) decorate(link *share_models.ShareLink) {
+	link.Token = link.ID + "." + s.sign(link)
+	link.Status = link.StatusAt(time.Now())
+}
+
+// sign returns the signature of everything a link grants access to, so a
+// token only opens the link it was issued for
+func (s *service) sign(link *share_models.ShareLink) string {
+	expires := ""
+	if link.ExpiresAt != nil {
+		expires = strconv.FormatInt(link.ExpiresAt.Unix(), 10)
+	}
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strings.Join([]string{link.ID, link.UserID, link.Kind, link.Target, expires}, "\n")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func randomID() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// planTitle names a plan range, e.g. "Meal plan, 19 Oct – 25 Oct 2026"
+func planTitle(from, to string) string {
+	start, err1 := time.Parse("2006-01-02", from)
+	end, err2 := time.Parse("2006-01-02", to)
+	if err1 != nil || err2 != nil {
+		return "Meal plan"
+	}
+	if start.Equal(end) {
+		return "Meal plan, " + start.Format("2 Jan 2006")
+	}
+	if start.Year() != end.Year() {
+		return "Meal plan, " + start.Format("2 Jan 2006") + " – " + end.Format("2 Jan 2006")
+	}
+	return "Meal plan, " + start.Format("2 Jan") + " – " + end.Format("2 Jan 2006")
+}
+
+// mealDescription sums a meal up as "British Beef recipe with 9 ingredients"
+func mealDescription(meal *meals_models.Meal) string {
+	kind := strings.TrimSpace(meal.MealArea + " " + meal.MealCategory)
+	if kind == "" {
+		kind = "A"
+	}
+	count := len(meal.MealIngredient)
+	if count == 0 {
+		return kind + " recipe on Nomie"
+	}
+	return fmt.Sprintf("%s recipe with %d %s on Nomie", kind, count, plural(count, "ingredient", "ingredients"))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,205 @@
+package share_services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	share_models "github.com/JonathanTriC/nomie-api/internal/modules/share/models"
+	share_repository "github.com/JonathanTriC/nomie-api/internal/modules/share/repository"
+)
+
+// fakeRepo keeps share links in memory
+type fakeRepo struct {
+	share_repository.Repository
+	links map[string]*share_models.ShareLink
+}
+
+func (r *fakeRepo) GetLink(linkID string) (*share_models.ShareLink, error) {
+	link, ok := r.links[linkID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *link
+	return &copied, nil
+}
+
+func (r *fakeRepo) CreateLink(link *share_models.ShareLink) error {
+	copied := *link
+	r.links[link.ID] = &copied
+	return nil
+}
+
+func (r *fakeRepo) RevokeLink(linkID string) error {
+	now := time.Now()
+	r.links[linkID].RevokedAt = &now
+	return nil
+}
+
+// fakeMeals serves the meals and collections of user 1
+type fakeMeals struct {
+	meals_services.Service
+	meals       map[string]*meals_models.Meal
+	collections map[int]*meals_models.FavouriteCollection
+}
+
+func (m *fakeMeals) LookupMeal(userID, mealID string) (*meals_models.Meal, error) {
+	meal, ok := m.meals[mealID]
+	if !ok {
+		return nil, meals_services.ErrMealNotFound
+	}
+	copied := *meal
+	return &copied, nil
+}
+
+func (m *fakeMeals) ViewCollection(ownerID string, collectionID, limit, page int) (map[string]interface{}, error) {
+	collection, ok := m.collections[collectionID]
+	if !ok || ownerID != "1" {
+		return nil, meals_services.ErrCollectionNotFound
+	}
+	copied := *collection
+	return map[string]interface{}{"collection": &copied, "meals": []*meals_models.Meal{}}, nil
+}
+
+func newTestService() (*service, *fakeRepo, *fakeMeals) {
+	repo := &fakeRepo{links: map[string]*share_models.ShareLink{}}
+	meals := &fakeMeals{
+		meals: map[string]*meals_models.Meal{
+			"52772": {MealID: "52772", MealName: "Teriyaki Chicken Casserole"},
+		},
+		collections: map[int]*meals_models.FavouriteCollection{
+			7: {ID: 7, Name: "Weeknights"},
+			8: {ID: 8, Name: "Private"},
+		},
+	}
+	return &service{repo: repo, meals: meals, secret: []byte("test secret")}, repo, meals
+}
+
+func createLink(t *testing.T, s *service, req share_models.ShareLinkRequest) *share_models.ShareLink {
+	t.Helper()
+	link, err := s.CreateLink("1", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link
+}
+
+func TestGetSharedChecksTokens(t *testing.T) {
+	s, repo, _ := newTestService()
+	weeknights := createLink(t, s, share_models.ShareLinkRequest{Kind: share_models.KindCollection, CollectionID: 7})
+	private := createLink(t, s, share_models.ShareLinkRequest{Kind: share_models.KindCollection, CollectionID: 8})
+	meal := createLink(t, s, share_models.ShareLinkRequest{Kind: share_models.KindMeal, MealID: "52772"})
+
+	expired := createLink(t, s, share_models.ShareLinkRequest{Kind: share_models.KindMeal, MealID: "52772", ExpiresInDays: 1})
+	past := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	repo.links[expired.ID].ExpiresAt = &past
+	s.decorate(repo.links[expired.ID])
+	expiredToken := repo.links[expired.ID].Token
+
+	revoked := createLink(t, s, share_models.ShareLinkRequest{Kind: share_models.KindMeal, MealID: "52772"})
+	if err := s.RevokeLink("1", revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pointing a stored link at another collection breaks its signature
+	retargeted := createLink(t, s, share_models.ShareLinkRequest{Kind: share_models.KindCollection, CollectionID: 7})
+	repo.links[retargeted.ID].Target = "8"
+
+	repo.links["legacy-token"] = &share_models.ShareLink{
+		ID: "legacy-token", UserID: "1", Kind: share_models.KindCollection, Target: "7", Legacy: true,
+	}
+
+	tampered := []byte(weeknights.Token)
+	if last := len(tampered) - 1; tampered[last] == 'A' {
+		tampered[last] = 'B'
+	} else {
+		tampered[last] = 'A'
+	}
+	_, weeknightsSignature, _ := strings.Cut(weeknights.Token, ".")
+	_, mealSignature, _ := strings.Cut(meal.Token, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", weeknights.Token, nil},
+		{"tampered signature", string(tampered), ErrLinkNotFound},
+		{"signature of another link", private.ID + "." + weeknightsSignature, ErrLinkNotFound},
+		{"signature of another kind", weeknights.ID + "." + mealSignature, ErrLinkNotFound},
+		{"changed target", retargeted.Token, ErrLinkNotFound},
+		{"no signature", weeknights.ID, ErrLinkNotFound},
+		{"expired", expiredToken, ErrLinkExpired},
+		{"revoked", revoked.Token, ErrLinkRevoked},
+		{"legacy token", "legacy-token", ErrLinkNotFound},
+		{"legacy token with a dot", "legacy-token.", ErrLinkNotFound},
+		{"unknown", "missing.signature", ErrLinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.GetShared(tt.token, 10, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetShared() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetLegacyCollection(t *testing.T) {
+	s, repo, _ := newTestService()
+	signed := createLink(t, s, share_models.ShareLinkRequest{Kind: share_models.KindCollection, CollectionID: 7})
+	repo.links["legacy-token"] = &share_models.ShareLink{
+		ID: "legacy-token", UserID: "1", Kind: share_models.KindCollection, Target: "7", Legacy: true,
+	}
+
+	if _, err := s.GetLegacyCollection("legacy-token", 10, 1); err != nil {
+		t.Errorf("legacy token: %v", err)
+	}
+	for _, token := range []string{signed.ID, signed.Token} {
+		if _, err := s.GetLegacyCollection(token, 10, 1); !errors.Is(err, ErrLinkNotFound) {
+			t.Errorf("GetLegacyCollection(%q) error = %v, want %v", token, err, ErrLinkNotFound)
+		}
+	}
+}
+
+func TestSharedTitleIsLive(t *testing.T) {
+	s, _, meals := newTestService()
+	collection := createLink(t, s, share_models.ShareLinkRequest{Kind: share_models.KindCollection, CollectionID: 7})
+	meal := createLink(t, s, share_models.ShareLinkRequest{Kind: share_models.KindMeal, MealID: "52772"})
+
+	meals.collections[7].Name = "Quick dinners"
+	meals.meals["52772"].MealName = "Teriyaki Chicken"
+
+	for token, want := range map[string]string{collection.Token: "Quick dinners", meal.Token: "Teriyaki Chicken"} {
+		shared, err := s.GetShared(token, 10, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shared["title"] != want {
+			t.Errorf("GetShared() title = %q, want %q", shared["title"], want)
+		}
+		preview, err := s.GetPreview(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if preview.Title != want {
+			t.Errorf("GetPreview() title = %q, want %q", preview.Title, want)
+		}
+	}
+}
+
+func TestRevokeLinkOfAnotherUser(t *testing.T) {
+	s, _, _ := newTestService()
+	link := createLink(t, s, share_models.ShareLinkRequest{Kind: share_models.KindCollection, CollectionID: 7})
+
+	if err := s.RevokeLink("2", link.ID); !errors.Is(err, ErrLinkNotFound) {
+		t.Errorf("RevokeLink() error = %v, want %v", err, ErrLinkNotFound)
+	}
+	if _, err := s.GetShared(link.Token, 10, 1); err != nil {
+		t.Errorf("link stopped opening: %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package shopping_handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	planner_services "github.com/JonathanTriC/nomie-api/internal/modules/planner/services"
+	shopping_models "github.com/JonathanTriC/nomie-api/internal/modules/shopping/models"
+	shopping_services "github.com/JonathanTriC/nomie-api/internal/modules/shopping/services"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service shopping_services.Service
+}
+
+func NewHandler(service shopping_services.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetLists(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	lists, err := h.service.GetLists(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lists": lists})
+}
+
+func (h *Handler) GetList(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	listID, ok := idParam(c, "listId")
+	if !ok {
+		return
+	}
+
+	list, err := h.service.GetList(userID, listID)
+	if err != nil {
+		h.shoppingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"list": list})
+}
+
+func (h *Handler) CreateList(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	var req shopping_models.CreateListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	list, err := h.service.CreateList(userID, name)
+	if err != nil {
+		h.shoppingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Shopping list created successfully",
+		"list":    list,
+	})
+}
+
+func (h *Handler) CreateMealList(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	var req shopping_models.MealListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.service.CreateMealList(userID, strings.TrimSpace(req.MealID), strings.TrimSpace(req.Name), req.Servings)
+	if err != nil {
+		h.shoppingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Shopping list created successfully",
+		"list":    list,
+	})
+}
+
+func (h *Handler) CreatePlanList(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	var req shopping_models.PlanListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.service.CreatePlanList(userID, req.From, req.To, strings.TrimSpace(req.Name))
+	if err != nil {
+		h.shoppingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Shopping list created successfully",
+		"list":    list,
+	})
+}
+
+func (h *Handler) DeleteList(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	listID, ok := idParam(c, "listId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteList(userID, listID); err != nil {
+		h.shoppingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shopping list deleted successfully"})
+}
+
+func (h *Handler) AddItem(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	listID, ok := idParam(c, "listId")
+	if !ok {
+		return
+	}
+
+	var req shopping_models.ManualItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	item, err := h.service.AddItem(userID, listID, name, strings.TrimSpace(req.Measure))
+	if err != nil {
+		h.shoppingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Item added to shopping list",
+		"item":    item,
+	})
+}
+
+func (h *Handler) CheckItem(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	listID, ok := idParam(c, "listId")
+	if !ok {
+		return
+	}
+	itemID, ok := idParam(c, "itemId")
+	if !ok {
+		return
+	}
+
+	var req shopping_models.CheckItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.CheckItem(userID, listID, itemID, *req.IsChecked); err != nil {
+		h.shoppingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item updated successfully"})
+}
+
+func (h *Handler) DeleteItem(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	listID, ok := idParam(c, "listId")
+	if !ok {
+		return
+	}
+	itemID, ok := idParam(c, "itemId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteItem(userID, listID, itemID); err != nil {
+		h.shoppingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item removed from shopping list"})
+}
+
+func (h *Handler) ClearChecked(c *gin.Context) {
+	userIDInt, ok := utils.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := strconv.Itoa(userIDInt)
+
+	listID, ok := idParam(c, "listId")
+	if !ok {
+		return
+	}
+
+	removed, err := h.service.ClearChecked(userID, listID)
+	if err != nil {
+		h.shoppingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Checked items cleared",
+		"removed": removed,
+	})
+}
+
+// shoppingError maps shopping list errors to their HTTP status
+func (h *Handler) shoppingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, shopping_services.ErrListNotFound),
+		errors.Is(err, shopping_services.ErrItemNotFound),
+		errors.Is(err, meals_services.ErrMealNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, shopping_services.ErrEmptyPlan),
+		errors.Is(err, planner_services.ErrInvalidDate),
+		errors.Is(err, planner_services.ErrInvalidRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func idParam(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a number"})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,68 @@
+package shopping_models
+
+import "time"
+
+// Where a shopping list was generated from
+const (
+	SourceMeal   = "meal"
+	SourcePlan   = "plan"
+	SourceManual = "manual"
+)
+
+type ShoppingList struct {
+	ID           int          `json:"id"`
+	UserID       string       `json:"-"`
+	Name         string       `json:"name"`
+	Source       string       `json:"source"`
+	SourceRef    string       `json:"sourceRef,omitempty"`
+	ItemCount    int          `json:"itemCount"`
+	CheckedCount int          `json:"checkedCount"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	UpdatedAt    time.Time    `json:"updatedAt"`
+	Aisles       []AisleGroup `json:"aisles,omitempty"`
+}
+
+type AisleGroup struct {
+	Aisle string         `json:"aisle"`
+	Items []ShoppingItem `json:"items"`
+}
+
+type ShoppingItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Key is the normalized name duplicates are merged on
+	Key   string `json:"-"`
+	Aisle string `json:"aisle"`
+	// Measure is the merged amount to buy, Measures the original measures it
+	// was added up from
+	Measure   string   `json:"measure"`
+	Measures  []string `json:"measures"`
+	MealIDs   []string `json:"mealIds"`
+	IsManual  bool     `json:"isManual"`
+	IsChecked bool     `json:"isChecked"`
+}
+
+type CreateListRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type MealListRequest struct {
+	MealID   string `json:"mealId" binding:"required"`
+	Name     string `json:"name" binding:"max=100"`
+	Servings int    `json:"servings" binding:"omitempty,min=1,max=100"`
+}
+
+type PlanListRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Name string `json:"name" binding:"max=100"`
+}
+
+type ManualItemRequest struct {
+	Name    string `json:"name" binding:"required,max=100"`
+	Measure string `json:"measure" binding:"max=50"`
+}
+
+type CheckItemRequest struct {
+	IsChecked *bool `json:"isChecked" binding:"required"`
+}
@@ -0,0 +1,210 @@
+package shopping_repository
+
+import (
+	"database/sql"
+
+	"github.com/JonathanTriC/nomie-api/internal/database"
+	shopping_models "github.com/JonathanTriC/nomie-api/internal/modules/shopping/models"
+	"github.com/lib/pq"
+)
+
+type Repository interface {
+	GetLists(userID string) ([]shopping_models.ShoppingList, error)
+	GetList(listID int) (*shopping_models.ShoppingList, error)
+	CreateList(list *shopping_models.ShoppingList, items []shopping_models.ShoppingItem) error
+	DeleteList(listID int) error
+	GetItems(listID int) ([]shopping_models.ShoppingItem, error)
+	AddItem(listID int, item *shopping_models.ShoppingItem) error
+	SetItemChecked(listID, itemID int, checked bool) (bool, error)
+	DeleteItem(listID, itemID int) (bool, error)
+	DeleteCheckedItems(listID int) (int, error)
+}
+
+type repository struct {
+	db *database.Database
+}
+
+func NewRepository(db *database.Database) Repository {
+	return &repository{db: db}
+}
+
+// listColumns is the column list scanned by scanList
+const listColumns = `l.id, l.user_id, l.name, l.source, l.source_ref, l.created_at, l.updated_at,
+	(SELECT COUNT(*) FROM shopping_list_items i WHERE i.list_id = l.id),
+	(SELECT COUNT(*) FROM shopping_list_items i WHERE i.list_id = l.id AND i.is_checked)`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanList(row rowScanner) (*shopping_models.ShoppingList, error) {
+	var l shopping_models.ShoppingList
+	if err := row.Scan(&l.ID, &l.UserID, &l.Name, &l.Source, &l.SourceRef, &l.CreatedAt, &l.UpdatedAt, &l.ItemCount, &l.CheckedCount); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *repository) GetLists(userID string) ([]shopping_models.ShoppingList, error) {
+	rows, err := r.db.Query(`
+		SELECT `+listColumns+`
+		FROM shopping_lists l
+		WHERE l.user_id = $1
+		ORDER BY l.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []shopping_models.ShoppingList{}
+	for rows.Next() {
+		l, err := scanList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, *l)
+	}
+	return lists, rows.Err()
+}
+
+func (r *repository) GetList(listID int) (*shopping_models.ShoppingList, error) {
+	l, err := scanList(r.db.QueryRow(`
+		SELECT `+listColumns+`
+		FROM shopping_lists l
+		WHERE l.id = $1
+	`, listID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return l, err
+}
+
+// CreateList saves a list with its items in one transaction
+func (r *repository) CreateList(list *shopping_models.ShoppingList, items []shopping_models.ShoppingItem) error {
+	tx, err := r.db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO shopping_lists (user_id, name, source, source_ref)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, list.UserID, list.Name, list.Source, list.SourceRef).Scan(&list.ID, &list.CreatedAt, &list.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		if err := insertItem(tx, list.ID, &items[i]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func insertItem(db rowQuerier, listID int, item *shopping_models.ShoppingItem) error {
+	return db.QueryRow(`
+		INSERT INTO shopping_list_items (list_id, name, ingredient_key, aisle, measure, measures, meal_ids, is_manual)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, listID, item.Name, item.Key, item.Aisle, item.Measure, pq.Array(item.Measures), pq.Array(item.MealIDs), item.IsManual).
+		Scan(&item.ID)
+}
+
+func (r *repository) DeleteList(listID int) error {
+	_, err := r.db.DB.Exec(`DELETE FROM shopping_lists WHERE id = $1`, listID)
+	return err
+}
+
+func (r *repository) GetItems(listID int) ([]shopping_models.ShoppingItem, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, ingredient_key, aisle, measure, measures, meal_ids, is_manual, is_checked
+		FROM shopping_list_items
+		WHERE list_id = $1
+		ORDER BY name, id
+	`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []shopping_models.ShoppingItem{}
+	for rows.Next() {
+		var item shopping_models.ShoppingItem
+		if err := rows.Scan(&item.ID, &item.Name, &item.Key, &item.Aisle, &item.Measure,
+			pq.Array(&item.Measures), pq.Array(&item.MealIDs), &item.IsManual, &item.IsChecked); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *repository) AddItem(listID int, item *shopping_models.ShoppingItem) error {
+	if err := insertItem(r.db, listID, item); err != nil {
+		return err
+	}
+	return r.touchList(listID)
+}
+
+func (r *repository) SetItemChecked(listID, itemID int, checked bool) (bool, error) {
+	res, err := r.db.DB.Exec(`
+		UPDATE shopping_list_items SET is_checked = $3
+		WHERE list_id = $1 AND id = $2
+	`, listID, itemID, checked)
+	return r.touchIfAffected(listID, res, err)
+}
+
+func (r *repository) DeleteItem(listID, itemID int) (bool, error) {
+	res, err := r.db.DB.Exec(`
+		DELETE FROM shopping_list_items
+		WHERE list_id = $1 AND id = $2
+	`, listID, itemID)
+	return r.touchIfAffected(listID, res, err)
+}
+
+func (r *repository) DeleteCheckedItems(listID int) (int, error) {
+	res, err := r.db.DB.Exec(`
+		DELETE FROM shopping_list_items
+		WHERE list_id = $1 AND is_checked
+	`, listID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		if err := r.touchList(listID); err != nil {
+			return 0, err
+		}
+	}
+	return int(n), nil
+}
+
+func (r *repository) touchIfAffected(listID int, res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+	return true, r.touchList(listID)
+}
+
+func (r *repository) touchList(listID int) error {
+	_, err := r.db.DB.Exec(`UPDATE shopping_lists SET updated_at = NOW() WHERE id = $1`, listID)
+	return err
+}
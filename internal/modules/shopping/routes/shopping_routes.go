@@ -0,0 +1,40 @@
+package shopping_routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/JonathanTriC/nomie-api/internal/database"
+	auth_middleware "github.com/JonathanTriC/nomie-api/internal/modules/auth/middleware"
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	planner_services "github.com/JonathanTriC/nomie-api/internal/modules/planner/services"
+	shopping_handlers "github.com/JonathanTriC/nomie-api/internal/modules/shopping/handlers"
+	shopping_repository "github.com/JonathanTriC/nomie-api/internal/modules/shopping/repository"
+	shopping_services "github.com/JonathanTriC/nomie-api/internal/modules/shopping/services"
+)
+
+// RegisterRoutes builds shopping lists from the shared meals and planner
+// services
+func RegisterRoutes(r *gin.Engine, db *database.Database, meals meals_services.Service, planner planner_services.Service, jwtSecret string) {
+	repo := shopping_repository.NewRepository(db)
+	service := shopping_services.NewService(repo, meals, planner)
+	handler := shopping_handlers.NewHandler(service)
+
+	shoppingGroup := r.Group("/v1/shopping-lists")
+	{
+		protected := shoppingGroup.Group("")
+		protected.Use(auth_middleware.AuthMiddleware([]byte(jwtSecret)))
+		{
+			// MARK: Your Shopping Lists
+			protected.GET("", handler.GetLists)
+			protected.POST("", handler.CreateList)
+			protected.POST("/from-meal", handler.CreateMealList)
+			protected.POST("/from-plan", handler.CreatePlanList)
+			protected.GET("/:listId", handler.GetList)
+			protected.DELETE("/:listId", handler.DeleteList)
+			protected.POST("/:listId/items", handler.AddItem)
+			protected.PUT("/:listId/items/:itemId", handler.CheckItem)
+			protected.DELETE("/:listId/items/:itemId", handler.DeleteItem)
+			protected.DELETE("/:listId/checked", handler.ClearChecked)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package shopping_services
+
+import "strings"
+
+// Aisles in the order a shop is usually walked; items are grouped by them
+const (
+	AisleProduce    = "Produce"
+	AisleMeat       = "Meat & Seafood"
+	AisleDairy      = "Dairy & Eggs"
+	AisleBakery     = "Bakery"
+	AisleGrains     = "Pasta, Rice & Grains"
+	AisleCanned     = "Canned & Jarred"
+	AisleBaking     = "Baking"
+	AisleSpices     = "Spices & Seasonings"
+	AisleCondiments = "Oils, Sauces & Condiments"
+	AisleFrozen     = "Frozen"
+	AisleDrinks     = "Drinks"
+	AisleOther      = "Other"
+)
+
+var aisleOrder = []string{
+	AisleProduce, AisleMeat, AisleDairy, AisleBakery, AisleGrains, AisleCanned,
+	AisleBaking, AisleSpices, AisleCondiments, AisleFrozen, AisleDrinks, AisleOther,
+}
+
+var aisleKeywords = map[string][]string{
+	AisleProduce: {
+		"apple", "apricot", "asparagus", "aubergine", "avocado", "banana", "basil", "bean sprout", "beetroot",
+		"bell pepper", "blackberry", "blueberry", "bok choy", "broccoli", "brussels sprout", "cabbage", "carrot",
+		"cauliflower", "celeriac", "celery", "chard", "cherry", "chilli", "chili", "chive", "coriander", "courgette",
+		"cucumber", "dill", "eggplant", "fennel", "fig", "garlic", "garlic clove", "ginger", "grape", "green bean", "green pepper",
+		"jalapeno", "kale", "leek", "lemon", "lemongrass", "lettuce", "lime", "mango", "mint", "mushroom", "onion",
+		"orange", "pak choi", "parsley", "parsnip", "pea", "peach", "pear", "pineapple", "plum",
+		"potato", "pumpkin", "radish", "raspberry", "red pepper", "rocket", "rosemary", "salad", "scallion", "shallot",
+		"spinach", "spring onion", "squash", "strawberry", "swede", "sweet potato", "sweetcorn", "thyme", "tomato",
+		"turnip", "watercress", "yellow pepper", "zucchini",
+	},
+	AisleMeat: {
+		"anchovy", "bacon", "beef", "brisket", "chicken", "chorizo", "clam", "cod", "crab", "duck", "fish", "gammon",
+		"haddock", "ham", "king prawn", "lamb", "lobster", "mackerel", "mince", "mussel", "oyster", "pancetta",
+		"pork", "prawn", "prosciutto", "salami", "salmon", "sausage", "scallop", "shrimp", "squid", "steak",
+		"tuna", "turkey", "veal", "venison",
+	},
+	AisleDairy: {
+		"butter", "buttermilk", "cheddar", "cheese", "cream", "creme fraiche", "egg", "feta", "ghee", "gruyere",
+		"milk", "mozzarella", "paneer", "parmesan", "ricotta", "sour cream", "yogurt", "yoghurt",
+	},
+	AisleBakery: {
+		"bagel", "baguette", "bread", "brioche", "bun", "ciabatta", "croissant", "naan", "pitta", "pita",
+		"roll", "tortilla", "wrap",
+	},
+	AisleGrains: {
+		"barley", "basmati", "bulgur", "couscous", "egg noodle", "fettuccine", "lasagne", "linguine", "macaroni",
+		"noodle", "oat", "pasta", "penne", "polenta", "quinoa", "rice", "rice noodle", "spaghetti", "tagliatelle",
+	},
+	AisleCanned: {
+		"black bean", "butter bean", "cannellini bean", "chickpea", "coconut cream", "coconut milk", "kidney bean",
+		"lentil", "passata", "stock", "stock cube", "tomato puree", "tomato paste", "chopped tomato", "tinned tomato",
+	},
+	AisleBaking: {
+		"almond", "baking powder", "bicarbonate of soda", "baking soda", "brown sugar", "caster sugar", "chocolate",
+		"cocoa", "cornflour", "cornstarch", "desiccated coconut", "flour", "gelatine", "golden syrup", "hazelnut",
+		"honey", "icing sugar", "muscovado", "peanut", "pecan", "pine nut", "raisin", "sugar", "sultana",
+		"treacle", "vanilla", "vanilla extract", "walnut", "yeast",
+	},
+	AisleSpices: {
+		"allspice", "bay leaf", "black pepper", "cardamom", "cayenne", "chilli flake", "chilli powder", "cinnamon",
+		"clove", "cumin", "curry powder", "dried oregano", "fenugreek", "five spice", "garam masala",
+		"garlic powder", "mixed herb", "mustard seed", "nutmeg", "onion powder", "oregano", "paprika", "pepper",
+		"red pepper flake", "saffron", "salt", "sea salt", "seasoning", "sesame seed", "spice", "star anise", "sumac",
+		"turmeric", "white pepper",
+	},
+	AisleCondiments: {
+		"balsamic", "barbecue sauce", "bbq sauce", "chutney", "fish sauce", "hoisin", "hot sauce", "ketchup", "lemon juice", "lime juice",
+		"mayonnaise", "mustard", "oil", "olive oil", "oyster sauce", "peanut butter", "pesto", "sauce",
+		"sesame oil", "soy sauce", "sriracha", "tahini", "vinegar", "worcestershire sauce",
+	},
+	AisleFrozen: {"frozen", "ice cream", "frozen pea", "puff pastry", "filo pastry", "shortcrust pastry", "pastry"},
+	AisleDrinks: {"beer", "brandy", "cider", "coffee", "juice", "red wine", "rum", "sherry", "tea", "white wine", "wine"},
+}
+
+type aisleKeyword struct {
+	words []string
+	aisle string
+}
+
+var keywordIndex []aisleKeyword
+
+func init() {
+	for aisle, keywords := range aisleKeywords {
+		for _, k := range keywords {
+			keywordIndex = append(keywordIndex, aisleKeyword{words: ingredientWords(k), aisle: aisle})
+		}
+	}
+}
+
+// aisleFor picks the aisle of an ingredient. The longest matching keyword
+// wins so "red pepper" beats "pepper"; between equally long keywords the one
+// nearest the end wins, as the last word names the thing ("chicken stock" is
+// stock, "garlic powder" is a spice).
+func aisleFor(name string) string {
+	words := ingredientWords(name)
+
+	best, bestLen, bestEnd := AisleOther, 0, -1
+	for _, k := range keywordIndex {
+		end := matchWords(words, k.words)
+		if end < 0 {
+			continue
+		}
+		if len(k.words) > bestLen || (len(k.words) == bestLen && end > bestEnd) {
+			best, bestLen, bestEnd = k.aisle, len(k.words), end
+		}
+	}
+	return best
+}
+
+// matchWords returns where the last matched word of keyword is in words, or -1
+func matchWords(words, keyword []string) int {
+	for i := len(words) - len(keyword); i >= 0; i-- {
+		matched := true
+		for j, w := range keyword {
+			if words[i+j] != w {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i + len(keyword) - 1
+		}
+	}
+	return -1
+}
+
+// ingredientWords splits a name into lowercase singular words
+func ingredientWords(name string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	})
+	for i, f := range fields {
+		fields[i] = singular(f)
+	}
+	return fields
+}
+
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 3:
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
@@ -0,0 +1,329 @@
+package shopping_services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	meals_models "github.com/JonathanTriC/nomie-api/internal/modules/meals/models"
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
+	planner_models "github.com/JonathanTriC/nomie-api/internal/modules/planner/models"
+	planner_services "github.com/JonathanTriC/nomie-api/internal/modules/planner/services"
+	shopping_models "github.com/JonathanTriC/nomie-api/internal/modules/shopping/models"
+	shopping_repository "github.com/JonathanTriC/nomie-api/internal/modules/shopping/repository"
+	"github.com/JonathanTriC/nomie-api/internal/utils"
+	"github.com/JonathanTriC/nomie-api/pkg/measure"
+)
+
+var (
+	ErrListNotFound = errors.New("shopping list not found")
+	ErrItemNotFound = errors.New("item not found")
+	ErrEmptyPlan    = errors.New("nothing is planned in this range")
+)
+
+type Service interface {
+	GetLists(userID string) ([]shopping_models.ShoppingList, error)
+	GetList(userID string, listID int) (*shopping_models.ShoppingList, error)
+	CreateList(userID, name string) (*shopping_models.ShoppingList, error)
+	CreateMealList(userID, mealID, name string, servings int) (*shopping_models.ShoppingList, error)
+	CreatePlanList(userID, from, to, name string) (*shopping_models.ShoppingList, error)
+	DeleteList(userID string, listID int) error
+	AddItem(userID string, listID int, name, measureText string) (*shopping_models.ShoppingItem, error)
+	CheckItem(userID string, listID, itemID int, checked bool) error
+	DeleteItem(userID string, listID, itemID int) error
+	ClearChecked(userID string, listID int) (int, error)
+}
+
+type service struct {
+	repo    shopping_repository.Repository
+	meals   meals_services.Service
+	planner planner_services.Service
+}
+
+func NewService(repo shopping_repository.Repository, meals meals_services.Service, planner planner_services.Service) Service {
+	return &service{repo: repo, meals: meals, planner: planner}
+}
+
+func (s *service) GetLists(userID string) ([]shopping_models.ShoppingList, error) {
+	return s.repo.GetLists(userID)
+}
+
+func (s *service) GetList(userID string, listID int) (*shopping_models.ShoppingList, error) {
+	list, err := s.ownList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.GetItems(listID)
+	if err != nil {
+		return nil, err
+	}
+	list.Aisles = groupByAisle(items)
+	return list, nil
+}
+
+func (s *service) CreateList(userID, name string) (*shopping_models.ShoppingList, error) {
+	list := &shopping_models.ShoppingList{UserID: userID, Name: name, Source: shopping_models.SourceManual}
+	if err := s.repo.CreateList(list, nil); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// CreateMealList builds a list from the ingredients of one meal, scaled to
+// servings when set
+func (s *service) CreateMealList(userID, mealID, name string, servings int) (*shopping_models.ShoppingList, error) {
+	meal, err := s.meals.LookupMeal(userID, mealID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.meals.ScaleMeal(meal, servings); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = meal.MealName
+	}
+
+	list := &shopping_models.ShoppingList{
+		UserID:    userID,
+		Name:      name,
+		Source:    shopping_models.SourceMeal,
+		SourceRef: meal.MealID,
+	}
+	return s.saveList(list, []*meals_models.Meal{meal})
+}
+
+// CreatePlanList builds a list from every meal planned in the range, each
+// scaled to the servings it is planned for. A meal planned twice is bought
+// for twice.
+func (s *service) CreatePlanList(userID, from, to, name string) (*shopping_models.ShoppingList, error) {
+	days, err := s.planner.GetPlan(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	looked := map[string]*meals_models.Meal{}
+	var meals []*meals_models.Meal
+	for _, day := range days {
+		for _, entry := range [...]*planner_models.PlanEntry{day.Breakfast, day.Lunch, day.Dinner} {
+			if entry == nil {
+				continue
+			}
+			meal, ok := looked[entry.MealID]
+			if !ok {
+				if meal, err = s.meals.LookupMeal(userID, entry.MealID); err != nil {
+					return nil, err
+				}
+				looked[entry.MealID] = meal
+			}
+
+			scaled := *meal
+			scaled.MealIngredient = append([]meals_models.MealIngredient(nil), meal.MealIngredient...)
+			if err := s.meals.ScaleMeal(&scaled, entry.Servings); err != nil {
+				return nil, err
+			}
+			meals = append(meals, &scaled)
+		}
+	}
+	if len(meals) == 0 {
+		return nil, ErrEmptyPlan
+	}
+
+	first, last := days[0].Date, days[len(days)-1].Date
+	if name == "" {
+		name = fmt.Sprintf("Meal plan %s to %s", first, last)
+	}
+
+	list := &shopping_models.ShoppingList{
+		UserID:    userID,
+		Name:      name,
+		Source:    shopping_models.SourcePlan,
+		SourceRef: first + "/" + last,
+	}
+	return s.saveList(list, meals)
+}
+
+func (s *service) saveList(list *shopping_models.ShoppingList, meals []*meals_models.Meal) (*shopping_models.ShoppingList, error) {
+	items := mergeIngredients(meals)
+	if err := s.repo.CreateList(list, items); err != nil {
+		return nil, err
+	}
+	list.ItemCount = len(items)
+	list.Aisles = groupByAisle(items)
+	return list, nil
+}
+
+func (s *service) DeleteList(userID string, listID int) error {
+	if _, err := s.ownList(userID, listID); err != nil {
+		return err
+	}
+	return s.repo.DeleteList(listID)
+}
+
+// AddItem adds something to buy that is not part of a meal
+func (s *service) AddItem(userID string, listID int, name, measureText string) (*shopping_models.ShoppingItem, error) {
+	if _, err := s.ownList(userID, listID); err != nil {
+		return nil, err
+	}
+
+	item := &shopping_models.ShoppingItem{
+		Name:     name,
+		Key:      ingredientKey(name),
+		Aisle:    aisleFor(name),
+		Measures: []string{},
+		MealIDs:  []string{},
+		IsManual: true,
+	}
+	if measureText != "" {
+		item.Measure = measure.Parse(measureText).String()
+		item.Measures = []string{measureText}
+	}
+
+	if err := s.repo.AddItem(listID, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *service) CheckItem(userID string, listID, itemID int, checked bool) error {
+	if _, err := s.ownList(userID, listID); err != nil {
+		return err
+	}
+	found, err := s.repo.SetItemChecked(listID, itemID, checked)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrItemNotFound
+	}
+	return nil
+}
+
+func (s *service) DeleteItem(userID string, listID, itemID int) error {
+	if _, err := s.ownList(userID, listID); err != nil {
+		return err
+	}
+	found, err := s.repo.DeleteItem(listID, itemID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrItemNotFound
+	}
+	return nil
+}
+
+// ClearChecked removes the items already bought and returns how many
+func (s *service) ClearChecked(userID string, listID int) (int, error) {
+	if _, err := s.ownList(userID, listID); err != nil {
+		return 0, err
+	}
+	return s.repo.DeleteCheckedItems(listID)
+}
+
+// ownList returns the list if it belongs to the user. Someone else's list is
+// reported as not found so IDs cannot be probed.
+func (s *service) ownList(userID string, listID int) (*shopping_models.ShoppingList, error) {
+	list, err := s.repo.GetList(listID)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil || list.UserID != userID {
+		return nil, ErrListNotFound
+	}
+	return list, nil
+}
+
+// mergeIngredients turns the ingredients of the meals into one item per
+// ingredient, adding up the measures that can be parsed. Scaled measures are
+// used when the meal has them.
+func mergeIngredients(meals []*meals_models.Meal) []shopping_models.ShoppingItem {
+	var items []shopping_models.ShoppingItem
+	parsed := map[string][]measure.Measure{}
+	byKey := map[string]int{}
+
+	for _, meal := range meals {
+		for _, ingredient := range meal.MealIngredient {
+			name := strings.TrimSpace(ingredient.IngredientName)
+			key := ingredientKey(name)
+			if key == "" {
+				continue
+			}
+
+			i, ok := byKey[key]
+			if !ok {
+				i = len(items)
+				byKey[key] = i
+				items = append(items, shopping_models.ShoppingItem{
+					Name:     name,
+					Key:      key,
+					Aisle:    aisleFor(name),
+					Measures: []string{},
+					MealIDs:  []string{},
+				})
+			}
+
+			item := &items[i]
+			if text := strings.TrimSpace(ingredient.IngredientMeasure); text != "" {
+				m := measure.Parse(text)
+				if ingredient.Measure != nil {
+					m = *ingredient.Measure
+				}
+				item.Measures = append(item.Measures, text)
+				parsed[key] = append(parsed[key], m)
+			}
+			if !containsString(item.MealIDs, meal.MealID) {
+				item.MealIDs = append(item.MealIDs, meal.MealID)
+			}
+		}
+	}
+
+	for i := range items {
+		var parts []string
+		for _, m := range measure.Sum(parsed[items[i].Key]) {
+			parts = append(parts, m.String())
+		}
+		items[i].Measure = strings.Join(parts, " + ")
+	}
+	return items
+}
+
+// groupByAisle sorts items into aisles in shop order, unchecked items first
+func groupByAisle(items []shopping_models.ShoppingItem) []shopping_models.AisleGroup {
+	byAisle := map[string][]shopping_models.ShoppingItem{}
+	for _, item := range items {
+		byAisle[item.Aisle] = append(byAisle[item.Aisle], item)
+	}
+
+	groups := []shopping_models.AisleGroup{}
+	for _, aisle := range aisleOrder {
+		aisleItems := byAisle[aisle]
+		if len(aisleItems) == 0 {
+			continue
+		}
+		sort.SliceStable(aisleItems, func(i, j int) bool {
+			if aisleItems[i].IsChecked != aisleItems[j].IsChecked {
+				return !aisleItems[i].IsChecked
+			}
+			return strings.ToLower(aisleItems[i].Name) < strings.ToLower(aisleItems[j].Name)
+		})
+		groups = append(groups, shopping_models.AisleGroup{Aisle: aisle, Items: aisleItems})
+	}
+	return groups
+}
+
+// ingredientKey is what duplicate ingredients are merged on: "Onions" and
+// "onion" are the same thing to buy
+func ingredientKey(name string) string {
+	return strings.Join(ingredientWords(utils.NormalizeIngredient(name)), " ")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -2,10 +2,12 @@ package user_handlers
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/JonathanTriC/nomie-api/internal/database"
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
 	"github.com/JonathanTriC/nomie-api/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
@@ -13,13 +15,15 @@ import (
 
 type UserHandler struct {
     db              *database.Database
+    meals           meals_services.Service
     jwtSecret       []byte
     tokenExpiration time.Duration
 }
 
-func NewUserHandler(db *database.Database, jwtSecret []byte) *UserHandler {
+func NewUserHandler(db *database.Database, meals meals_services.Service, jwtSecret []byte) *UserHandler {
     return &UserHandler{
         db:              db,
+        meals:           meals,
         jwtSecret:       jwtSecret,
         tokenExpiration: 24 * time.Hour,
     }
@@ -145,3 +149,201 @@ func (h *UserHandler) GetUserProfile(c *gin.Context) {
         "avatar":   avatar,
     })
 }
+
+// GetPantry lists the ingredients the authenticated user has at home
+func (h *UserHandler) GetPantry(c *gin.Context) {
+    userID, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+
+    rows, err := h.db.DB.Query(`
+        SELECT ingredient_name, created_at
+        FROM user_pantry
+        WHERE user_id = $1
+        ORDER BY ingredient_key
+    `, userID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+        return
+    }
+    defer rows.Close()
+
+    pantry := []gin.H{}
+    for rows.Next() {
+        var name string
+        var addedAt time.Time
+        if err := rows.Scan(&name, &addedAt); err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+            return
+        }
+        pantry = append(pantry, gin.H{
+            "ingredientName": name,
+            "addedAt":        addedAt,
+        })
+    }
+
+    c.JSON(http.StatusOK, gin.H{"pantry": pantry})
+}
+
+// AddPantryItems adds one or more ingredients to the user's pantry
+func (h *UserHandler) AddPantryItems(c *gin.Context) {
+    userID, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+
+    var req struct {
+        Ingredients []string `json:"ingredients" binding:"required,min=1"`
+    }
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "ingredients is required"})
+        return
+    }
+
+    added := 0
+    for _, ingredient := range req.Ingredients {
+        key := utils.NormalizeIngredient(ingredient)
+        if key == "" {
+            continue
+        }
+
+        res, err := h.db.DB.Exec(`
+            INSERT INTO user_pantry (user_id, ingredient_name, ingredient_key)
+            VALUES ($1, $2, $3)
+            ON CONFLICT (user_id, ingredient_key) DO NOTHING
+        `, userID, strings.TrimSpace(ingredient), key)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pantry"})
+            return
+        }
+        if n, _ := res.RowsAffected(); n > 0 {
+            added++
+        }
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Pantry updated successfully",
+        "added":   added,
+    })
+}
+
+// RemovePantryItem removes a single ingredient from the user's pantry
+func (h *UserHandler) RemovePantryItem(c *gin.Context) {
+    userID, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+
+    key := utils.NormalizeIngredient(c.Param("ingredient"))
+    if key == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "ingredient is required"})
+        return
+    }
+
+    res, err := h.db.DB.Exec(`
+        DELETE FROM user_pantry
+        WHERE user_id = $1 AND ingredient_key = $2
+    `, userID, key)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pantry"})
+        return
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Ingredient not found in pantry"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Ingredient removed from pantry"})
+}
+
+// ClearPantry removes every ingredient from the user's pantry
+func (h *UserHandler) ClearPantry(c *gin.Context) {
+    userID, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+
+    if _, err := h.db.DB.Exec(`DELETE FROM user_pantry WHERE user_id = $1`, userID); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear pantry"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Pantry cleared successfully"})
+}
+
+// GetPreferences returns the authenticated user's taste preferences
+func (h *UserHandler) GetPreferences(c *gin.Context) {
+    userID, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+
+    prefs, err := h.meals.GetPreferences(strconv.Itoa(userID))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+        return
+    }
+
+    c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences replaces the authenticated user's taste preferences
+func (h *UserHandler) UpdatePreferences(c *gin.Context) {
+    userID, ok := utils.GetUserID(c)
+    if !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+        return
+    }
+
+    var req struct {
+        FavouriteCategories []string `json:"favouriteCategories" binding:"max=20"`
+        FavouriteAreas      []string `json:"favouriteAreas" binding:"max=20"`
+        ExcludedIngredients []string `json:"excludedIngredients" binding:"max=50"`
+        // MeasurementSystem is kept as it is when left out
+        MeasurementSystem   string   `json:"measurementSystem" binding:"omitempty,oneof=metric imperial"`
+    }
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+        return
+    }
+
+    excluded := make([]string, 0, len(req.ExcludedIngredients))
+    for _, ingredient := range req.ExcludedIngredients {
+        if key := utils.NormalizeIngredient(ingredient); key != "" {
+            excluded = append(excluded, key)
+        }
+    }
+
+    _, err := h.db.DB.Exec(`
+        INSERT INTO user_preferences (user_id, favourite_categories, favourite_areas, excluded_ingredients, measurement_system, updated_at)
+        VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'metric'), NOW())
+        ON CONFLICT (user_id) DO UPDATE
+        SET favourite_categories = EXCLUDED.favourite_categories,
+            favourite_areas = EXCLUDED.favourite_areas,
+            excluded_ingredients = EXCLUDED.excluded_ingredients,
+            measurement_system = COALESCE(NULLIF($5, ''), user_preferences.measurement_system),
+            updated_at = NOW()
+    `, userID, pq.Array(trimAll(req.FavouriteCategories)), pq.Array(trimAll(req.FavouriteAreas)), pq.Array(excluded), req.MeasurementSystem)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Preferences updated successfully"})
+}
+
+func trimAll(values []string) []string {
+    trimmed := make([]string, 0, len(values))
+    for _, v := range values {
+        if v = strings.TrimSpace(v); v != "" {
+            trimmed = append(trimmed, v)
+        }
+    }
+    return trimmed
+}
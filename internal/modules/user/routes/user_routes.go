@@ -3,12 +3,15 @@ package user_routes
 import (
 	"github.com/JonathanTriC/nomie-api/internal/database"
 	auth_middleware "github.com/JonathanTriC/nomie-api/internal/modules/auth/middleware"
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
 	user_handlers "github.com/JonathanTriC/nomie-api/internal/modules/user/handlers"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoute(r *gin.Engine, db *database.Database, jwtSecret string) {
-	handler := user_handlers.NewUserHandler(db, []byte(jwtSecret))
+// RegisterRoute serves the user's profile, preferences and pantry. Saved
+// preferences are read through the shared meals service.
+func RegisterRoute(r *gin.Engine, db *database.Database, meals meals_services.Service, jwtSecret string) {
+	handler := user_handlers.NewUserHandler(db, meals, []byte(jwtSecret))
 
 	userGroup := r.Group("/v1/user")
 	{
@@ -19,6 +22,14 @@ func RegisterRoute(r *gin.Engine, db *database.Database, jwtSecret string) {
 			protected.POST("/update-profile", handler.UpdateProfile)
 			protected.POST("/change-password", handler.ChangePassword)
 			protected.POST("/delete-account", handler.DeleteAccount)
+
+			protected.GET("/preferences", handler.GetPreferences)
+			protected.PUT("/preferences", handler.UpdatePreferences)
+
+			protected.GET("/pantry", handler.GetPantry)
+			protected.POST("/pantry", handler.AddPantryItems)
+			protected.DELETE("/pantry", handler.ClearPantry)
+			protected.DELETE("/pantry/:ingredient", handler.RemovePantryItem)
 		}
 	}
 }
@@ -1,17 +1,28 @@
 package server
 
 import (
+	"context"
+
 	"github.com/JonathanTriC/nomie-api/internal/config"
 	"github.com/JonathanTriC/nomie-api/internal/database"
 	"github.com/JonathanTriC/nomie-api/internal/middleware"
 	auth_routes "github.com/JonathanTriC/nomie-api/internal/modules/auth/routes"
+	meals_repository "github.com/JonathanTriC/nomie-api/internal/modules/meals/repository"
 	meals_routes "github.com/JonathanTriC/nomie-api/internal/modules/meals/routes"
+	meals_services "github.com/JonathanTriC/nomie-api/internal/modules/meals/services"
 	misc_routes "github.com/JonathanTriC/nomie-api/internal/modules/misc/routes"
+	planner_repository "github.com/JonathanTriC/nomie-api/internal/modules/planner/repository"
+	planner_routes "github.com/JonathanTriC/nomie-api/internal/modules/planner/routes"
+	planner_services "github.com/JonathanTriC/nomie-api/internal/modules/planner/services"
+	recipes_repository "github.com/JonathanTriC/nomie-api/internal/modules/recipes/repository"
+	recipes_routes "github.com/JonathanTriC/nomie-api/internal/modules/recipes/routes"
+	share_routes "github.com/JonathanTriC/nomie-api/internal/modules/share/routes"
+	shopping_routes "github.com/JonathanTriC/nomie-api/internal/modules/shopping/routes"
 	user_routes "github.com/JonathanTriC/nomie-api/internal/modules/user/routes"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(db *database.Database, cfg *config.Config) *gin.Engine {
+func SetupRouter(ctx context.Context, db *database.Database, cfg *config.Config) *gin.Engine {
 	// Set Gin mode
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -21,11 +32,21 @@ func SetupRouter(db *database.Database, cfg *config.Config) *gin.Engine {
 	r.Use(gin.Recovery(), gin.Logger())
 	r.Use(middleware.CORSMiddleware())
 
+	// Meals and plans are served by one service each, shared by every module
+	// that reads them
+	mealsRepo := meals_repository.NewRepository(db)
+	meals := meals_services.NewService(mealsRepo, recipes_repository.NewRepository(db))
+	planner := planner_services.NewService(planner_repository.NewRepository(db), meals)
+
 	// Register modules
 	auth_routes.RegisterRoute(r, db, cfg.JWT.Secret)
-	user_routes.RegisterRoute(r, db, cfg.JWT.Secret)
-	meals_routes.RegisterRoutes(r, db, cfg.JWT.Secret)
+	user_routes.RegisterRoute(r, db, meals, cfg.JWT.Secret)
+	meals_routes.RegisterRoutes(ctx, r, db, mealsRepo, meals, cfg.JWT.Secret)
 	misc_routes.RegisterRoutes(r, db, cfg.JWT.Secret)
+	planner_routes.RegisterRoutes(r, planner, cfg.JWT.Secret)
+	shopping_routes.RegisterRoutes(r, db, meals, planner, cfg.JWT.Secret)
+	recipes_routes.RegisterRoutes(r, db, cfg.JWT.Secret)
+	share_routes.RegisterRoutes(r, db, meals, planner, cfg.JWT.Secret)
 
 	return r
 }
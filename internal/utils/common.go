@@ -55,6 +55,12 @@ func GetEnv(key, defaultValue string) string {
     return defaultValue
 }
 
+// NormalizeIngredient lowercases an ingredient name and collapses whitespace
+// so pantry items and meal ingredients can be compared reliably
+func NormalizeIngredient(name string) string {
+    return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
 func GenerateRandomID() string {
     b := make([]byte, 16)
     _, _ = rand.Read(b)
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS user_pantry (
+    user_id         INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ingredient_name TEXT        NOT NULL,
+    ingredient_key  TEXT        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, ingredient_key)
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id              INTEGER     PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    favourite_categories TEXT[]      NOT NULL DEFAULT '{}',
+    favourite_areas      TEXT[]      NOT NULL DEFAULT '{}',
+    excluded_ingredients TEXT[]      NOT NULL DEFAULT '{}',
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS daily_recommendations (
+    user_id        INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recommended_on DATE        NOT NULL,
+    meal_id        TEXT        NOT NULL,
+    explanation    TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, recommended_on)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_last_seen_user_seen_at ON user_last_seen (user_id, seen_at DESC);
+CREATE INDEX IF NOT EXISTS idx_meal_reviews_user_id ON meal_reviews (user_id);
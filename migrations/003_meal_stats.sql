@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS meal_stats (
+    meal_id          TEXT             PRIMARY KEY,
+    meal_name        TEXT             NOT NULL DEFAULT '',
+    meal_thumb       TEXT             NOT NULL DEFAULT '',
+    recent_views     INTEGER          NOT NULL DEFAULT 0,
+    favourite_count  INTEGER          NOT NULL DEFAULT 0,
+    recent_reviews   INTEGER          NOT NULL DEFAULT 0,
+    total_reviews    INTEGER          NOT NULL DEFAULT 0,
+    avg_rating       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    bayesian_rating  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    trending_score   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at       TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_meal_stats_trending ON meal_stats (trending_score DESC, meal_id);
+CREATE INDEX IF NOT EXISTS idx_meal_stats_bayesian ON meal_stats (bayesian_rating DESC, total_reviews DESC, meal_id);
+CREATE INDEX IF NOT EXISTS idx_meal_reviews_created_at ON meal_reviews (created_at);
@@ -0,0 +1,10 @@
+-- Keep only the latest review per user and meal before enforcing uniqueness
+DELETE FROM meal_reviews r
+USING meal_reviews newer
+WHERE r.user_id = newer.user_id
+  AND r.meal_id = newer.meal_id
+  AND (r.created_at, r.id) < (newer.created_at, newer.id);
+
+ALTER TABLE meal_reviews ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_meal_reviews_user_meal ON meal_reviews (user_id, meal_id);
@@ -0,0 +1,5 @@
+ALTER TABLE meal_reviews ADD COLUMN IF NOT EXISTS helpful_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_meal_reviews_meal_newest ON meal_reviews (meal_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_meal_reviews_meal_rating ON meal_reviews (meal_id, rating, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_meal_reviews_meal_helpful ON meal_reviews (meal_id, helpful_count DESC, created_at DESC, id DESC);
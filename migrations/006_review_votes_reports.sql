@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS review_helpful_votes (
+    review_id  INTEGER     NOT NULL REFERENCES meal_reviews(id) ON DELETE CASCADE,
+    user_id    INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (review_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS review_reports (
+    id         SERIAL      PRIMARY KEY,
+    review_id  INTEGER     NOT NULL REFERENCES meal_reviews(id) ON DELETE CASCADE,
+    user_id    INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason     TEXT        NOT NULL,
+    details    TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (review_id, user_id)
+);
+
+ALTER TABLE meal_reviews ADD COLUMN IF NOT EXISTS report_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE meal_reviews ADD COLUMN IF NOT EXISTS is_hidden BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE meal_reviews ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'approved'
+    CHECK (status IN ('pending', 'approved', 'rejected'));
+ALTER TABLE meal_reviews ADD COLUMN IF NOT EXISTS moderation_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE meal_reviews ADD COLUMN IF NOT EXISTS moderated_at TIMESTAMPTZ;
+ALTER TABLE meal_reviews ADD COLUMN IF NOT EXISTS moderated_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+-- Reviews hidden by reports now wait in the moderation queue
+UPDATE meal_reviews
+SET status = 'pending', moderation_reason = 'Hidden after community reports'
+WHERE is_hidden;
+
+ALTER TABLE meal_reviews DROP COLUMN IF EXISTS is_hidden;
+
+CREATE INDEX IF NOT EXISTS idx_meal_reviews_status ON meal_reviews (status, created_at);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS review_images (
+    id SERIAL PRIMARY KEY,
+    review_id INTEGER NOT NULL REFERENCES meal_reviews(id) ON DELETE CASCADE,
+    public_id TEXT NOT NULL UNIQUE,
+    image_url TEXT NOT NULL,
+    caption TEXT NOT NULL DEFAULT '',
+    position INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Deferred so images can be reordered inside one transaction
+    CONSTRAINT review_images_position_key UNIQUE (review_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+-- Existing single images were uploaded as reviews/review_<meal>_<user>
+INSERT INTO review_images (review_id, public_id, image_url, position, created_at)
+SELECT id, 'reviews/review_' || meal_id || '_' || user_id, review_image, 0, COALESCE(edited_at, created_at)
+FROM meal_reviews
+WHERE review_image IS NOT NULL AND review_image <> ''
+ON CONFLICT (public_id) DO NOTHING;
+
+ALTER TABLE meal_reviews DROP COLUMN IF EXISTS review_image;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS review_replies (
+    id                SERIAL      PRIMARY KEY,
+    review_id         INTEGER     NOT NULL REFERENCES meal_reviews(id) ON DELETE CASCADE,
+    user_id           INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reply_text        TEXT        NOT NULL,
+    status            TEXT        NOT NULL DEFAULT 'approved'
+        CHECK (status IN ('pending', 'approved', 'rejected')),
+    moderation_reason TEXT        NOT NULL DEFAULT '',
+    moderated_at      TIMESTAMPTZ,
+    moderated_by      INTEGER     REFERENCES users(id) ON DELETE SET NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    edited_at         TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_replies_review ON review_replies (review_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_review_replies_status ON review_replies (status, created_at);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS favourite_collections (
+    id          SERIAL      PRIMARY KEY,
+    user_id     INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name        TEXT        NOT NULL,
+    share_token TEXT        UNIQUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS favourite_collection_meals (
+    collection_id INTEGER     NOT NULL REFERENCES favourite_collections(id) ON DELETE CASCADE,
+    meal_id       TEXT        NOT NULL,
+    position      INTEGER     NOT NULL,
+    added_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, meal_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_favourite_collection_meals_position ON favourite_collection_meals (collection_id, position);
@@ -0,0 +1,6 @@
+-- Existing favourites keep a NULL date: when they were added is unknown, and
+-- stamping them with the migration time would make them all look recent
+ALTER TABLE favourites ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ;
+ALTER TABLE favourites ALTER COLUMN created_at SET DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_favourites_user_created ON favourites (user_id, created_at DESC);
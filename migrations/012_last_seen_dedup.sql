@@ -0,0 +1,24 @@
+ALTER TABLE user_last_seen ADD COLUMN IF NOT EXISTS view_count INTEGER NOT NULL DEFAULT 1;
+
+-- Collapse repeated views into the latest row per user and meal
+WITH ranked AS (
+    SELECT ctid,
+           ROW_NUMBER() OVER (PARTITION BY user_id, meal_id ORDER BY seen_at DESC) AS rn,
+           COUNT(*) OVER (PARTITION BY user_id, meal_id) AS views
+    FROM user_last_seen
+)
+UPDATE user_last_seen u
+SET view_count = ranked.views
+FROM ranked
+WHERE u.ctid = ranked.ctid AND ranked.rn = 1;
+
+WITH ranked AS (
+    SELECT ctid,
+           ROW_NUMBER() OVER (PARTITION BY user_id, meal_id ORDER BY seen_at DESC) AS rn
+    FROM user_last_seen
+)
+DELETE FROM user_last_seen u
+USING ranked
+WHERE u.ctid = ranked.ctid AND ranked.rn > 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_user_last_seen_user_meal ON user_last_seen (user_id, meal_id);
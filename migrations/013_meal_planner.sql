@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS meal_plan_entries (
+    user_id     INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan_date   DATE        NOT NULL,
+    slot        TEXT        NOT NULL CHECK (slot IN ('breakfast', 'lunch', 'dinner')),
+    meal_id     TEXT        NOT NULL,
+    meal_name   TEXT        NOT NULL DEFAULT '',
+    meal_thumb  TEXT        NOT NULL DEFAULT '',
+    servings    INTEGER     NOT NULL DEFAULT 2 CHECK (servings > 0),
+    source      TEXT        NOT NULL DEFAULT 'manual' CHECK (source IN ('manual', 'auto', 'copied')),
+    explanation TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, plan_date, slot)
+);
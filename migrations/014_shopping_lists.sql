@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS shopping_lists (
+    id         SERIAL      PRIMARY KEY,
+    user_id    INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name       TEXT        NOT NULL,
+    source     TEXT        NOT NULL CHECK (source IN ('meal', 'plan', 'manual')),
+    source_ref TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shopping_lists_user ON shopping_lists (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS shopping_list_items (
+    id             SERIAL      PRIMARY KEY,
+    list_id        INTEGER     NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    name           TEXT        NOT NULL,
+    ingredient_key TEXT        NOT NULL,
+    aisle          TEXT        NOT NULL,
+    measure        TEXT        NOT NULL DEFAULT '',
+    measures       TEXT[]      NOT NULL DEFAULT '{}',
+    meal_ids       TEXT[]      NOT NULL DEFAULT '{}',
+    is_manual      BOOLEAN     NOT NULL DEFAULT FALSE,
+    is_checked     BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shopping_list_items_list ON shopping_list_items (list_id);
@@ -0,0 +1,3 @@
+ALTER TABLE user_preferences
+    ADD COLUMN IF NOT EXISTS measurement_system TEXT NOT NULL DEFAULT 'metric'
+    CHECK (measurement_system IN ('metric', 'imperial'));
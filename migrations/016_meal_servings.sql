@@ -0,0 +1,7 @@
+-- Serving counts set by admins for meals whose recipe does not state one
+CREATE TABLE IF NOT EXISTS meal_servings (
+    meal_id    TEXT        PRIMARY KEY,
+    servings   INTEGER     NOT NULL CHECK (servings BETWEEN 1 AND 100),
+    updated_by INTEGER     REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
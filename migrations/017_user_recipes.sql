@@ -0,0 +1,31 @@
+-- Recipes written by users. They are served as meals under the ID "u-<id>"
+-- so they never collide with upstream meal IDs.
+CREATE TABLE IF NOT EXISTS user_recipes (
+    id              SERIAL      PRIMARY KEY,
+    user_id         INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name            TEXT        NOT NULL,
+    alternate_name  TEXT        NOT NULL DEFAULT '',
+    category        TEXT        NOT NULL DEFAULT '',
+    area            TEXT        NOT NULL DEFAULT '',
+    instructions    TEXT        NOT NULL,
+    tags            TEXT        NOT NULL DEFAULT '',
+    youtube_url     TEXT        NOT NULL DEFAULT '',
+    source_url      TEXT        NOT NULL DEFAULT '',
+    image_url       TEXT        NOT NULL DEFAULT '',
+    image_public_id TEXT        NOT NULL DEFAULT '',
+    servings        INTEGER     CHECK (servings BETWEEN 1 AND 100),
+    visibility      TEXT        NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'public')),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recipes_user ON user_recipes (user_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_recipes_public ON user_recipes (LOWER(name)) WHERE visibility = 'public';
+
+CREATE TABLE IF NOT EXISTS user_recipe_ingredients (
+    recipe_id INTEGER NOT NULL REFERENCES user_recipes(id) ON DELETE CASCADE,
+    position  INTEGER NOT NULL,
+    name      TEXT    NOT NULL,
+    measure   TEXT    NOT NULL DEFAULT '',
+    PRIMARY KEY (recipe_id, position)
+);
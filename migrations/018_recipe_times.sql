@@ -0,0 +1,4 @@
+-- Preparation and cooking times of user recipes, in minutes; 0 when unknown
+ALTER TABLE user_recipes ADD COLUMN IF NOT EXISTS prep_minutes  INTEGER NOT NULL DEFAULT 0 CHECK (prep_minutes >= 0);
+ALTER TABLE user_recipes ADD COLUMN IF NOT EXISTS cook_minutes  INTEGER NOT NULL DEFAULT 0 CHECK (cook_minutes >= 0);
+ALTER TABLE user_recipes ADD COLUMN IF NOT EXISTS total_minutes INTEGER NOT NULL DEFAULT 0 CHECK (total_minutes >= 0);
@@ -0,0 +1,15 @@
+-- Public links to a meal, a collection or a range of a meal plan. The token
+-- handed out is the id signed with the server's share secret.
+CREATE TABLE IF NOT EXISTS share_links (
+    id         TEXT        PRIMARY KEY,
+    user_id    INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind       TEXT        NOT NULL CHECK (kind IN ('meal', 'collection', 'plan')),
+    -- A meal ID, a collection ID, or a plan range as "YYYY-MM-DD/YYYY-MM-DD"
+    target     TEXT        NOT NULL,
+    title      TEXT        NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_links_user ON share_links (user_id, created_at DESC);
@@ -0,0 +1,12 @@
+-- Collection share tokens move onto share_links, so every public link can be
+-- listed, revoked and expires the same way. Links shared before keep opening
+-- at /v1/shared/collections/:token for another 90 days.
+ALTER TABLE share_links ADD COLUMN IF NOT EXISTS legacy BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO share_links (id, user_id, kind, target, title, expires_at, legacy)
+SELECT share_token, user_id, 'collection', id::TEXT, name, NOW() + INTERVAL '90 days', TRUE
+FROM favourite_collections
+WHERE share_token IS NOT NULL
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE favourite_collections DROP COLUMN IF EXISTS share_token;
@@ -0,0 +1,104 @@
+package measure
+
+import "math"
+
+// System is a measurement system a user can read recipes in
+type System string
+
+const (
+	Metric   System = "metric"
+	Imperial System = "imperial"
+)
+
+// ParseSystem validates a measurement system name
+func ParseSystem(s string) (System, bool) {
+	switch System(s) {
+	case Metric, Imperial:
+		return System(s), true
+	}
+	return "", false
+}
+
+// Convert expresses a mass or volume in the given system, rounded the way a
+// recipe would write it. Counts, spoons and measures that could not be parsed
+// are returned unchanged; the original text is always kept.
+func Convert(m Measure, system System) Measure {
+	if converted, ok := convert(m, system); ok {
+		return Round(Normalize(converted))
+	}
+	return m
+}
+
+// convert expresses m in system without rounding, reporting whether it had
+// to be converted
+func convert(m Measure, system System) (Measure, bool) {
+	if !m.Parsed {
+		return m, false
+	}
+	u, ok := unitsByName[m.Unit]
+	if !ok || u.dimension == Count || u.system == "" || u.system == system {
+		return m, false
+	}
+
+	base := m.Quantity * u.factor
+	switch {
+	case system == Metric && u.dimension == Mass:
+		m.Unit, m.Quantity = "g", base
+	case system == Metric && u.dimension == Volume:
+		m.Unit, m.Quantity = "ml", base
+	case system == Imperial && u.dimension == Mass:
+		m.Unit, m.Quantity = "oz", base/unitsByName["oz"].factor
+	case system == Imperial && u.dimension == Volume:
+		// Small volumes read better in spoons than in fractions of a cup
+		switch {
+		case base >= unitsByName["cup"].factor/4:
+			m.Unit = "cup"
+		case base >= unitsByName["tbsp"].factor:
+			m.Unit = "tbsp"
+		default:
+			m.Unit = "tsp"
+		}
+		m.Quantity = base / unitsByName[m.Unit].factor
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// Round rounds a quantity to what its unit is measured in: whole grams and
+// millilitres, kitchen fractions for imperial units and spoons, and halves or
+// whole numbers for counts
+func Round(m Measure) Measure {
+	if !m.Parsed {
+		return m
+	}
+
+	u := unitsByName[m.Unit]
+	var rounded, smallest float64
+	switch {
+	case u != nil && u.system == Metric:
+		step := 5.0
+		switch {
+		case m.Unit == "kg" || m.Unit == "l":
+			step = 0.01
+		case m.Quantity < 10:
+			step = 0.5
+		case m.Quantity < 100:
+			step = 1
+		}
+		rounded, smallest = math.Round(m.Quantity/step)*step, step
+	case (u == nil || u.dimension == Count) && m.Quantity >= 5:
+		rounded, smallest = math.Round(m.Quantity), 1
+	case (u == nil || u.dimension == Count) && m.Quantity >= 1:
+		rounded, smallest = math.Round(m.Quantity*2)/2, 1
+	default:
+		rounded, smallest = roundKitchen(m.Quantity), kitchenFractions[1].value
+	}
+
+	if rounded == 0 && m.Quantity > 0 {
+		// Never round an ingredient away
+		rounded = smallest
+	}
+	m.Quantity = rounded
+	return m
+}
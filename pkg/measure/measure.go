@@ -0,0 +1,359 @@
+// Package measure parses the free-text ingredient measures used by TheMealDB,
+// such as "1/2 tsp", "200g" or "2 cups chopped", and adds them up.
+package measure
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dimension tells which measures can be added together
+type Dimension int
+
+const (
+	// Count measures are things like cloves or tins; they only add up with
+	// the same unit
+	Count Dimension = iota
+	// Mass measures convert through grams
+	Mass
+	// Volume measures convert through millilitres
+	Volume
+)
+
+type unit struct {
+	name      string
+	plural    string
+	dimension Dimension
+	// system is empty for units used the same way everywhere, like spoons
+	system System
+	// factor converts one of the unit to grams or millilitres
+	factor  float64
+	aliases []string
+}
+
+// units lists the units found in recipes. Imperial volumes follow US kitchen
+// measures except the pint, which British recipes use as 568ml.
+var units = []unit{
+	{name: "mg", system: Metric, dimension: Mass, factor: 0.001, aliases: []string{"mg", "milligram", "milligrams"}},
+	{name: "g", system: Metric, dimension: Mass, factor: 1, aliases: []string{"g", "gr", "grs", "gm", "gms", "gram", "grams", "gramme", "grammes"}},
+	{name: "kg", system: Metric, dimension: Mass, factor: 1000, aliases: []string{"kg", "kgs", "kilo", "kilos", "kilogram", "kilograms"}},
+	{name: "oz", system: Imperial, dimension: Mass, factor: 28.3495, aliases: []string{"oz", "ozs", "ounce", "ounces"}},
+	{name: "lb", system: Imperial, dimension: Mass, factor: 453.592, aliases: []string{"lb", "lbs", "pound", "pounds"}},
+
+	{name: "ml", system: Metric, dimension: Volume, factor: 1, aliases: []string{"ml", "mls", "millilitre", "millilitres", "milliliter", "milliliters"}},
+	{name: "cl", system: Metric, dimension: Volume, factor: 10, aliases: []string{"cl", "centilitre", "centilitres", "centiliter", "centiliters"}},
+	{name: "dl", system: Metric, dimension: Volume, factor: 100, aliases: []string{"dl", "decilitre", "decilitres", "deciliter", "deciliters"}},
+	{name: "l", system: Metric, dimension: Volume, factor: 1000, aliases: []string{"l", "ltr", "ltrs", "litre", "litres", "liter", "liters"}},
+	{name: "tsp", dimension: Volume, factor: 4.92892, aliases: []string{"tsp", "tsps", "tspn", "teaspoon", "teaspoons"}},
+	{name: "tbsp", dimension: Volume, factor: 14.7868, aliases: []string{"tbsp", "tbsps", "tbs", "tbls", "tblsp", "tblspn", "tbspn", "tablespoon", "tablespoons"}},
+	{name: "fl oz", system: Imperial, dimension: Volume, factor: 29.5735, aliases: []string{"fl oz", "fl. oz", "fl.oz", "floz", "fluid ounce", "fluid ounces"}},
+	{name: "cup", system: Imperial, plural: "cups", dimension: Volume, factor: 236.588, aliases: []string{"cup", "cups"}},
+	{name: "pint", system: Imperial, plural: "pints", dimension: Volume, factor: 568.261, aliases: []string{"pint", "pints", "pt"}},
+	{name: "quart", system: Imperial, plural: "quarts", dimension: Volume, factor: 946.353, aliases: []string{"quart", "quarts", "qt"}},
+
+	{name: "clove", plural: "cloves", aliases: []string{"clove", "cloves"}},
+	{name: "slice", plural: "slices", aliases: []string{"slice", "slices"}},
+	{name: "can", plural: "cans", aliases: []string{"can", "cans"}},
+	{name: "tin", plural: "tins", aliases: []string{"tin", "tins"}},
+	{name: "jar", plural: "jars", aliases: []string{"jar", "jars"}},
+	{name: "packet", plural: "packets", aliases: []string{"packet", "packets", "pack", "packs", "pkg", "package", "packages"}},
+	{name: "sachet", plural: "sachets", aliases: []string{"sachet", "sachets"}},
+	{name: "bunch", plural: "bunches", aliases: []string{"bunch", "bunches"}},
+	{name: "sprig", plural: "sprigs", aliases: []string{"sprig", "sprigs"}},
+	{name: "handful", plural: "handfuls", aliases: []string{"handful", "handfuls"}},
+	{name: "pinch", plural: "pinches", aliases: []string{"pinch", "pinches"}},
+	{name: "dash", plural: "dashes", aliases: []string{"dash", "dashes"}},
+	{name: "drop", plural: "drops", aliases: []string{"drop", "drops"}},
+	{name: "knob", plural: "knobs", aliases: []string{"knob", "knobs"}},
+	{name: "stick", plural: "sticks", aliases: []string{"stick", "sticks"}},
+	{name: "stalk", plural: "stalks", aliases: []string{"stalk", "stalks"}},
+	{name: "head", plural: "heads", aliases: []string{"head", "heads"}},
+	{name: "leaf", plural: "leaves", aliases: []string{"leaf", "leaves"}},
+	{name: "sheet", plural: "sheets", aliases: []string{"sheet", "sheets"}},
+	{name: "fillet", plural: "fillets", aliases: []string{"fillet", "fillets"}},
+	{name: "rasher", plural: "rashers", aliases: []string{"rasher", "rashers"}},
+	{name: "piece", plural: "pieces", aliases: []string{"piece", "pieces", "pc", "pcs"}},
+	{name: "cube", plural: "cubes", aliases: []string{"cube", "cubes"}},
+}
+
+// Units that make sense without a number: "Pinch" means one pinch
+var implicitOne = map[string]bool{"pinch": true, "dash": true, "handful": true, "knob": true, "sprig": true, "bunch": true}
+
+var (
+	unitsByName = map[string]*unit{}
+	// aliases is every alias, longest first so "fl oz" wins over "fl"
+	aliases      []string
+	aliasToUnit  = map[string]*unit{}
+	unicodeFracs = map[rune]string{
+		'½': "1/2", '⅓': "1/3", '⅔': "2/3", '¼': "1/4", '¾': "3/4",
+		'⅕': "1/5", '⅙': "1/6", '⅛': "1/8", '⅜': "3/8", '⅝': "5/8", '⅞': "7/8",
+	}
+
+	numberPattern     = `(\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?)`
+	quantityRe        = regexp.MustCompile(`^` + numberPattern + `(?:\s*(?:-|–|to)\s*` + numberPattern + `)?`)
+	multiplierRe      = regexp.MustCompile(`^\s*x\s*`)
+	articleRe         = regexp.MustCompile(`^(a|an|one)\s+`)
+	trailingJunkRe    = regexp.MustCompile(`^[\s.,:;-]+|[\s.,:;-]+$`)
+	leadingOfRe       = regexp.MustCompile(`^of\s+`)
+	unitBoundaryRunes = "abcdefghijklmnopqrstuvwxyz"
+)
+
+func init() {
+	for i := range units {
+		u := &units[i]
+		if u.plural == "" {
+			u.plural = u.name
+		}
+		unitsByName[u.name] = u
+		for _, alias := range u.aliases {
+			aliasToUnit[alias] = u
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool { return len(aliases[i]) > len(aliases[j]) })
+}
+
+// Measure is a parsed ingredient measure
+type Measure struct {
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit,omitempty"`
+	Note     string  `json:"note,omitempty"`
+	Original string  `json:"original"`
+	// Parsed is false when no quantity could be read; only Original is set then
+	Parsed bool `json:"parsed"`
+}
+
+// Parse reads a quantity, a unit and a trailing note from text. Ranges such
+// as "2-3" take the upper bound and "2 x 400g" is read as 800g.
+func Parse(text string) Measure {
+	m := Measure{Original: strings.TrimSpace(text)}
+
+	s := strings.ToLower(m.Original)
+	s = expandUnicodeFractions(s)
+
+	quantity, rest, ok := parseQuantity(s)
+	if ok {
+		// "2 x 400g tins"
+		if loc := multiplierRe.FindStringIndex(rest); loc != nil {
+			if inner, innerRest, innerOK := parseQuantity(strings.TrimSpace(rest[loc[1]:])); innerOK {
+				quantity *= inner
+				rest = innerRest
+			}
+		}
+	} else if loc := articleRe.FindStringIndex(s); loc != nil {
+		quantity, rest, ok = 1, s[loc[1]:], true
+	}
+
+	u, rest := parseUnit(strings.TrimSpace(rest))
+	if !ok {
+		if u == nil || !implicitOne[u.name] {
+			return m
+		}
+		quantity = 1
+	}
+
+	m.Quantity = quantity
+	m.Parsed = true
+	if u != nil {
+		m.Unit = u.name
+	}
+	note := trailingJunkRe.ReplaceAllString(rest, "")
+	m.Note = strings.TrimSpace(leadingOfRe.ReplaceAllString(note, ""))
+	return m
+}
+
+// Dimension returns whether the measure is a mass, a volume or a count
+func (m Measure) Dimension() Dimension {
+	if u, ok := unitsByName[m.Unit]; ok {
+		return u.dimension
+	}
+	return Count
+}
+
+// Base returns the quantity in grams for masses and millilitres for volumes.
+// Counts are returned as they are.
+func (m Measure) Base() float64 {
+	if u, ok := unitsByName[m.Unit]; ok && u.dimension != Count {
+		return m.Quantity * u.factor
+	}
+	return m.Quantity
+}
+
+// String formats the measure, falling back to the original text when it
+// could not be parsed
+func (m Measure) String() string {
+	if !m.Parsed {
+		return m.Original
+	}
+
+	u, ok := unitsByName[m.Unit]
+	quantity := FormatFraction(m.Quantity)
+	if ok && u.system == Metric {
+		quantity = FormatQuantity(m.Quantity)
+	}
+
+	parts := []string{quantity}
+	if ok {
+		name := u.name
+		if m.Quantity > 1 {
+			name = u.plural
+		}
+		parts = append(parts, name)
+	}
+	if m.Note != "" {
+		parts = append(parts, m.Note)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Sum adds up measures of the same ingredient. Masses and volumes add up
+// across units, counts only with the same unit. Measures that cannot be
+// parsed are returned as they are so nothing is silently dropped.
+func Sum(measures []Measure) []Measure {
+	var result []Measure
+	groups := map[string]int{}
+
+	for _, m := range measures {
+		if !m.Parsed {
+			if m.Original != "" {
+				result = append(result, m)
+			}
+			continue
+		}
+
+		var key string
+		switch m.Dimension() {
+		case Mass:
+			key = "mass"
+		case Volume:
+			key = "volume"
+		default:
+			// "3 large" and "2" eggs are still five eggs; notes are only kept
+			// when they agree
+			key = "count:" + m.Unit
+		}
+
+		i, ok := groups[key]
+		if !ok {
+			groups[key] = len(result)
+			result = append(result, m)
+			continue
+		}
+		result[i] = add(result[i], m)
+	}
+	return result
+}
+
+// add adds b to a, which have the same dimension. The sum keeps the unit
+// when both agree and is given in grams or millilitres otherwise.
+func add(a, b Measure) Measure {
+	sum := Measure{Parsed: true, Original: a.Original + " + " + b.Original}
+	if a.Note == b.Note {
+		sum.Note = a.Note
+	}
+
+	if a.Unit == b.Unit {
+		sum.Unit = a.Unit
+		sum.Quantity = a.Quantity + b.Quantity
+		return sum
+	}
+
+	ua, ub := unitsByName[a.Unit], unitsByName[b.Unit]
+	sum.Quantity = a.Quantity*ua.factor + b.Quantity*ub.factor
+	sum.Unit = "g"
+	if ua.dimension == Volume {
+		sum.Unit = "ml"
+	}
+	return Normalize(sum)
+}
+
+// Normalize moves large quantities up a unit: 1500 g becomes 1.5 kg and
+// 20 oz becomes 1.25 lb
+func Normalize(m Measure) Measure {
+	switch {
+	case m.Unit == "g" && m.Quantity >= 1000:
+		m.Unit, m.Quantity = "kg", m.Quantity/1000
+	case m.Unit == "ml" && m.Quantity >= 1000:
+		m.Unit, m.Quantity = "l", m.Quantity/1000
+	case m.Unit == "oz" && m.Quantity >= 16:
+		m.Unit, m.Quantity = "lb", m.Quantity/16
+	}
+	return m
+}
+
+// FormatQuantity prints a quantity with at most two decimals
+func FormatQuantity(q float64) string {
+	return strconv.FormatFloat(math.Round(q*100)/100, 'f', -1, 64)
+}
+
+func expandUnicodeFractions(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if frac, ok := unicodeFracs[r]; ok {
+			b.WriteString(" " + frac)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// parseQuantity reads a number, fraction, mixed number or range from the
+// start of s
+func parseQuantity(s string) (float64, string, bool) {
+	match := quantityRe.FindStringSubmatch(s)
+	if match == nil {
+		return 0, s, false
+	}
+
+	value := match[1]
+	if match[2] != "" {
+		value = match[2]
+	}
+	q, ok := parseNumber(value)
+	if !ok {
+		return 0, s, false
+	}
+	return q, s[len(match[0]):], true
+}
+
+func parseNumber(s string) (float64, bool) {
+	var total float64
+	for _, part := range strings.Fields(s) {
+		if num, den, found := strings.Cut(part, "/"); found {
+			n, err1 := strconv.ParseFloat(num, 64)
+			d, err2 := strconv.ParseFloat(den, 64)
+			if err1 != nil || err2 != nil || d == 0 {
+				return 0, false
+			}
+			total += n / d
+			continue
+		}
+		f, err := strconv.ParseFloat(strings.Replace(part, ",", ".", 1), 64)
+		if err != nil {
+			return 0, false
+		}
+		total += f
+	}
+	return total, true
+}
+
+// parseUnit reads a unit from the start of s. A unit must end at a word
+// boundary so "g" does not match "garlic".
+func parseUnit(s string) (*unit, string) {
+	for _, alias := range aliases {
+		if !strings.HasPrefix(s, alias) {
+			continue
+		}
+		rest := s[len(alias):]
+		if rest != "" && strings.ContainsRune(unitBoundaryRunes, rune(rest[0])) {
+			continue
+		}
+		rest = strings.TrimPrefix(rest, ".")
+		return aliasToUnit[alias], rest
+	}
+	return nil, s
+}
@@ -0,0 +1,197 @@
+package measure
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text     string
+		quantity float64
+		unit     string
+		note     string
+		parsed   bool
+	}{
+		{"200g", 200, "g", "", true},
+		{"1/2 tsp", 0.5, "tsp", "", true},
+		{"1 1/2 cups chopped", 1.5, "cup", "chopped", true},
+		{"½ tbsp", 0.5, "tbsp", "", true},
+		{"2-3 tbsp", 3, "tbsp", "", true},
+		{"2 to 3 cloves", 3, "clove", "", true},
+		{"2 x 400g tins", 800, "g", "tins", true},
+		{"1,5 kg", 1.5, "kg", "", true},
+		{"3 large", 3, "", "large", true},
+		{"2 fl oz", 2, "fl oz", "", true},
+		{"Pinch", 1, "pinch", "", true},
+		{"a handful of basil", 1, "handful", "basil", true},
+		{"2 garlic", 2, "", "garlic", true},
+		{"to taste", 0, "", "", false},
+		{"", 0, "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m := Parse(tt.text)
+			if m.Parsed != tt.parsed || m.Quantity != tt.quantity || m.Unit != tt.unit || m.Note != tt.note {
+				t.Errorf("Parse(%q) = %v %q %q parsed=%v, want %v %q %q parsed=%v",
+					tt.text, m.Quantity, m.Unit, m.Note, m.Parsed, tt.quantity, tt.unit, tt.note, tt.parsed)
+			}
+			if m.Original != tt.text {
+				t.Errorf("Original = %q, want %q", m.Original, tt.text)
+			}
+		})
+	}
+}
+
+func TestSum(t *testing.T) {
+	tests := []struct {
+		name     string
+		measures []string
+		want     []string
+	}{
+		{"same unit", []string{"100g", "50g"}, []string{"150 g"}},
+		{"imperial and metric mass", []string{"1 lb", "8 oz"}, []string{"680.39 g"}},
+		{"same unit keeps its unit", []string{"800g", "400g"}, []string{"1200 g"}},
+		{"mixed units move up a unit", []string{"800g", "1 lb"}, []string{"1.25 kg"}},
+		{"volumes", []string{"1 tbsp", "1 tsp"}, []string{"19.72 ml"}},
+		{"counts with the same unit", []string{"2 cloves", "3 cloves"}, []string{"5 cloves"}},
+		{"counts keep agreeing notes", []string{"3 large", "2 large"}, []string{"5 large"}},
+		{"different dimensions stay apart", []string{"200g", "1 cup", "2"}, []string{"200 g", "1 cup", "2"}},
+		{"unparsed kept as they are", []string{"to taste", "100g", "to taste"}, []string{"to taste", "100 g", "to taste"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			measures := make([]Measure, len(tt.measures))
+			for i, text := range tt.measures {
+				measures[i] = Parse(text)
+			}
+			got := Sum(measures)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Sum(%q) = %v, want %q", tt.measures, got, tt.want)
+			}
+			for i := range got {
+				if got[i].String() != tt.want[i] {
+					t.Errorf("Sum(%q)[%d] = %q, want %q", tt.measures, i, got[i].String(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		text   string
+		system System
+		want   string
+	}{
+		{"1 lb", Metric, "455 g"},
+		{"8 oz", Metric, "225 g"},
+		{"2 cups", Metric, "475 ml"},
+		{"1 pint", Metric, "570 ml"},
+		{"3 lb", Metric, "1.36 kg"},
+		{"500g", Imperial, "1 1/8 lb"},
+		{"100g", Imperial, "3 1/2 oz"},
+		{"1 kg", Imperial, "2 1/4 lb"},
+		{"250ml", Imperial, "1 cup"},
+		{"30ml", Imperial, "2 tbsp"},
+		{"2 tsp", Imperial, "2 tsp"},
+		{"200g", Metric, "200 g"},
+		{"2 cloves", Imperial, "2 cloves"},
+		{"to taste", Metric, "to taste"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text+" to "+string(tt.system), func(t *testing.T) {
+			if got := Convert(Parse(tt.text), tt.system).String(); got != tt.want {
+				t.Errorf("Convert(%q, %s) = %q, want %q", tt.text, tt.system, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		text   string
+		factor float64
+		want   string
+	}{
+		{"200g", 1.5, "300 g"},
+		{"700g", 2, "1.4 kg"},
+		{"1 tsp", 0.5, "1/2 tsp"},
+		{"1 tbsp", 1.0 / 3, "1/3 tbsp"},
+		{"1 cup", 0.01, "1/8 cup"},
+		{"3 eggs", 0.5, "1 1/2 eggs"},
+		{"3", 0.5, "1 1/2"},
+		{"7", 1.5, "11"},
+		{"10 oz", 2, "1 1/4 lb"},
+		{"to taste", 2, "to taste"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Scale(Parse(tt.text), tt.factor).String(); got != tt.want {
+				t.Errorf("Scale(%q, %v) = %q, want %q", tt.text, tt.factor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScaleTo(t *testing.T) {
+	tests := []struct {
+		text   string
+		factor float64
+		system System
+		want   string
+	}{
+		// Scaling first would round 30 oz to 1 3/4 lb, or 795 g
+		{"10 oz", 3, Metric, "850 g"},
+		{"150g", 3, Imperial, "15 3/4 oz"},
+		{"1 cup", 1, Metric, "235 ml"},
+		{"200g", 1.5, Metric, "300 g"},
+		{"200g", 1, Metric, "200 g"},
+		{"3 eggs", 0.5, Imperial, "1 1/2 eggs"},
+		{"to taste", 2, Metric, "to taste"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text+" to "+string(tt.system), func(t *testing.T) {
+			if got := ScaleTo(Parse(tt.text), tt.factor, tt.system).String(); got != tt.want {
+				t.Errorf("ScaleTo(%q, %v, %s) = %q, want %q", tt.text, tt.factor, tt.system, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFraction(t *testing.T) {
+	tests := []struct {
+		q    float64
+		want string
+	}{
+		{0.5, "1/2"},
+		{1.5, "1 1/2"},
+		{2, "2"},
+		{1.0 / 3, "1/3"},
+		{2.0 / 3, "2/3"},
+		{0.125, "1/8"},
+		{2.999, "3"},
+		{0.4, "0.4"},
+		{1.37, "1.37"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatFraction(tt.q); got != tt.want {
+			t.Errorf("FormatFraction(%v) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestBase(t *testing.T) {
+	if got := Parse("1 lb").Base(); math.Abs(got-453.592) > 1e-9 {
+		t.Errorf("Base() of 1 lb = %v, want 453.592", got)
+	}
+	if got := Parse("2 cloves").Base(); got != 2 {
+		t.Errorf("Base() of 2 cloves = %v, want 2", got)
+	}
+}
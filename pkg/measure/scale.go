@@ -0,0 +1,78 @@
+package measure
+
+import (
+	"math"
+	"strconv"
+)
+
+// kitchenFractions are the fractions measuring cups and spoons come in
+var kitchenFractions = []struct {
+	value float64
+	text  string
+}{
+	{0, ""},
+	{1.0 / 8, "1/8"},
+	{1.0 / 4, "1/4"},
+	{1.0 / 3, "1/3"},
+	{1.0 / 2, "1/2"},
+	{2.0 / 3, "2/3"},
+	{3.0 / 4, "3/4"},
+	{1, ""},
+}
+
+// Scale multiplies a measure by factor and rounds the result for its unit.
+// Measures that could not be parsed cannot be scaled and are returned
+// unchanged; callers should check Parsed.
+func Scale(m Measure, factor float64) Measure {
+	return ScaleTo(m, factor, "")
+}
+
+// ScaleTo scales a measure like Scale and expresses it in system like
+// Convert, rounding only once at the end so a scaled amount is not rounded
+// again by the conversion. An empty system keeps the measure's own units.
+func ScaleTo(m Measure, factor float64, system System) Measure {
+	if !m.Parsed {
+		return m
+	}
+	m.Quantity *= factor
+	converted, ok := convert(m, system)
+	if !ok && factor == 1 {
+		return m
+	}
+	return Round(Normalize(converted))
+}
+
+// roundKitchen rounds to the nearest kitchen fraction
+func roundKitchen(q float64) float64 {
+	whole, frac := math.Modf(q)
+	best := kitchenFractions[0].value
+	for _, f := range kitchenFractions {
+		if math.Abs(frac-f.value) < math.Abs(frac-best) {
+			best = f.value
+		}
+	}
+	return whole + best
+}
+
+// FormatFraction prints a quantity as a mixed number, 1.5 as "1 1/2". Values
+// that are not kitchen fractions are printed as decimals.
+func FormatFraction(q float64) string {
+	whole, frac := math.Modf(q)
+	for _, f := range kitchenFractions {
+		if math.Abs(frac-f.value) > 0.01 {
+			continue
+		}
+		if f.value == 1 {
+			whole++
+		}
+		switch {
+		case f.text == "":
+			return strconv.FormatFloat(whole, 'f', -1, 64)
+		case whole == 0:
+			return f.text
+		default:
+			return strconv.FormatFloat(whole, 'f', -1, 64) + " " + f.text
+		}
+	}
+	return FormatQuantity(q)
+}
@@ -0,0 +1,403 @@
+// Package nutrition estimates the nutritional value of a recipe from its
+// ingredients using a small bundled food composition dataset.
+package nutrition
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/JonathanTriC/nomie-api/pkg/measure"
+)
+
+//go:embed ingredients.csv
+var dataset string
+
+// Confidence levels of an estimate
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Status of one ingredient in an estimate
+const (
+	StatusMatched      = "matched"
+	StatusUnmatched    = "unmatched"
+	StatusUnquantified = "unquantified"
+)
+
+// countWeights is the weight in grams of one of a counted unit when the food
+// has no weight of its own for it
+var countWeights = map[string]float64{
+	"clove": 5, "slice": 30, "can": 400, "tin": 400, "jar": 300, "packet": 200,
+	"sachet": 7, "bunch": 30, "sprig": 1, "handful": 30, "pinch": 0.4, "dash": 0.6,
+	"drop": 0.05, "knob": 15, "stick": 113, "stalk": 40, "head": 300, "leaf": 0.5,
+	"sheet": 20, "fillet": 150, "rasher": 25, "piece": 100, "cube": 10,
+}
+
+// containerUnits always weigh their generic weight; the food's own piece
+// weight is for one item, not a tin or bunch of them
+var containerUnits = map[string]bool{
+	"can": true, "tin": true, "jar": true, "packet": true, "sachet": true, "bunch": true,
+	"handful": true, "pinch": true, "dash": true, "drop": true, "knob": true, "head": true, "sprig": true,
+}
+
+// negligible measures add no meaningful amount, like "to taste"
+var negligible = []string{"taste", "garnish", "to serve", "sprinkl", "dusting", "drizzle"}
+
+// Nutrients in kcal and grams
+type Nutrients struct {
+	Calories      float64 `json:"calories"`
+	Protein       float64 `json:"protein"`
+	Fat           float64 `json:"fat"`
+	Carbohydrates float64 `json:"carbohydrates"`
+	Fiber         float64 `json:"fiber"`
+	Sugar         float64 `json:"sugar"`
+}
+
+func (n Nutrients) add(o Nutrients, factor float64) Nutrients {
+	return Nutrients{
+		Calories:      n.Calories + o.Calories*factor,
+		Protein:       n.Protein + o.Protein*factor,
+		Fat:           n.Fat + o.Fat*factor,
+		Carbohydrates: n.Carbohydrates + o.Carbohydrates*factor,
+		Fiber:         n.Fiber + o.Fiber*factor,
+		Sugar:         n.Sugar + o.Sugar*factor,
+	}
+}
+
+func (n Nutrients) rounded() Nutrients {
+	return Nutrients{
+		Calories:      math.Round(n.Calories),
+		Protein:       round1(n.Protein),
+		Fat:           round1(n.Fat),
+		Carbohydrates: round1(n.Carbohydrates),
+		Fiber:         round1(n.Fiber),
+		Sugar:         round1(n.Sugar),
+	}
+}
+
+// Food is one entry of the dataset
+type Food struct {
+	Name    string
+	Per100g Nutrients
+	// Density is grams per millilitre, PieceWeight grams per item; zero when
+	// unknown
+	Density     float64
+	PieceWeight float64
+	Allergens   []string
+}
+
+// Grams converts a measure of the food to grams
+func (f *Food) Grams(m measure.Measure) (float64, bool) {
+	if !m.Parsed {
+		return 0, false
+	}
+
+	switch m.Dimension() {
+	case measure.Mass:
+		return m.Base(), true
+	case measure.Volume:
+		density := f.Density
+		if density == 0 {
+			density = 1
+		}
+		return m.Base() * density, true
+	}
+
+	if f.PieceWeight > 0 && !containerUnits[m.Unit] {
+		return m.Quantity * f.PieceWeight, true
+	}
+	if w, ok := countWeights[m.Unit]; ok {
+		return m.Quantity * w, true
+	}
+	return 0, false
+}
+
+// Database is a set of foods looked up by name and synonyms
+type Database struct {
+	foods map[string]*Food
+	// longest is the most words in any name, to bound matching
+	longest int
+}
+
+// Load reads a dataset in the bundled CSV format
+func Load(r io.Reader) (*Database, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("nutrition dataset is empty")
+	}
+
+	db := &Database{foods: map[string]*Food{}}
+	for i, rec := range records[1:] {
+		food, err := parseFood(rec)
+		if err != nil {
+			return nil, fmt.Errorf("nutrition dataset line %d: %w", i+2, err)
+		}
+		names := append([]string{rec[0]}, splitList(rec[1])...)
+		for _, name := range names {
+			key := foodKey(name)
+			db.foods[key] = food
+			if n := len(strings.Fields(key)); n > db.longest {
+				db.longest = n
+			}
+		}
+	}
+	return db, nil
+}
+
+var (
+	defaultOnce sync.Once
+	defaultDB   *Database
+)
+
+// Default returns the bundled dataset
+func Default() *Database {
+	defaultOnce.Do(func() {
+		db, err := Load(strings.NewReader(dataset))
+		if err != nil {
+			panic(err)
+		}
+		defaultDB = db
+	})
+	return defaultDB
+}
+
+func parseFood(rec []string) (*Food, error) {
+	if len(rec) != 11 {
+		return nil, fmt.Errorf("expected 11 fields, got %d", len(rec))
+	}
+
+	values := make([]float64, 8)
+	for i, field := range rec[2:10] {
+		if field == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	return &Food{
+		Name: rec[0],
+		Per100g: Nutrients{
+			Calories:      values[0],
+			Protein:       values[1],
+			Fat:           values[2],
+			Carbohydrates: values[3],
+			Fiber:         values[4],
+			Sugar:         values[5],
+		},
+		Density:     values[6],
+		PieceWeight: values[7],
+		Allergens:   splitList(rec[10]),
+	}, nil
+}
+
+// Match finds the food for an ingredient name. The whole name is tried
+// first, then ever shorter runs of its words, preferring the last words as
+// they name the thing: "free-range chicken thighs" matches chicken thigh.
+// Plurals missing from the dataset are tried in the singular.
+func (db *Database) Match(name string) *Food {
+	food, _ := db.matchAll(strings.Fields(foodKey(name)))
+	return food
+}
+
+// matchAll returns the food Match finds in words, and every food the words
+// mention: the search repeats on the words before and after the matched run,
+// so "chicken and leek" mentions chicken and leeks but "peanut butter" only
+// peanut butter
+func (db *Database) matchAll(words []string) (*Food, []*Food) {
+	for size := min(len(words), db.longest); size > 0; size-- {
+		for start := len(words) - size; start >= 0; start-- {
+			food := db.lookup(words[start : start+size])
+			if food == nil {
+				continue
+			}
+			_, before := db.matchAll(words[:start])
+			_, after := db.matchAll(words[start+size:])
+			return food, append(append(before, food), after...)
+		}
+	}
+	return nil, nil
+}
+
+// lookup finds a run of words as written, then in the singular
+func (db *Database) lookup(words []string) *Food {
+	if food, ok := db.foods[strings.Join(words, " ")]; ok {
+		return food
+	}
+	singulars := make([]string, len(words))
+	for i, w := range words {
+		singulars[i] = singular(w)
+	}
+	return db.foods[strings.Join(singulars, " ")]
+}
+
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// Ingredient is one line of a recipe
+type Ingredient struct {
+	Name    string
+	Measure measure.Measure
+}
+
+type IngredientEstimate struct {
+	Name     string  `json:"name"`
+	Food     string  `json:"food,omitempty"`
+	Grams    float64 `json:"grams"`
+	Calories float64 `json:"calories"`
+	Status   string  `json:"status"`
+}
+
+type Estimate struct {
+	Total      Nutrients `json:"total"`
+	PerServing Nutrients `json:"perServing"`
+	Servings   int       `json:"servings"`
+	Allergens  []string  `json:"allergens"`
+	// AllergensIncomplete is set when an ingredient was not recognised, so
+	// Allergens may be missing some; Unmatched names them
+	AllergensIncomplete bool `json:"allergensIncomplete"`
+	// Coverage is the share of ingredients that were matched and weighed
+	Coverage     float64              `json:"coverage"`
+	Confidence   string               `json:"confidence"`
+	Unmatched    []string             `json:"unmatched"`
+	Unquantified []string             `json:"unquantified"`
+	Ingredients  []IngredientEstimate `json:"ingredients"`
+}
+
+// Estimate adds up the ingredients. Ingredients that are not in the dataset
+// or whose amount cannot be read count as nothing and lower the confidence;
+// unrecognised ones also mark the allergen list as incomplete.
+func (db *Database) Estimate(ingredients []Ingredient, servings int) *Estimate {
+	if servings <= 0 {
+		servings = 1
+	}
+
+	est := &Estimate{
+		Servings:     servings,
+		Allergens:    []string{},
+		Unmatched:    []string{},
+		Unquantified: []string{},
+		Ingredients:  []IngredientEstimate{},
+	}
+	allergens := map[string]bool{}
+	counted := 0
+
+	for _, ingredient := range ingredients {
+		name := strings.TrimSpace(ingredient.Name)
+		if name == "" {
+			continue
+		}
+		line := IngredientEstimate{Name: name, Status: StatusMatched}
+
+		food, foods := db.matchAll(strings.Fields(foodKey(name)))
+		if food == nil {
+			line.Status = StatusUnmatched
+			est.Unmatched = append(est.Unmatched, name)
+			est.AllergensIncomplete = true
+			est.Ingredients = append(est.Ingredients, line)
+			continue
+		}
+		line.Food = food.Name
+		// Allergens come from every food the name mentions, not only the one
+		// it is weighed as
+		for _, f := range foods {
+			for _, a := range f.Allergens {
+				allergens[a] = true
+			}
+		}
+
+		grams, ok := food.Grams(ingredient.Measure)
+		if !ok && !isNegligible(ingredient.Measure.Original) {
+			line.Status = StatusUnquantified
+			est.Unquantified = append(est.Unquantified, name)
+			est.Ingredients = append(est.Ingredients, line)
+			continue
+		}
+
+		counted++
+		est.Total = est.Total.add(food.Per100g, grams/100)
+		line.Grams = math.Round(grams)
+		line.Calories = math.Round(food.Per100g.Calories * grams / 100)
+		est.Ingredients = append(est.Ingredients, line)
+	}
+
+	est.PerServing = Nutrients{}.add(est.Total, 1/float64(servings)).rounded()
+	est.Total = est.Total.rounded()
+
+	for a := range allergens {
+		est.Allergens = append(est.Allergens, a)
+	}
+	sort.Strings(est.Allergens)
+
+	if len(est.Ingredients) > 0 {
+		est.Coverage = round2(float64(counted) / float64(len(est.Ingredients)))
+	}
+	switch {
+	case est.Coverage >= 0.9:
+		est.Confidence = ConfidenceHigh
+	case est.Coverage >= 0.7:
+		est.Confidence = ConfidenceMedium
+	default:
+		est.Confidence = ConfidenceLow
+	}
+	return est
+}
+
+func isNegligible(text string) bool {
+	text = strings.ToLower(text)
+	for _, n := range negligible {
+		if strings.Contains(text, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// foodKey lowercases a name and drops punctuation so "Free-range Eggs" and
+// "free range eggs" look the same
+func foodKey(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("-", " ", ",", " ", "(", " ", ")", " ").Replace(name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func splitList(field string) []string {
+	var values []string
+	for _, v := range strings.Split(field, "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func round1(v float64) float64 { return math.Round(v*10) / 10 }
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
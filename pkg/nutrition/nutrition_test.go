@@ -0,0 +1,109 @@
+package nutrition
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JonathanTriC/nomie-api/pkg/measure"
+)
+
+func TestDefaultDatasetLoads(t *testing.T) {
+	if _, err := Load(strings.NewReader(dataset)); err != nil {
+		t.Fatalf("bundled dataset: %v", err)
+	}
+	if Default().Match("butter") == nil {
+		t.Fatal("Default() does not know butter")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Chicken Breast", "chicken breast"},
+		{"free-range chicken thighs", "chicken thigh"},
+		{"Almond Milk", "almond milk"},
+		{"Buttermilk", "buttermilk"},
+		{"Chicken Stock", "stock"},
+		{"Beef Stock", "stock"},
+		{"Peanut Butter", "peanut butter"},
+		{"Butter Beans", "beans"},
+		{"Leeks", "leek"},
+		{"Xanthan gum", ""},
+	}
+
+	db := Default()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if food := db.Match(tt.name); food != nil {
+				got = food.Name
+			}
+			if got != tt.want {
+				t.Errorf("Match(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateAllergens(t *testing.T) {
+	tests := []struct {
+		name       string
+		ingredient string
+		want       []string
+		incomplete bool
+	}{
+		{"nut milk is not dairy", "Almond Milk", []string{"nuts"}, false},
+		{"buttermilk", "Buttermilk", []string{"dairy"}, false},
+		{"stock", "Chicken Stock", []string{"celery"}, false},
+		{"compound name keeps its own allergens", "Peanut Butter", []string{"peanuts"}, false},
+		{"every food in the name", "Almonds and Milk", []string{"dairy", "nuts"}, false},
+		{"soy and gluten", "Soy Sauce", []string{"gluten", "soy"}, false},
+		{"unmatched", "Xanthan gum", []string{}, true},
+	}
+
+	db := Default()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est := db.Estimate([]Ingredient{{Name: tt.ingredient, Measure: measure.Parse("100g")}}, 1)
+			if !reflect.DeepEqual(est.Allergens, tt.want) {
+				t.Errorf("Allergens = %q, want %q", est.Allergens, tt.want)
+			}
+			if est.AllergensIncomplete != tt.incomplete {
+				t.Errorf("AllergensIncomplete = %v, want %v", est.AllergensIncomplete, tt.incomplete)
+			}
+		})
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	est := Default().Estimate([]Ingredient{
+		{Name: "Butter", Measure: measure.Parse("100g")},
+		{Name: "Milk", Measure: measure.Parse("200ml")},
+		{Name: "Eggs", Measure: measure.Parse("2")},
+		{Name: "Salt", Measure: measure.Parse("to taste")},
+		{Name: "Xanthan gum", Measure: measure.Parse("1 pinch")},
+	}, 2)
+
+	// 100 g butter, 206 g milk and two 50 g eggs: 717 + 125.66 + 143 kcal
+	if est.Total.Calories != 986 {
+		t.Errorf("Total.Calories = %v, want 986", est.Total.Calories)
+	}
+	if est.PerServing.Calories != 493 {
+		t.Errorf("PerServing.Calories = %v, want 493", est.PerServing.Calories)
+	}
+	if want := []string{"Xanthan gum"}; !reflect.DeepEqual(est.Unmatched, want) {
+		t.Errorf("Unmatched = %q, want %q", est.Unmatched, want)
+	}
+	if !est.AllergensIncomplete {
+		t.Error("AllergensIncomplete is false with an unmatched ingredient")
+	}
+	if want := []string{"dairy", "egg"}; !reflect.DeepEqual(est.Allergens, want) {
+		t.Errorf("Allergens = %q, want %q", est.Allergens, want)
+	}
+	if est.Coverage != 0.8 || est.Confidence != ConfidenceMedium {
+		t.Errorf("Coverage, Confidence = %v, %q, want 0.8, %q", est.Coverage, est.Confidence, ConfidenceMedium)
+	}
+}
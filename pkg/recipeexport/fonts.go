@@ -0,0 +1,117 @@
+package recipeexport
+
+import "strings"
+
+// The PDF uses the standard Helvetica fonts every reader has, so nothing is
+// embedded. Their widths, in thousandths of the font size, are needed to
+// wrap lines; the oblique face has the regular widths.
+
+// asciiWidths are the widths of the printable ASCII characters from the space
+var asciiWidths = map[pdfFont][95]uint16{
+	fontRegular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	fontBold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// symbolWidths are the WinAnsi characters above ASCII that are not accented
+// letters, as regular and bold widths
+var symbolWidths = map[byte][2]uint16{
+	0x80: {556, 556}, 0x82: {222, 278}, 0x83: {556, 556}, 0x84: {333, 500}, 0x85: {1000, 1000},
+	0x86: {556, 556}, 0x87: {556, 556}, 0x88: {333, 333}, 0x89: {1000, 1000}, 0x8A: {667, 667},
+	0x8B: {333, 333}, 0x8C: {1000, 1000}, 0x8E: {611, 611}, 0x91: {222, 278}, 0x92: {222, 278},
+	0x93: {333, 500}, 0x94: {333, 500}, 0x95: {350, 350}, 0x96: {556, 556}, 0x97: {1000, 1000},
+	0x98: {333, 333}, 0x99: {1000, 1000}, 0x9A: {500, 556}, 0x9B: {333, 333}, 0x9C: {944, 944},
+	0x9E: {500, 500}, 0x9F: {667, 667},
+	0xA0: {278, 278}, 0xA1: {333, 333}, 0xA2: {556, 556}, 0xA3: {556, 556}, 0xA4: {556, 556},
+	0xA5: {556, 556}, 0xA6: {260, 280}, 0xA7: {556, 556}, 0xA8: {333, 333}, 0xA9: {737, 737},
+	0xAA: {370, 370}, 0xAB: {556, 556}, 0xAC: {584, 584}, 0xAD: {333, 333}, 0xAE: {737, 737},
+	0xAF: {333, 333}, 0xB0: {400, 400}, 0xB1: {584, 584}, 0xB2: {333, 333}, 0xB3: {333, 333},
+	0xB4: {333, 333}, 0xB5: {556, 611}, 0xB6: {537, 556}, 0xB7: {278, 278}, 0xB8: {333, 333},
+	0xB9: {333, 333}, 0xBA: {365, 365}, 0xBB: {556, 556}, 0xBC: {834, 834}, 0xBD: {834, 834},
+	0xBE: {834, 834}, 0xBF: {611, 611},
+	0xC6: {1000, 1000}, 0xD7: {584, 584}, 0xDF: {611, 611}, 0xE6: {889, 889}, 0xF7: {584, 584},
+	0xEC: {278, 278}, 0xED: {278, 278}, 0xEE: {278, 278}, 0xEF: {278, 278},
+}
+
+// accentedBase gives, from 0xC0, the unaccented letter whose width an
+// accented Latin-1 letter shares; '*' entries are in symbolWidths
+const accentedBase = "AAAAAA*CEEEEIIIIDNOOOOO*OUUUUYP*aaaaaa*ceeee****onooooo*ouuuuypy"
+
+// charWidth returns the width of a WinAnsi character
+func charWidth(font pdfFont, c byte) float64 {
+	widths := asciiWidths[fontRegular]
+	face := 0
+	if font == fontBold {
+		widths = asciiWidths[fontBold]
+		face = 1
+	}
+	switch {
+	case c >= 32 && c <= 126:
+		return float64(widths[c-32])
+	case c >= 0xC0 && accentedBase[c-0xC0] != '*':
+		return float64(widths[accentedBase[c-0xC0]-32])
+	}
+	if w, ok := symbolWidths[c]; ok {
+		return float64(w[face])
+	}
+	return float64(widths['?'-32])
+}
+
+// textWidth returns the width in points of WinAnsi text at size
+func textWidth(font pdfFont, size float64, text []byte) float64 {
+	total := 0.0
+	for _, c := range text {
+		total += charWidth(font, c)
+	}
+	return total * size / 1000
+}
+
+// winAnsiSpecials are the characters WinAnsi places in 0x80-0x9F
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// transliterations stand in for characters WinAnsi lacks that recipes use
+var transliterations = strings.NewReplacer(
+	"⅓", "1/3", "⅔", "2/3", "⅕", "1/5", "⅙", "1/6", "⅛", "1/8", "⅜", "3/8", "⅝", "5/8", "⅞", "7/8",
+	"⁄", "/", "−", "-", "‐", "-", "‑", "-", "′", "'", "″", "\"", "≈", "~", "℃", "°C", "℉", "°F",
+	"\u2009", " ", "\u202f", " ", "\u200b", "",
+)
+
+// winAnsi encodes text for the standard fonts, replacing characters they
+// cannot show with '?'
+func winAnsi(text string) []byte {
+	text = transliterations.Replace(text)
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 32 && r <= 126, r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		case r == '\t':
+			out = append(out, ' ')
+		default:
+			if c, ok := winAnsiSpecials[r]; ok {
+				out = append(out, c)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package recipeexport
+
+import (
+	"bytes"
+	"html/template"
+)
+
+var htmlTemplate = template.Must(template.New("recipe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+@page { size: A4; margin: 18mm 16mm; }
+* { box-sizing: border-box; }
+body { margin: 0 auto; max-width: 760px; padding: 32px 24px; color: #222; font: 15px/1.55 Georgia, "Times New Roman", serif; }
+h1 { margin: 0 0 4px; font: 700 30px/1.2 "Helvetica Neue", Arial, sans-serif; }
+h2 { margin: 28px 0 10px; padding-bottom: 4px; border-bottom: 1px solid #ddd; font: 700 18px/1.3 "Helvetica Neue", Arial, sans-serif; }
+.subtitle { margin: 0 0 16px; color: #666; font-style: italic; }
+.photo { display: block; width: 100%; max-height: 360px; object-fit: cover; margin: 16px 0; border-radius: 6px; }
+.facts { display: flex; flex-wrap: wrap; gap: 8px 24px; margin: 16px 0; padding: 0; list-style: none; font: 13px/1.4 "Helvetica Neue", Arial, sans-serif; }
+.facts dt { color: #777; text-transform: uppercase; letter-spacing: .04em; font-size: 11px; }
+.facts dd { margin: 0; font-weight: 600; }
+.ingredients { margin: 0; padding-left: 20px; columns: 2; column-gap: 32px; }
+.ingredients li { break-inside: avoid; margin-bottom: 4px; }
+.measure { font-weight: 700; }
+.note { color: #666; font-size: 13px; font-style: italic; }
+.steps { margin: 0; padding-left: 24px; }
+.steps li { break-inside: avoid; margin-bottom: 10px; padding-left: 4px; }
+footer { margin-top: 32px; padding-top: 10px; border-top: 1px solid #ddd; color: #666; font-size: 12px; }
+footer p { margin: 2px 0; }
+footer a { color: inherit; word-break: break-all; }
+@media (max-width: 560px) { .ingredients { columns: 1; } }
+@media print {
+  body { max-width: none; padding: 0; font-size: 12pt; }
+  .photo { max-height: 80mm; }
+  a { text-decoration: none; }
+  h2 { break-after: avoid; }
+}
+</style>
+</head>
+<body>
+<article>
+<h1>{{.Title}}</h1>
+{{if .Subtitle}}<p class="subtitle">{{.Subtitle}}</p>{{end}}
+{{if .ImageURL}}<img class="photo" src="{{.ImageURL}}" alt="{{.Title}}">{{end}}
+{{if .Facts}}<dl class="facts">{{range .Facts}}<div><dt>{{.Label}}</dt><dd>{{.Value}}</dd></div>{{end}}</dl>{{end}}
+{{if .Ingredients}}<h2>Ingredients</h2>
+<ul class="ingredients">
+{{range .Ingredients}}<li>{{if .Measure}}<span class="measure">{{.Measure}}</span> {{end}}{{.Name}}</li>
+{{end}}</ul>
+{{if .IngredientNote}}<p class="note">{{.IngredientNote}}</p>{{end}}{{end}}
+{{if .Steps}}<h2>Method</h2>
+<ol class="steps">
+{{range .Steps}}<li>{{.}}</li>
+{{end}}</ol>{{end}}
+{{if or .Links .Attribution}}<footer>
+{{range .Links}}<p>{{.Label}}: <a href="{{.URL}}">{{.URL}}</a></p>
+{{end}}{{if .Attribution}}<p>{{.Attribution}}</p>{{end}}
+</footer>{{end}}
+</article>
+</body>
+</html>
+`))
+
+// HTML writes the recipe as a standalone page laid out for printing
+func HTML(r *Recipe) ([]byte, error) {
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, r); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
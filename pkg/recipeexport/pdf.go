@@ -0,0 +1,302 @@
+package recipeexport
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+type pdfFont int
+
+const (
+	fontRegular pdfFont = iota
+	fontBold
+	fontItalic
+)
+
+// pdfFontNames are the resource names and base fonts of the PDF's fonts
+var pdfFontNames = []struct{ resource, base string }{
+	fontRegular: {"F1", "Helvetica"},
+	fontBold:    {"F2", "Helvetica-Bold"},
+	fontItalic:  {"F3", "Helvetica-Oblique"},
+}
+
+// A4 in points, with the margins of the printable area
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	marginX      = 56.0
+	marginTop    = 60.0
+	marginBottom = 70.0
+	contentWidth = pageWidth - 2*marginX
+)
+
+const (
+	textGray  = "0.13"
+	mutedGray = "0.4"
+	ruleGray  = "0.8"
+)
+
+// span is a run of text in one font
+type span struct {
+	font pdfFont
+	text string
+}
+
+// word is one word of a span, encoded for the font
+type word struct {
+	font  pdfFont
+	text  []byte
+	width float64
+}
+
+// pdfLayout lays text out top to bottom over as many pages as it needs
+type pdfLayout struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+// PDF writes the recipe as an A4 document. Images are left out, and text the
+// standard fonts cannot show is replaced.
+func PDF(r *Recipe) ([]byte, error) {
+	l := &pdfLayout{}
+	l.newPage()
+
+	l.paragraph([]span{{fontBold, r.Title}}, 22, marginX, contentWidth, 27, textGray)
+	if r.Subtitle != "" {
+		l.y -= 2
+		l.paragraph([]span{{fontItalic, r.Subtitle}}, 12, marginX, contentWidth, 16, mutedGray)
+	}
+	if len(r.Facts) > 0 {
+		l.y -= 10
+		for _, fact := range r.Facts {
+			l.paragraph([]span{{fontBold, fact.Label + ":"}, {fontRegular, fact.Value}}, 10.5, marginX, contentWidth, 15, textGray)
+		}
+	}
+
+	if len(r.Ingredients) > 0 {
+		l.heading("Ingredients")
+		for _, ingredient := range r.Ingredients {
+			l.keep(15)
+			l.text(marginX+2, l.y-10.5, fontRegular, 10.5, textGray, winAnsi("•"))
+			spans := []span{{fontRegular, ingredient.Name}}
+			if ingredient.Measure != "" {
+				spans = []span{{fontBold, ingredient.Measure}, {fontRegular, ingredient.Name}}
+			}
+			l.paragraph(spans, 10.5, marginX+14, contentWidth-14, 15, textGray)
+			l.y -= 2
+		}
+		if r.IngredientNote != "" {
+			l.y -= 4
+			l.paragraph([]span{{fontItalic, r.IngredientNote}}, 9.5, marginX, contentWidth, 13, mutedGray)
+		}
+	}
+
+	if len(r.Steps) > 0 {
+		l.heading("Method")
+		for i, step := range r.Steps {
+			l.keep(15)
+			l.text(marginX, l.y-10.5, fontBold, 10.5, textGray, []byte(fmt.Sprintf("%d.", i+1)))
+			l.paragraph([]span{{fontRegular, step}}, 10.5, marginX+22, contentWidth-22, 15, textGray)
+			l.y -= 7
+		}
+	}
+
+	if len(r.Links) > 0 || r.Attribution != "" {
+		l.keep(40)
+		l.y -= 14
+		l.rule(l.y)
+		l.y -= 8
+		for _, link := range r.Links {
+			l.paragraph([]span{{fontRegular, link.Label + ": " + link.URL}}, 9, marginX, contentWidth, 12, mutedGray)
+		}
+		if r.Attribution != "" {
+			l.paragraph([]span{{fontItalic, r.Attribution}}, 9, marginX, contentWidth, 12, mutedGray)
+		}
+	}
+
+	return l.document(r.Title)
+}
+
+func (l *pdfLayout) newPage() {
+	l.page = &bytes.Buffer{}
+	l.pages = append(l.pages, l.page)
+	l.y = pageHeight - marginTop
+}
+
+// keep starts a new page unless height fits above the bottom margin
+func (l *pdfLayout) keep(height float64) {
+	if l.y-height < marginBottom {
+		l.newPage()
+	}
+}
+
+// heading writes a section heading, keeping it on a page with the first line
+// of its section
+func (l *pdfLayout) heading(title string) {
+	l.y -= 18
+	l.keep(22 + 30)
+	l.text(marginX, l.y-14, fontBold, 14, textGray, winAnsi(title))
+	l.y -= 20
+	l.rule(l.y)
+	l.y -= 8
+}
+
+// paragraph wraps spans to width and writes them, moving down leading per
+// line
+func (l *pdfLayout) paragraph(spans []span, size, x, width, leading float64, gray string) {
+	for _, line := range wrap(spans, size, width) {
+		l.keep(leading)
+		// Words in the same font are written as one run, so the text copies
+		// out with its spaces
+		cx := x
+		for start := 0; start < len(line); {
+			end := start
+			run := append([]byte{}, line[start].text...)
+			runWidth := line[start].width
+			for end+1 < len(line) && line[end+1].font == line[start].font {
+				end++
+				run = append(append(run, ' '), line[end].text...)
+				runWidth += textWidth(line[end].font, size, []byte{' '}) + line[end].width
+			}
+			l.text(cx, l.y-size, line[start].font, size, gray, run)
+			cx += runWidth
+			if end+1 < len(line) {
+				cx += textWidth(line[end+1].font, size, []byte{' '})
+			}
+			start = end + 1
+		}
+		l.y -= leading
+	}
+}
+
+func (l *pdfLayout) text(x, y float64, font pdfFont, size float64, gray string, text []byte) {
+	fmt.Fprintf(l.page, "BT /%s %.1f Tf %s g %.2f %.2f Td (%s) Tj ET\n",
+		pdfFontNames[font].resource, size, gray, x, y, escapePDF(text))
+}
+
+func (l *pdfLayout) rule(y float64) {
+	fmt.Fprintf(l.page, "%s G 0.5 w %.2f %.2f m %.2f %.2f l S\n", ruleGray, marginX, y, pageWidth-marginX, y)
+}
+
+// wrap breaks spans into lines no wider than width. A word wider than a line
+// is split across lines.
+func wrap(spans []span, size, width float64) [][]word {
+	var words []word
+	for _, s := range spans {
+		for _, field := range strings.Fields(s.text) {
+			text := winAnsi(field)
+			for len(text) > 0 {
+				n := fitting(s.font, size, text, width)
+				words = append(words, word{font: s.font, text: text[:n], width: textWidth(s.font, size, text[:n])})
+				text = text[n:]
+			}
+		}
+	}
+
+	var lines [][]word
+	var line []word
+	lineWidth := 0.0
+	for _, w := range words {
+		space := 0.0
+		if len(line) > 0 {
+			space = textWidth(w.font, size, []byte{' '})
+		}
+		if len(line) > 0 && lineWidth+space+w.width > width {
+			lines = append(lines, line)
+			line, lineWidth, space = nil, 0, 0
+		}
+		line = append(line, w)
+		lineWidth += space + w.width
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitting returns how many bytes of text fit in width, at least one
+func fitting(font pdfFont, size float64, text []byte, width float64) int {
+	total := 0.0
+	for i, c := range text {
+		total += charWidth(font, c) * size / 1000
+		if total > width && i > 0 {
+			return i
+		}
+	}
+	return len(text)
+}
+
+func escapePDF(text []byte) []byte {
+	out := make([]byte, 0, len(text))
+	for _, c := range text {
+		if c == '(' || c == ')' || c == '\\' {
+			out = append(out, '\\')
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// document numbers the pages and writes the file: catalog, page tree, fonts
+// and info, then each page with its compressed content stream
+func (l *pdfLayout) document(title string) ([]byte, error) {
+	footer := winAnsi(title)
+	for i, page := range l.pages {
+		label := append(append([]byte{}, footer...), winAnsi(fmt.Sprintf(" — page %d of %d", i+1, len(l.pages)))...)
+		x := (pageWidth - textWidth(fontRegular, 8, label)) / 2
+		if x < marginX {
+			label = winAnsi(fmt.Sprintf("Page %d of %d", i+1, len(l.pages)))
+			x = (pageWidth - textWidth(fontRegular, 8, label)) / 2
+		}
+		fmt.Fprintf(page, "BT /%s 8.0 Tf %s g %.2f %.2f Td (%s) Tj ET\n",
+			pdfFontNames[fontRegular].resource, mutedGray, x, marginBottom/2, escapePDF(label))
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-6 are fixed; page i is object 7+2i and its content 8+2i
+	kids := make([]string, len(l.pages))
+	for i := range l.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 7+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(l.pages)))
+	for _, font := range pdfFontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.base))
+	}
+	object(fmt.Sprintf("<< /Title (%s) /Producer (Nomie) >>", escapePDF(winAnsi(title))))
+
+	for _, page := range l.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, len(offsets)+2))
+
+		var stream bytes.Buffer
+		zw := zlib.NewWriter(&stream)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes(), nil
+}
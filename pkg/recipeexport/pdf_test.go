@@ -0,0 +1,163 @@
+package recipeexport
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	startXrefRe = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	xrefRe      = regexp.MustCompile(`^xref\n0 (\d+)\n`)
+	trailerRe   = regexp.MustCompile(`trailer\n<< /Size (\d+) /Root 1 0 R`)
+	pageCountRe = regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`)
+	lengthRe    = regexp.MustCompile(`^<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`)
+)
+
+// pdfObjects checks that the cross-reference table points at each object and
+// returns the bodies of the objects, numbered from 1
+func pdfObjects(t *testing.T, doc []byte) []string {
+	t.Helper()
+	if !bytes.HasPrefix(doc, []byte("%PDF-1.4\n")) {
+		t.Fatal("missing PDF header")
+	}
+
+	m := startXrefRe.FindSubmatch(doc)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if xref >= len(doc) {
+		t.Fatalf("startxref %d is past the end of the file", xref)
+	}
+	table := doc[xref:]
+	m = xrefRe.FindSubmatch(table)
+	if m == nil {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	size, _ := strconv.Atoi(string(m[1]))
+	if m := trailerRe.FindSubmatch(doc); m == nil || string(m[1]) != strconv.Itoa(size) {
+		t.Fatalf("trailer /Size does not match the xref table of %d entries", size)
+	}
+
+	// Entries are 20 bytes each; entry 0 is the head of the free list
+	entries := table[len(m[0]):]
+	if !bytes.HasPrefix(entries, []byte("0000000000 65535 f \n")) {
+		t.Fatal("xref table does not start with the free entry")
+	}
+	offsets := make([]int, size)
+	for i := 1; i < size; i++ {
+		entry := string(entries[20*i : 20*i+20])
+		if !strings.HasSuffix(entry, " 00000 n \n") {
+			t.Fatalf("xref entry %d = %q", i, entry)
+		}
+		offsets[i], _ = strconv.Atoi(entry[:10])
+	}
+
+	objects := make([]string, size)
+	for i := 1; i < size; i++ {
+		header := fmt.Sprintf("%d 0 obj\n", i)
+		if !bytes.HasPrefix(doc[offsets[i]:], []byte(header)) {
+			t.Fatalf("xref offset %d of object %d points at %q", offsets[i], i, doc[offsets[i]:offsets[i]+12])
+		}
+		body := doc[offsets[i]+len(header):]
+		end := bytes.Index(body, []byte("\nendobj\n"))
+		if m := lengthRe.FindSubmatch(body); m != nil {
+			// A compressed stream can hold the end marker by chance, so its
+			// end is found from its length
+			length, _ := strconv.Atoi(string(m[1]))
+			end = len(m[0]) + length + len("\nendstream")
+			if !bytes.HasPrefix(body[end:], []byte("\nendobj\n")) {
+				t.Fatalf("stream of object %d does not end at its /Length", i)
+			}
+		}
+		if end < 0 {
+			t.Fatalf("object %d has no endobj", i)
+		}
+		objects[i] = string(body[:end])
+	}
+	return objects
+}
+
+// pageText returns the uncompressed content stream of a page object
+func pageText(t *testing.T, object string) string {
+	t.Helper()
+	m := lengthRe.FindStringSubmatch(object)
+	if m == nil {
+		t.Fatalf("not a content stream: %.40q", object)
+	}
+	length, _ := strconv.Atoi(m[1])
+	zr, err := zlib.NewReader(strings.NewReader(object[len(m[0]) : len(m[0])+length]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(text)
+}
+
+func TestPDF(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps int
+		pages int
+	}{
+		{"one page", 3, 1},
+		{"several pages", 120, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Recipe{
+				Title:       "Beef (and Ale) Stew",
+				Facts:       []Fact{{Label: "Cuisine", Value: "British"}},
+				Ingredients: []Ingredient{{Measure: "1 kg", Name: "Beef"}, {Name: "Salt"}},
+			}
+			for i := 0; i < tt.steps; i++ {
+				r.Steps = append(r.Steps, fmt.Sprintf("Stir the stew and simmer gently for another %d minutes.", i+1))
+			}
+
+			doc, err := PDF(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			objects := pdfObjects(t, doc)
+
+			m := pageCountRe.FindStringSubmatch(objects[2])
+			if m == nil {
+				t.Fatalf("object 2 is not the page tree: %q", objects[2])
+			}
+			if count, _ := strconv.Atoi(m[1]); count != tt.pages {
+				t.Fatalf("/Count = %d, want %d", count, tt.pages)
+			}
+			if want := 7 + 2*tt.pages; len(objects) != want {
+				t.Fatalf("xref has %d entries, want %d", len(objects), want)
+			}
+
+			var text strings.Builder
+			for i := 0; i < tt.pages; i++ {
+				page, content := objects[7+2*i], objects[8+2*i]
+				if !strings.Contains(page, "/Type /Page ") || !strings.Contains(page, fmt.Sprintf("/Contents %d 0 R", 8+2*i)) {
+					t.Errorf("object %d is not page %d: %q", 7+2*i, i+1, page)
+				}
+				stream := pageText(t, content)
+				if footer := fmt.Sprintf("page %d of %d", i+1, tt.pages); !strings.Contains(stream, footer) {
+					t.Errorf("page %d has no %q footer", i+1, footer)
+				}
+				text.WriteString(stream)
+			}
+			for _, want := range []string{`(Beef \(and Ale\) Stew) Tj`, fmt.Sprintf("another %d minutes.", tt.steps)} {
+				if !strings.Contains(text.String(), want) {
+					t.Errorf("pages do not contain %q", want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Package recipeexport renders a recipe for printing or sharing as Markdown,
+// a printable HTML page or a PDF, using only the standard library.
+package recipeexport
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Formats a recipe can be exported in
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatPDF      = "pdf"
+)
+
+// Recipe is what an export shows. Every field but Title may be empty.
+type Recipe struct {
+	Title    string
+	Subtitle string
+	ImageURL string
+	// Facts are short labelled values such as the cuisine or cooking time
+	Facts       []Fact
+	Ingredients []Ingredient
+	// IngredientNote explains the measures, e.g. that they were scaled
+	IngredientNote string
+	Steps          []string
+	Links          []Link
+	Attribution    string
+}
+
+type Fact struct {
+	Label string
+	Value string
+}
+
+type Ingredient struct {
+	Measure string
+	Name    string
+}
+
+type Link struct {
+	Label string
+	URL   string
+}
+
+// Render writes the recipe in format and returns it with its content type
+// and file extension
+func Render(r *Recipe, format string) ([]byte, string, error) {
+	switch format {
+	case FormatMarkdown:
+		return Markdown(r), "text/markdown; charset=utf-8", nil
+	case FormatHTML:
+		out, err := HTML(r)
+		return out, "text/html; charset=utf-8", err
+	case FormatPDF:
+		out, err := PDF(r)
+		return out, "application/pdf", err
+	}
+	return nil, "", fmt.Errorf("unknown export format %q", format)
+}
+
+// ValidFormat reports whether format is one Render accepts
+func ValidFormat(format string) bool {
+	return format == FormatMarkdown || format == FormatHTML || format == FormatPDF
+}
+
+// FileName returns a file name for the recipe from its title
+func FileName(r *Recipe, format string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(r.Title) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "-")
+	if len(name) > 60 {
+		name = strings.TrimSuffix(name[:60], "-")
+	}
+	if name == "" {
+		name = "recipe"
+	}
+	return name + "." + format
+}
+
+// Markdown writes the recipe as CommonMark
+func Markdown(r *Recipe) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n\n", markdownText(r.Title))
+	if r.Subtitle != "" {
+		fmt.Fprintf(&b, "*%s*\n\n", markdownText(r.Subtitle))
+	}
+	if r.ImageURL != "" {
+		fmt.Fprintf(&b, "![%s](<%s>)\n\n", markdownText(r.Title), markdownURL(r.ImageURL))
+	}
+	for _, fact := range r.Facts {
+		fmt.Fprintf(&b, "- **%s:** %s\n", markdownText(fact.Label), markdownText(fact.Value))
+	}
+	if len(r.Facts) > 0 {
+		b.WriteString("\n")
+	}
+
+	if len(r.Ingredients) > 0 {
+		b.WriteString("## Ingredients\n\n")
+		for _, ingredient := range r.Ingredients {
+			if ingredient.Measure != "" {
+				fmt.Fprintf(&b, "- %s %s\n", markdownText(ingredient.Measure), markdownText(ingredient.Name))
+			} else {
+				fmt.Fprintf(&b, "- %s\n", markdownText(ingredient.Name))
+			}
+		}
+		b.WriteString("\n")
+		if r.IngredientNote != "" {
+			fmt.Fprintf(&b, "*%s*\n\n", markdownText(r.IngredientNote))
+		}
+	}
+
+	if len(r.Steps) > 0 {
+		b.WriteString("## Method\n\n")
+		for i, step := range r.Steps {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, markdownText(step))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Links) > 0 || r.Attribution != "" {
+		b.WriteString("---\n\n")
+		for _, link := range r.Links {
+			fmt.Fprintf(&b, "%s: <%s>  \n", markdownText(link.Label), markdownURL(link.URL))
+		}
+		if r.Attribution != "" {
+			fmt.Fprintf(&b, "%s\n", markdownText(r.Attribution))
+		}
+	}
+	return append(bytes.TrimRight(b.Bytes(), "\n "), '\n')
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+// markdownText escapes text for inline use and folds it onto one line
+func markdownText(s string) string {
+	return markdownEscaper.Replace(strings.Join(strings.Fields(s), " "))
+}
+
+// markdownURL percent-encodes what would end or break a URL written between
+// angle brackets: the brackets themselves, spaces and control characters
+func markdownURL(u string) string {
+	var b strings.Builder
+	for i := 0; i < len(u); i++ {
+		switch c := u[i]; {
+		case c == '<' || c == '>' || c <= ' ' || c == 0x7f:
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package recipeexport
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	r := &Recipe{
+		Title:       "Fish *and* Chips",
+		ImageURL:    "https://cdn.example.com/fish and chips>.jpg",
+		Ingredients: []Ingredient{{Measure: "2", Name: "cod_fillets"}},
+		Steps:       []string{"Heat the oil\nto 180C.", "# Fry"},
+		Links: []Link{
+			{Label: "Source", URL: "https://kitchen.example.com/recipes?q=<fish>"},
+		},
+		Attribution: "From [Nomie]",
+	}
+
+	// The link line ends in a hard line break
+	want := `# Fish \*and\* Chips
+
+![Fish \*and\* Chips](<https://cdn.example.com/fish%20and%20chips%3E.jpg>)
+
+## Ingredients
+
+- 2 cod\_fillets
+
+## Method
+
+1. Heat the oil to 180C.
+2. \# Fry
+
+---
+
+Source: <https://kitchen.example.com/recipes?q=%3Cfish%3E>` + "  " + `
+From \[Nomie\]
+`
+	if got := string(Markdown(r)); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMarkdownURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/a?b=c&d=e#f", "https://example.com/a?b=c&d=e#f"},
+		{"https://example.com/a b", "https://example.com/a%20b"},
+		{"https://example.com/<a>", "https://example.com/%3Ca%3E"},
+		{"https://example.com/a\nb", "https://example.com/a%0Ab"},
+		{"https://example.com/café", "https://example.com/café"},
+	}
+
+	for _, tt := range tests {
+		if got := markdownURL(tt.url); got != tt.want {
+			t.Errorf("markdownURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Fish & Chips", "fish-chips.pdf"},
+		{"  Crème brûlée!  ", "cr-me-br-l-e.pdf"},
+		{"***", "recipe.pdf"},
+		{strings.Repeat("abc ", 20), strings.TrimSuffix(strings.Repeat("abc-", 15), "-") + ".pdf"},
+	}
+
+	for _, tt := range tests {
+		if got := FileName(&Recipe{Title: tt.title}, FormatPDF); got != tt.want {
+			t.Errorf("FileName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package recipeimport reads a recipe from a web page using its schema.org
+// Recipe markup, as JSON-LD or microdata. Pages are fetched with limits on
+// size and time, and only from public network addresses.
+package recipeimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultMaxBytes     = 2 << 20
+	DefaultMaxRedirects = 5
+)
+
+var (
+	ErrInvalidURL     = errors.New("invalid recipe URL")
+	ErrBlockedAddress = errors.New("recipe URL points to a private network address")
+	ErrTooLarge       = errors.New("recipe page is too large")
+	ErrNotHTML        = errors.New("recipe URL is not a web page")
+	ErrFetchFailed    = errors.New("could not fetch recipe page")
+	ErrNoRecipe       = errors.New("no recipe found on the page")
+)
+
+var errTooManyRedirects = errors.New("too many redirects")
+
+// Options configure an Importer; zero values use the defaults
+type Options struct {
+	Timeout      time.Duration
+	MaxBytes     int64
+	MaxRedirects int
+	// AllowPrivateNetworks disables the address check, for tests against a
+	// local server. Never set it for user-supplied URLs.
+	AllowPrivateNetworks bool
+}
+
+// Importer fetches pages and reads recipes from them
+type Importer struct {
+	client       *http.Client
+	maxBytes     int64
+	allowPrivate bool
+}
+
+func New(opts Options) *Importer {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = DefaultMaxRedirects
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		// Checked on the resolved address at connect time, so a name that
+		// resolves differently on a second lookup cannot get through
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return ErrBlockedAddress
+			}
+			if isBlocked(addrPort.Addr()) {
+				return ErrBlockedAddress
+			}
+			return nil
+		}
+	}
+
+	transport := &http.Transport{
+		// No proxy: the address check must apply to the page's own host
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.Timeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	im := &Importer{maxBytes: opts.MaxBytes, allowPrivate: opts.AllowPrivateNetworks}
+	im.client = &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= opts.MaxRedirects {
+				return errTooManyRedirects
+			}
+			return im.checkURL(req.URL)
+		},
+	}
+	return im
+}
+
+// Import fetches the page at rawURL and reads its recipe
+func (im *Importer) Import(ctx context.Context, rawURL string) (*Recipe, error) {
+	pageURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	if err := im.checkURL(pageURL); err != nil {
+		return nil, err
+	}
+
+	body, finalURL, err := im.fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	recipe, err := Parse(strings.NewReader(body), finalURL)
+	if err != nil {
+		return nil, err
+	}
+	recipe.SourceURL = finalURL.String()
+	return recipe, nil
+}
+
+func (im *Importer) fetch(ctx context.Context, pageURL *url.URL) (string, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return "", nil, ErrInvalidURL
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "NomieRecipeImporter/1.0")
+
+	resp, err := im.client.Do(req)
+	if err != nil {
+		return "", nil, fetchError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", nil, fmt.Errorf("%w: the page returned %s", ErrFetchFailed, resp.Status)
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", nil, ErrNotHTML
+	}
+	if resp.ContentLength > im.maxBytes {
+		return "", nil, ErrTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, im.maxBytes+1))
+	if err != nil {
+		return "", nil, fetchError(err)
+	}
+	if int64(len(data)) > im.maxBytes {
+		return "", nil, ErrTooLarge
+	}
+	return string(data), resp.Request.URL, nil
+}
+
+// fetchError keeps a refused address or URL as its sentinel and reports
+// anything else, timeouts included, as a failed fetch
+func fetchError(err error) error {
+	for _, sentinel := range []error{ErrBlockedAddress, ErrInvalidURL} {
+		if errors.Is(err, sentinel) {
+			return sentinel
+		}
+	}
+	if errors.Is(err, errTooManyRedirects) {
+		return fmt.Errorf("%w: too many redirects", ErrFetchFailed)
+	}
+	if errors.Is(err, context.DeadlineExceeded) || isTimeout(err) {
+		return fmt.Errorf("%w: timed out", ErrFetchFailed)
+	}
+	return fmt.Errorf("%w: %v", ErrFetchFailed, err)
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// checkURL accepts absolute http and https URLs whose host is not an IP
+// literal in a blocked range. Host names are checked once resolved.
+func (im *Importer) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: only http and https are supported", ErrInvalidURL)
+	}
+	if u.Hostname() == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !im.allowPrivate && isBlocked(addr) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// blockedPrefixes are ranges not covered by the netip helpers that are still
+// not reachable on the public internet
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// isBlocked reports whether addr is loopback, private, link-local (which
+// includes cloud metadata endpoints), multicast or otherwise reserved
+func isBlocked(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,493 @@
+package recipeimport
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Recipe is what could be read from a page. Text is plain, with markup and
+// entities removed, and image URLs are absolute.
+type Recipe struct {
+	Name         string
+	Description  string
+	Images       []string
+	Ingredients  []string
+	Instructions []string
+	PrepTime     time.Duration
+	CookTime     time.Duration
+	TotalTime    time.Duration
+	// Yield is the recipe's own text, Servings the number read from it
+	Yield     string
+	Servings  int
+	Category  string
+	Cuisine   string
+	Keywords  []string
+	VideoURL  string
+	SourceURL string
+}
+
+// maxSearchDepth bounds how deep JSON-LD is searched for a recipe
+const maxSearchDepth = 8
+
+// Parse reads the schema.org Recipe of an HTML page. JSON-LD is preferred;
+// microdata is the fallback. base resolves relative image URLs.
+func Parse(r io.Reader, base *url.URL) (*Recipe, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, ErrNoRecipe
+	}
+
+	var scripts []string
+	var microdata *html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case n.DataAtom == atom.Script && strings.EqualFold(strings.TrimSpace(attr(n, "type")), "application/ld+json"):
+				if n.FirstChild != nil {
+					scripts = append(scripts, n.FirstChild.Data)
+				}
+				return
+			case microdata == nil && hasAttr(n, "itemscope") && isRecipeType(attr(n, "itemtype")):
+				microdata = n
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	for _, script := range scripts {
+		var data interface{}
+		if err := json.Unmarshal([]byte(script), &data); err != nil {
+			continue
+		}
+		if node := findRecipe(data, 0); node != nil {
+			if recipe := fromSchema(node, base); recipe.valid() {
+				return recipe, nil
+			}
+		}
+	}
+
+	if microdata != nil {
+		if recipe := fromSchema(microdataItem(microdata), base); recipe.valid() {
+			return recipe, nil
+		}
+	}
+	return nil, ErrNoRecipe
+}
+
+func (r *Recipe) valid() bool {
+	return r.Name != "" && (len(r.Ingredients) > 0 || len(r.Instructions) > 0)
+}
+
+// findRecipe returns the first object typed as a Recipe, searching @graph
+// lists and nested objects such as mainEntity
+func findRecipe(v interface{}, depth int) map[string]interface{} {
+	if depth > maxSearchDepth {
+		return nil
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, t := range list(v["@type"]) {
+			if s, ok := t.(string); ok && isRecipeType(s) {
+				return v
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if found := findRecipe(v[key], depth+1); found != nil {
+				return found
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if found := findRecipe(child, depth+1); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// isRecipeType matches "Recipe" and schema.org URLs of it, in any of the
+// space separated types of a microdata itemtype
+func isRecipeType(t string) bool {
+	for _, t := range strings.Fields(t) {
+		if t == "Recipe" || strings.HasSuffix(t, "schema.org/Recipe") {
+			return true
+		}
+	}
+	return false
+}
+
+func fromSchema(m map[string]interface{}, base *url.URL) *Recipe {
+	r := &Recipe{
+		Name:         text(m["name"]),
+		Description:  text(m["description"]),
+		Images:       imageURLs(m["image"], base),
+		Ingredients:  texts(m["recipeIngredient"]),
+		Instructions: instructions(m["recipeInstructions"], 0),
+		PrepTime:     duration(text(m["prepTime"])),
+		CookTime:     duration(text(m["cookTime"])),
+		TotalTime:    duration(text(m["totalTime"])),
+		Category:     first(texts(m["recipeCategory"])),
+		Cuisine:      first(texts(m["recipeCuisine"])),
+		Keywords:     keywords(m["keywords"]),
+		VideoURL:     videoURL(m["video"], base),
+	}
+	if len(r.Ingredients) == 0 {
+		// The pre-2017 name of recipeIngredient, still common in microdata
+		r.Ingredients = texts(m["ingredients"])
+	}
+	if r.TotalTime == 0 {
+		r.TotalTime = r.PrepTime + r.CookTime
+	}
+
+	for _, yield := range texts(m["recipeYield"]) {
+		if r.Yield == "" {
+			r.Yield = yield
+		}
+		if n := firstNumber(yield); n > 0 && n <= 100 {
+			r.Yield, r.Servings = yield, n
+			break
+		}
+	}
+	return r
+}
+
+// list treats a single value as a list of one
+func list(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
+// text returns a value as one line of plain text
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.Join(strings.Fields(cleanText(v)), " ")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		for _, item := range v {
+			if s := text(item); s != "" {
+				return s
+			}
+		}
+	case map[string]interface{}:
+		for _, key := range []string{"@value", "name", "text"} {
+			if s := text(v[key]); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func texts(v interface{}) []string {
+	var out []string
+	for _, item := range list(v) {
+		if s := text(item); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+var (
+	blockBreakRe = regexp.MustCompile(`(?i)<\s*(br\s*/?|/p|/li|/div|/h[1-6]|/tr)\s*>`)
+	tagRe        = regexp.MustCompile(`<[^>]*>`)
+)
+
+// cleanText turns text that may hold markup or entities into plain text,
+// keeping line breaks where block elements ended. Entities are decoded first
+// since many sites escape the markup inside their JSON-LD.
+func cleanText(s string) string {
+	s = html.UnescapeString(s)
+	if strings.Contains(s, "<") {
+		s = blockBreakRe.ReplaceAllString(s, "\n")
+		s = tagRe.ReplaceAllString(s, "")
+	}
+	return s
+}
+
+// instructions flattens recipeInstructions, which may be one block of text,
+// a list of strings, HowToStep objects or HowToSections of steps
+func instructions(v interface{}, depth int) []string {
+	if depth > maxSearchDepth {
+		return nil
+	}
+	var steps []string
+	for _, item := range list(v) {
+		switch item := item.(type) {
+		case string:
+			for _, line := range strings.Split(cleanText(item), "\n") {
+				if line = strings.Join(strings.Fields(line), " "); line != "" {
+					steps = append(steps, line)
+				}
+			}
+		case map[string]interface{}:
+			if children, ok := item["itemListElement"]; ok {
+				steps = append(steps, instructions(children, depth+1)...)
+				continue
+			}
+			if s := text(item["text"]); s != "" {
+				steps = append(steps, s)
+			} else if s := text(item["name"]); s != "" {
+				steps = append(steps, s)
+			}
+		}
+	}
+	return steps
+}
+
+func keywords(v interface{}) []string {
+	var out []string
+	for _, item := range texts(v) {
+		for _, keyword := range strings.Split(item, ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				out = append(out, keyword)
+			}
+		}
+	}
+	return out
+}
+
+func imageURLs(v interface{}, base *url.URL) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, item := range list(v) {
+		var raw string
+		switch item := item.(type) {
+		case string:
+			raw = item
+		case map[string]interface{}:
+			raw = firstString(item, "url", "contentUrl")
+		}
+		if u := absoluteURL(raw, base); u != "" && !seen[u] {
+			seen[u] = true
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+func videoURL(v interface{}, base *url.URL) string {
+	for _, item := range list(v) {
+		var raw string
+		switch item := item.(type) {
+		case string:
+			raw = item
+		case map[string]interface{}:
+			raw = firstString(item, "contentUrl", "embedUrl", "url")
+		}
+		if u := absoluteURL(raw, base); u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+func firstString(m map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := m[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// absoluteURL resolves raw against base and keeps only http and https URLs
+func absoluteURL(raw string, base *url.URL) string {
+	raw = strings.TrimSpace(html.UnescapeString(raw))
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+var isoDurationRe = regexp.MustCompile(`(?i)^P(?:(\d+(?:\.\d+)?)W)?(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// duration parses an ISO 8601 duration such as "PT1H30M" or "P0DT20M"
+func duration(s string) time.Duration {
+	match := isoDurationRe.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return 0
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if match[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(match[i+1], 64)
+		if err != nil {
+			return 0
+		}
+		d += time.Duration(math.Round(n * float64(unit)))
+	}
+	return d
+}
+
+var numberRe = regexp.MustCompile(`\d+`)
+
+func firstNumber(s string) int {
+	n, err := strconv.Atoi(numberRe.FindString(s))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// microdataItem reads the properties of an itemscope element into the shape
+// JSON-LD decodes to, so both are mapped the same way. Nested items become
+// nested objects.
+func microdataItem(n *html.Node) map[string]interface{} {
+	item := map[string]interface{}{"@type": attr(n, "itemtype")}
+
+	var walk func(*html.Node)
+	walk = func(parent *html.Node) {
+		for c := parent.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			scoped := hasAttr(c, "itemscope")
+			if props := strings.Fields(attr(c, "itemprop")); len(props) > 0 {
+				var value interface{}
+				if scoped {
+					value = microdataItem(c)
+				} else {
+					value = propertyValue(c)
+				}
+				for _, prop := range props {
+					item[prop] = appendValue(item[prop], value)
+				}
+			}
+			if !scoped {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return item
+}
+
+func appendValue(existing, value interface{}) interface{} {
+	switch existing := existing.(type) {
+	case nil:
+		return value
+	case []interface{}:
+		return append(existing, value)
+	default:
+		return []interface{}{existing, value}
+	}
+}
+
+// propertyValue is the value of a microdata property by the element it is
+// on, per the HTML microdata rules
+func propertyValue(n *html.Node) interface{} {
+	if hasAttr(n, "content") {
+		return attr(n, "content")
+	}
+	switch n.DataAtom {
+	case atom.Img, atom.Audio, atom.Video, atom.Source, atom.Embed, atom.Iframe, atom.Track:
+		return attr(n, "src")
+	case atom.A, atom.Area, atom.Link:
+		return attr(n, "href")
+	case atom.Object:
+		return attr(n, "data")
+	case atom.Data, atom.Meter:
+		return attr(n, "value")
+	case atom.Time:
+		if hasAttr(n, "datetime") {
+			return attr(n, "datetime")
+		}
+	}
+	return nodeText(n)
+}
+
+// nodeText returns the text of an element with a line break after each
+// block element, so lists of steps stay one step per line
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if n.DataAtom == atom.Script || n.DataAtom == atom.Style {
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Br, atom.P, atom.Li, atom.Div, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Tr:
+				b.WriteString("\n")
+			}
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package recipeimport
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseFixture(t *testing.T, name, base string) (*Recipe, error) {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Parse(f, baseURL)
+}
+
+func TestParseJSONLD(t *testing.T) {
+	recipe, err := parseFixture(t, "jsonld.html", "https://kitchen.example.com/recipes/lemon-drizzle")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Recipe{
+		Name:        "Lemon Drizzle Cake",
+		Description: "A tangy loaf cake & a crunchy sugar topping.",
+		Images: []string{
+			"https://kitchen.example.com/images/lemon-drizzle.jpg",
+			"https://cdn.example.com/lemon-drizzle-wide.jpg",
+		},
+		Ingredients: []string{"225g unsalted butter", "225g caster sugar", "4 eggs", "1 lemon, zested"},
+		Instructions: []string{
+			"Heat the oven to 180C.",
+			"Beat the butter and sugar until pale.",
+			"Pour the drizzle over the warm cake.",
+		},
+		PrepTime:  15 * time.Minute,
+		CookTime:  45 * time.Minute,
+		TotalTime: time.Hour,
+		Yield:     "10",
+		Servings:  10,
+		Category:  "Dessert",
+		Cuisine:   "British",
+		Keywords:  []string{"cake", "lemon", "baking"},
+	}
+	if !reflect.DeepEqual(recipe, want) {
+		t.Errorf("Parse() = %+v\nwant %+v", recipe, want)
+	}
+}
+
+func TestParseMicrodata(t *testing.T) {
+	recipe, err := parseFixture(t, "microdata.html", "https://kitchen.example.com/recipes/soup")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if recipe.Name != "Tomato Soup" {
+		t.Errorf("Name = %q, want %q", recipe.Name, "Tomato Soup")
+	}
+	if want := []string{"https://kitchen.example.com/recipes/photos/soup.jpg"}; !reflect.DeepEqual(recipe.Images, want) {
+		t.Errorf("Images = %q, want %q", recipe.Images, want)
+	}
+	if want := []string{"2 tins chopped tomatoes", "1 onion", "500ml vegetable stock"}; !reflect.DeepEqual(recipe.Ingredients, want) {
+		t.Errorf("Ingredients = %q, want %q", recipe.Ingredients, want)
+	}
+	if want := []string{"Soften the onion.", "Add the tomatoes and stock and simmer for 20 minutes."}; !reflect.DeepEqual(recipe.Instructions, want) {
+		t.Errorf("Instructions = %q, want %q", recipe.Instructions, want)
+	}
+	if recipe.TotalTime != 30*time.Minute {
+		t.Errorf("TotalTime = %v, want 30m", recipe.TotalTime)
+	}
+	if recipe.Servings != 4 {
+		t.Errorf("Servings = %d, want 4", recipe.Servings)
+	}
+}
+
+func TestParseNoRecipe(t *testing.T) {
+	if _, err := parseFixture(t, "no-recipe.html", "https://kitchen.example.com/about"); !errors.Is(err, ErrNoRecipe) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrNoRecipe)
+	}
+}
+
+func TestParseSkipsInvalidJSONLD(t *testing.T) {
+	page := `<script type="application/ld+json">{not json</script>
+<script type="application/ld+json">{"@type": "Recipe", "name": "Toast", "recipeIngredient": ["bread"]}</script>`
+
+	recipe, err := Parse(strings.NewReader(page), &url.URL{Scheme: "https", Host: "kitchen.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipe.Name != "Toast" {
+		t.Errorf("Name = %q, want %q", recipe.Name, "Toast")
+	}
+}
@@ -0,0 +1,356 @@
+// Package steps splits free-text cooking instructions into numbered steps
+// and picks out durations, temperatures and the ingredients each step uses,
+// for a step-by-step cooking mode.
+package steps
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxSentencesPerStep groups the sentences of instructions written as one
+	// paragraph into steps of at most this many sentences
+	maxSentencesPerStep = 2
+	// minStepLength merges fragments shorter than this into the next step
+	minStepLength = 25
+	maxTimerLabel = 80
+)
+
+type Step struct {
+	Number       int           `json:"number"`
+	Text         string        `json:"text"`
+	Durations    []Duration    `json:"durations"`
+	Temperatures []Temperature `json:"temperatures"`
+	Ingredients  []string      `json:"ingredients"`
+	Timers       []Timer       `json:"timers"`
+}
+
+// Duration is a time mentioned in a step. Ranges like "20-25 minutes" set
+// MaxSeconds.
+type Duration struct {
+	Text       string `json:"text"`
+	Seconds    int    `json:"seconds"`
+	MaxSeconds int    `json:"maxSeconds,omitempty"`
+}
+
+// Temperature is an oven or cooking temperature in both scales
+type Temperature struct {
+	Text       string `json:"text"`
+	Celsius    int    `json:"celsius"`
+	Fahrenheit int    `json:"fahrenheit"`
+	Fan        bool   `json:"fan,omitempty"`
+}
+
+// Timer is a suggested countdown for a duration in a step
+type Timer struct {
+	Label   string `json:"label"`
+	Seconds int    `json:"seconds"`
+}
+
+var (
+	stepMarkerRe  = regexp.MustCompile(`(?i)^\s*(?:step\s*)?\d{1,2}\s*[.):\-–]?\s*$`)
+	numberingRe   = regexp.MustCompile(`(?i)^\s*(?:step\s*\d{1,2}\s*[.):\-–]?|\d{1,2}\s*[.)]|\d{1,2}\s*[-–:])\s+`)
+	sentenceEndRe = regexp.MustCompile(`[.!?]\s+`)
+	clauseRe      = regexp.MustCompile(`(?i)\s*(?:[,;]\s*(?:and\s+)?(?:then\s+)?|\s+then\s+)`)
+
+	numberWord = `\d+(?:[.,]\d+)?|an?|one|two|three|four|five|six|seven|eight|nine|ten|twelve|fifteen|twenty|thirty|forty|forty-five|sixty|half an?|a half`
+	durationRe = regexp.MustCompile(`(?i)\b(` + numberWord + `)(?:\s*(?:-|–|to|or)\s*(` + numberWord + `))?\s*(?:more\s+|further\s+|extra\s+)?(hours?|hrs?|minutes?|mins?|seconds?|secs?)\b`)
+
+	temperatureRe = regexp.MustCompile(`(?i)\b(\d{2,3})\s*(?:°|º|degrees?|deg)?\s*(c|f|celsius|fahrenheit)\b(\s*fan)?`)
+	gasMarkRe     = regexp.MustCompile(`(?i)\bgas(?:\s*mark)?\s*(\d{1,2})\b`)
+
+	numberWords = map[string]float64{
+		"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+		"seven": 7, "eight": 8, "nine": 9, "ten": 10, "twelve": 12, "fifteen": 15,
+		"twenty": 20, "thirty": 30, "forty": 40, "forty-five": 45, "sixty": 60,
+		"half a": 0.5, "half an": 0.5, "a half": 0.5,
+	}
+
+	// gasMarks maps British gas marks to Celsius
+	gasMarks = map[int]int{1: 140, 2: 150, 3: 170, 4: 180, 5: 190, 6: 200, 7: 220, 8: 230, 9: 240}
+
+	// descriptors are skipped when matching an ingredient by one of its words
+	descriptors = map[string]bool{
+		"fresh": true, "chopped": true, "ground": true, "large": true, "small": true, "medium": true,
+		"red": true, "green": true, "white": true, "black": true, "dried": true, "plain": true,
+		"extra": true, "virgin": true, "whole": true, "sliced": true, "diced": true, "minced": true,
+		"grated": true, "light": true, "dark": true, "free": true, "range": true, "boneless": true,
+		"skinless": true, "unsalted": true, "salted": true, "caster": true, "brown": true, "sweet": true,
+	}
+)
+
+// Parse splits instructions into steps. ingredients are the recipe's
+// ingredient names; each step lists the ones it mentions.
+func Parse(instructions string, ingredients []string) []Step {
+	texts := splitSteps(instructions)
+
+	steps := make([]Step, 0, len(texts))
+	for i, text := range texts {
+		step := Step{
+			Number:       i + 1,
+			Text:         text,
+			Durations:    findDurations(text),
+			Temperatures: findTemperatures(text),
+			Ingredients:  findIngredients(text, ingredients),
+			Timers:       []Timer{},
+		}
+		for _, d := range step.Durations {
+			seconds := d.Seconds
+			if d.MaxSeconds > seconds {
+				seconds = d.MaxSeconds
+			}
+			step.Timers = append(step.Timers, Timer{Label: timerLabel(text, d.Text), Seconds: seconds})
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// TotalSeconds adds up the suggested timers of all steps
+func TotalSeconds(steps []Step) int {
+	total := 0
+	for _, s := range steps {
+		for _, t := range s.Timers {
+			total += t.Seconds
+		}
+	}
+	return total
+}
+
+// splitSteps uses the line breaks of the instructions when there are any,
+// dropping "STEP 1" markers and numbering. A single paragraph is split into
+// groups of sentences.
+func splitSteps(instructions string) []string {
+	text := strings.ReplaceAll(instructions, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var chunks []string
+	// numbered marks the chunks the author numbered, which stay steps of
+	// their own however short
+	var numbered []bool
+	marked := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if stepMarkerRe.MatchString(line) {
+			marked = true
+			continue
+		}
+		stripped := strings.TrimSpace(numberingRe.ReplaceAllString(line, ""))
+		if stripped != "" {
+			chunks = append(chunks, stripped)
+			numbered = append(numbered, marked || stripped != line)
+		}
+		marked = false
+	}
+
+	if len(chunks) == 1 {
+		chunks = groupSentences(chunks[0])
+		numbered = make([]bool, len(chunks))
+	}
+
+	// Fold fragments like "Enjoy!" into the step before them
+	var merged []string
+	for i, c := range chunks {
+		if len(merged) > 0 && len(c) < minStepLength && !numbered[i] {
+			merged[len(merged)-1] += " " + c
+			continue
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+func groupSentences(paragraph string) []string {
+	var sentences []string
+	last := 0
+	for _, loc := range sentenceEndRe.FindAllStringIndex(paragraph, -1) {
+		sentences = append(sentences, strings.TrimSpace(paragraph[last:loc[1]]))
+		last = loc[1]
+	}
+	if rest := strings.TrimSpace(paragraph[last:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+
+	var groups []string
+	for i := 0; i < len(sentences); i += maxSentencesPerStep {
+		end := i + maxSentencesPerStep
+		if end > len(sentences) {
+			end = len(sentences)
+		}
+		groups = append(groups, strings.Join(sentences[i:end], " "))
+	}
+	return groups
+}
+
+func findDurations(text string) []Duration {
+	durations := []Duration{}
+	for _, m := range durationRe.FindAllStringSubmatch(text, -1) {
+		unit := unitSeconds(m[3])
+		low, ok := parseNumberWord(m[1])
+		if !ok {
+			continue
+		}
+		d := Duration{Text: m[0], Seconds: int(math.Round(low * unit))}
+		if m[2] != "" {
+			if high, ok := parseNumberWord(m[2]); ok && high > low {
+				d.MaxSeconds = int(math.Round(high * unit))
+			}
+		}
+		if d.Seconds > 0 {
+			durations = append(durations, d)
+		}
+	}
+	return durations
+}
+
+func unitSeconds(unit string) float64 {
+	switch u := strings.ToLower(unit); {
+	case strings.HasPrefix(u, "h"):
+		return 3600
+	case strings.HasPrefix(u, "m"):
+		return 60
+	}
+	return 1
+}
+
+func parseNumberWord(s string) (float64, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if v, ok := numberWords[s]; ok {
+		return v, true
+	}
+	v, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	return v, err == nil
+}
+
+func findTemperatures(text string) []Temperature {
+	temps := []Temperature{}
+	for _, m := range temperatureRe.FindAllStringSubmatch(text, -1) {
+		value, _ := strconv.Atoi(m[1])
+		t := Temperature{Text: strings.TrimSpace(m[0]), Fan: m[3] != ""}
+		if strings.HasPrefix(strings.ToLower(m[2]), "f") {
+			t.Fahrenheit = value
+			t.Celsius = int(math.Round(float64(value-32) * 5 / 9))
+		} else {
+			t.Celsius = value
+			t.Fahrenheit = int(math.Round(float64(value)*9/5 + 32))
+		}
+		temps = append(temps, t)
+	}
+	for _, m := range gasMarkRe.FindAllStringSubmatch(text, -1) {
+		mark, _ := strconv.Atoi(m[1])
+		// "180C/gas 4" gives the same temperature twice
+		if celsius, ok := gasMarks[mark]; ok && !hasCelsius(temps, celsius) {
+			temps = append(temps, Temperature{
+				Text:       m[0],
+				Celsius:    celsius,
+				Fahrenheit: int(math.Round(float64(celsius)*9/5 + 32)),
+			})
+		}
+	}
+	return temps
+}
+
+func hasCelsius(temps []Temperature, celsius int) bool {
+	for _, t := range temps {
+		if t.Celsius == celsius && !t.Fan {
+			return true
+		}
+	}
+	return false
+}
+
+// findIngredients returns the ingredients mentioned in text, by full name or
+// by one of their main words: "Plain Flour" is mentioned by "flour" and
+// "Garlic Clove" by "garlic"
+func findIngredients(text string, ingredients []string) []string {
+	lower := strings.ToLower(text)
+	found := []string{}
+	for _, ingredient := range ingredients {
+		name := strings.ToLower(strings.TrimSpace(ingredient))
+		if name == "" {
+			continue
+		}
+		if mentions(lower, name) || mentionsMainWord(lower, name) {
+			found = append(found, ingredient)
+		}
+	}
+	return found
+}
+
+func mentionsMainWord(text, name string) bool {
+	words := strings.FieldsFunc(name, func(r rune) bool { return !(r >= 'a' && r <= 'z') })
+	for i := len(words) - 1; i >= 0; i-- {
+		w := words[i]
+		if len(w) < 3 || descriptors[w] {
+			continue
+		}
+		singular := strings.TrimSuffix(w, "s")
+		if mentions(text, w) || mentions(text, singular) || mentions(text, singular+"s") {
+			return true
+		}
+	}
+	return false
+}
+
+func mentions(text, phrase string) bool {
+	for from := 0; ; {
+		i := strings.Index(text[from:], phrase)
+		if i < 0 {
+			return false
+		}
+		start, end := from+i, from+i+len(phrase)
+		if (start == 0 || !isLetter(text[start-1])) && (end == len(text) || !isLetter(text[end])) {
+			return true
+		}
+		from = end
+	}
+}
+
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// timerLabel is the clause the duration is mentioned in, shortened
+func timerLabel(text, duration string) string {
+	sentence := text
+	last := 0
+	for _, loc := range sentenceEndRe.FindAllStringIndex(text, -1) {
+		if strings.Contains(text[last:loc[1]], duration) {
+			sentence = text[last:loc[1]]
+			break
+		}
+		last = loc[1]
+	}
+	if last > 0 && sentence == text {
+		sentence = text[last:]
+	}
+
+	for _, clause := range clauseRe.Split(sentence, -1) {
+		if strings.Contains(clause, duration) {
+			sentence = clause
+			break
+		}
+	}
+
+	sentence = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(sentence), ".!?"))
+	if sentence != "" {
+		r, size := utf8.DecodeRuneInString(sentence)
+		sentence = string(unicode.ToUpper(r)) + sentence[size:]
+	}
+	if len(sentence) > maxTimerLabel {
+		cut := strings.LastIndex(sentence[:maxTimerLabel], " ")
+		if cut <= 0 {
+			cut = maxTimerLabel
+		}
+		sentence = sentence[:cut] + "…"
+	}
+	return sentence
+}
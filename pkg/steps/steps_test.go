@@ -0,0 +1,146 @@
+package steps
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSteps(t *testing.T) {
+	tests := []struct {
+		name         string
+		instructions string
+		want         []string
+	}{
+		{
+			name:         "lines",
+			instructions: "Heat the oil in a large pan.\r\nFry the onion until soft and golden.",
+			want:         []string{"Heat the oil in a large pan.", "Fry the onion until soft and golden."},
+		},
+		{
+			name:         "step markers",
+			instructions: "STEP 1\nHeat the oven to 180C.\nSTEP 2\nBake until golden on top.",
+			want:         []string{"Heat the oven to 180C.", "Bake until golden on top."},
+		},
+		{
+			name:         "numbered short steps stay apart",
+			instructions: "1. Brown the beef in batches.\n2. Simmer 20 min\n3. Serve.",
+			want:         []string{"Brown the beef in batches.", "Simmer 20 min", "Serve."},
+		},
+		{
+			name:         "short step after a marker stays apart",
+			instructions: "Step 1\nBrown the beef in batches.\nStep 2\nSimmer 20 min",
+			want:         []string{"Brown the beef in batches.", "Simmer 20 min"},
+		},
+		{
+			name:         "unnumbered fragment joins the step before",
+			instructions: "Spoon into bowls and top with cheese.\nEnjoy!",
+			want:         []string{"Spoon into bowls and top with cheese. Enjoy!"},
+		},
+		{
+			name:         "paragraph",
+			instructions: "Heat the oil. Fry the onion. Add the garlic. Stir in the tomatoes.",
+			want:         []string{"Heat the oil. Fry the onion.", "Add the garlic. Stir in the tomatoes."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSteps(tt.instructions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSteps() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindDurations(t *testing.T) {
+	tests := []struct {
+		text string
+		want []Duration
+	}{
+		{"Simmer for 20 minutes.", []Duration{{Text: "20 minutes", Seconds: 1200}}},
+		{"Bake for 20-25 mins", []Duration{{Text: "20-25 mins", Seconds: 1200, MaxSeconds: 1500}}},
+		{"Roast for 1.5 hours", []Duration{{Text: "1.5 hours", Seconds: 5400}}},
+		{"Leave for half an hour", []Duration{{Text: "half an hour", Seconds: 1800}}},
+		{"Cook for two to three minutes more", []Duration{{Text: "two to three minutes", Seconds: 120, MaxSeconds: 180}}},
+		{"Cook for 5 more minutes", []Duration{{Text: "5 more minutes", Seconds: 300}}},
+		{"Boil 30 secs, then rest 10 mins", []Duration{{Text: "30 secs", Seconds: 30}, {Text: "10 mins", Seconds: 600}}},
+		{"Serve at once.", []Duration{}},
+	}
+
+	for _, tt := range tests {
+		if got := findDurations(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findDurations(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFindTemperatures(t *testing.T) {
+	tests := []struct {
+		text string
+		want []Temperature
+	}{
+		{"Heat the oven to 180C.", []Temperature{{Text: "180C", Celsius: 180, Fahrenheit: 356}}},
+		{"Heat the oven to 350°F.", []Temperature{{Text: "350°F", Celsius: 177, Fahrenheit: 350}}},
+		{"Heat the oven to 160C fan.", []Temperature{{Text: "160C fan", Celsius: 160, Fahrenheit: 320, Fan: true}}},
+		{"Heat the oven to gas mark 4.", []Temperature{{Text: "gas mark 4", Celsius: 180, Fahrenheit: 356}}},
+		// The gas mark repeats the temperature before it
+		{"Heat the oven to 180C/gas 4.", []Temperature{{Text: "180C", Celsius: 180, Fahrenheit: 356}}},
+		{"Heat the oven to 200C/180C fan/gas 6.", []Temperature{
+			{Text: "200C", Celsius: 200, Fahrenheit: 392},
+			{Text: "180C fan", Celsius: 180, Fahrenheit: 356, Fan: true},
+		}},
+		{"Use a 20cm tin and gas 12.", []Temperature{}},
+	}
+
+	for _, tt := range tests {
+		if got := findTemperatures(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findTemperatures(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFindIngredients(t *testing.T) {
+	ingredients := []string{"Plain Flour", "Garlic Clove", "Olive Oil", "Red Onions", "Chopped Tomatoes", "Salt"}
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Sift the flour into a bowl.", []string{"Plain Flour"}},
+		{"Crush the garlic.", []string{"Garlic Clove"}},
+		{"Heat the olive oil and fry the onion.", []string{"Olive Oil", "Red Onions"}},
+		{"Add the tomatoes and season with salt.", []string{"Chopped Tomatoes", "Salt"}},
+		// Words only count when whole and descriptors never do
+		{"Add the shallots and the chopped parsley.", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := findIngredients(tt.text, ingredients); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findIngredients(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	steps := Parse("1. Heat the oven to 200C/gas 6.\n2. Rub the chicken with oil and roast for 40-45 minutes, then rest for 10 mins.", []string{"Chicken Thighs", "Olive Oil"})
+
+	if len(steps) != 2 {
+		t.Fatalf("Parse() returned %d steps, want 2", len(steps))
+	}
+	if steps[1].Number != 2 {
+		t.Errorf("Number = %d, want 2", steps[1].Number)
+	}
+	if want := []string{"Chicken Thighs", "Olive Oil"}; !reflect.DeepEqual(steps[1].Ingredients, want) {
+		t.Errorf("Ingredients = %q, want %q", steps[1].Ingredients, want)
+	}
+	want := []Timer{
+		{Label: "Rub the chicken with oil and roast for 40-45 minutes", Seconds: 2700},
+		{Label: "Rest for 10 mins", Seconds: 600},
+	}
+	if !reflect.DeepEqual(steps[1].Timers, want) {
+		t.Errorf("Timers = %+v, want %+v", steps[1].Timers, want)
+	}
+	if got := TotalSeconds(steps); got != 3300 {
+		t.Errorf("TotalSeconds() = %d, want 3300", got)
+	}
+}